@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/conallob/jira-beads-sync/internal/graph"
+)
+
+// analyzeWeight is what the critical path is weighed by
+var analyzeWeight string
+
+// registerAnalyzeFlags adds the analyze command's options
+func registerAnalyzeFlags(fs *flag.FlagSet) {
+	fs.StringVar(&analyzeWeight, "weight", "count", "weigh the critical path by issue count or story points: count or points")
+}
+
+// runAnalyze lists the issues ready to work on, what the rest wait on, a
+// work order and the critical path, for .beads or issues fetched from Jira
+func runAnalyze(e *env, args []string) error {
+	weight, err := graph.ParseWeight(analyzeWeight)
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+
+	export, err := scopeExport(e, args)
+	if err != nil {
+		return err
+	}
+
+	g := graph.New(export)
+	analysis := graph.Analyze(export, weight)
+	result.Data = analysis
+	result.count("ready", len(analysis.Ready))
+	result.count("blocked", len(analysis.Blocked))
+	result.count("critical_path", len(analysis.CriticalPath))
+
+	e.println("jira-beads-sync analyze")
+	e.println("=======================")
+	e.println()
+
+	describe := func(id string) string {
+		if node := g.Node(id); node != nil && node.Title != "" {
+			return fmt.Sprintf("%s %s", id, node.Title)
+		}
+		return id
+	}
+
+	e.printf("Ready (%d):\n", len(analysis.Ready))
+	for _, id := range analysis.Ready {
+		e.printf("  %s\n", describe(id))
+	}
+	e.println()
+
+	e.printf("Blocked (%d):\n", len(analysis.Blocked))
+	for _, node := range g.Nodes {
+		chain, ok := analysis.Blocked[node.ID]
+		if !ok {
+			continue
+		}
+		waits := strings.Join(chain, " → ")
+		if external := analysis.External[node.ID]; len(external) > 0 {
+			if waits != "" {
+				waits += ", and "
+			}
+			waits += strings.Join(external, ", ") + " (not analyzed)"
+		}
+		e.printf("  %s\n    waits on %s\n", describe(node.ID), waits)
+	}
+	e.println()
+
+	e.printf("Work order (%d):\n", len(analysis.Order))
+	for i, id := range analysis.Order {
+		e.printf("  %d. %s\n", i+1, describe(id))
+	}
+	if len(analysis.Cyclic) > 0 {
+		e.printf("⚠ In or behind a dependency cycle, so left out (%d): %s\n", len(analysis.Cyclic), strings.Join(analysis.Cyclic, ", "))
+	}
+	e.println()
+
+	if len(analysis.CriticalPath) == 0 {
+		e.println("Critical path: none, everything is closed")
+		return nil
+	}
+	length := fmt.Sprintf("%d issues", len(analysis.CriticalPath))
+	if strings.EqualFold(analyzeWeight, "points") {
+		length = fmt.Sprintf("%s story points, %s", strconv.FormatFloat(analysis.Length, 'f', -1, 64), length)
+	}
+	e.printf("Critical path (%s):\n  %s\n", length, strings.Join(analysis.CriticalPath, " → "))
+	return nil
+}
@@ -0,0 +1,539 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/conallob/jira-beads-sync/internal/config"
+	"github.com/conallob/jira-beads-sync/internal/progress"
+)
+
+// Exit codes
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2 // Bad command line
+	exitAuth     = 3 // Credentials rejected or not logged in
+	exitNotFound = 4 // A requested issue, filter or board doesn't exist
+	exitPartial  = 5 // Finished, but some issues failed
+	exitConflict = 6 // Jira changed in a way that conflicts with local changes
+	exitInvalid  = 7 // Converted issues failed validation with --validate fail
+)
+
+// Global options, accepted before the command or among its arguments
+var (
+	// profileName is the config profile selected with --profile
+	profileName string
+	// outputDir is the directory to work in, selected with --output-dir or -C
+	outputDir string
+	// outputFormat is text or json, selected with --output
+	outputFormat = outputText
+	// quiet and verbosity select how much is logged with -q, -v and -vv
+	quiet       bool
+	verbose     bool
+	veryVerbose bool
+	// validateMode overrides validation.mode from the config, selected with
+	// --validate
+	validateMode string
+)
+
+// reporter logs progress from the Jira client and beads renderer to stderr
+var reporter = progress.NewLogger(os.Stderr, slog.LevelInfo, false)
+
+// command is a CLI subcommand
+type command struct {
+	name    string
+	aliases []string
+	args    string // Argument synopsis, e.g. "<issue-id> <repository>"
+	summary string
+	minArgs int
+	maxArgs int // -1 for no limit
+
+	// flags registers command-specific flags, if any
+	flags func(fs *flag.FlagSet)
+	run   func(e *env, args []string) error
+}
+
+// env is what a command runs in. It's passed down rather than changing the
+// working directory or os.Stdout, which belong to the whole process.
+type env struct {
+	// dir holds .beads; the project config is found and relative paths are
+	// resolved from it
+	dir string
+	// out takes messages and progress: stdout, stderr with --output json,
+	// or nowhere with -q
+	out io.Writer
+	// data takes what a command produces, such as a diff: stdout, or
+	// stderr with --output json
+	data io.Writer
+	// aside takes messages while data is on stdout: stderr, or nowhere
+	// with -q
+	aside io.Writer
+	// start is when the command started, before anything was fetched
+	start time.Time
+}
+
+// path resolves a path given on the command line against e.dir
+func (e *env) path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(e.dir, p)
+}
+
+// printf writes a message to e.out
+func (e *env) printf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(e.out, format, args...)
+}
+
+// println writes a message line to e.out
+func (e *env) println(args ...interface{}) {
+	_, _ = fmt.Fprintln(e.out, args...)
+}
+
+// withOut returns a copy of e printing messages to out
+func (e *env) withOut(out io.Writer) *env {
+	copied := *e
+	copied.out = out
+	return &copied
+}
+
+// usageError is a bad command line, reported with the command's usage
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// commands lists the subcommands in the order shown by help
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{
+			name: "quickstart", aliases: []string{"fetch"}, args: "<jira-url|issue-key>",
+			summary: "Fetch an issue, filter, search, board or sprint",
+			minArgs: 1, maxArgs: 1,
+			run: func(e *env, args []string) error { return runQuickstart(e, args[0]) },
+		},
+		{
+			name: "fetch-by-label", aliases: []string{"label"}, args: "<label>",
+			summary: "Fetch all issues with label from Jira",
+			minArgs: 1, maxArgs: 1,
+			run: func(e *env, args []string) error { return runFetchByLabel(e, args[0]) },
+		},
+		{
+			name: "fetch-jql", aliases: []string{"jql"}, args: "<jql|saved-query>",
+			summary: "Fetch all issues matching a JQL query",
+			minArgs: 1, maxArgs: -1,
+			run: func(e *env, args []string) error { return runFetchJQL(e, strings.Join(args, " ")) },
+		},
+		{
+			name: "fetch-filter", aliases: []string{"filter"}, args: "<id|url>",
+			summary: "Fetch all issues matched by a saved filter",
+			minArgs: 1, maxArgs: 1,
+			run: func(e *env, args []string) error { return runFetchFilter(e, args[0]) },
+		},
+		{
+			name: "fetch-sprint", aliases: []string{"sprint"}, args: "<board> [name]",
+			summary: "Fetch the active (or named) sprint of a board",
+			minArgs: 1, maxArgs: -1,
+			run: func(e *env, args []string) error { return runFetchSprint(e, args[0], strings.Join(args[1:], " ")) },
+		},
+		{
+			name: "fetch-backlog", aliases: []string{"backlog"}, args: "<board>",
+			summary: "Fetch the backlog of a board",
+			minArgs: 1, maxArgs: 1,
+			run: func(e *env, args []string) error { return runFetchBacklog(e, args[0]) },
+		},
+		{
+			name: "watch", args: "[saved-query|jql...]",
+			summary: "Keep .beads in sync with Jira queries on an interval",
+			maxArgs: -1,
+			flags:   registerWatchFlags,
+			run:     runWatch,
+		},
+		{
+			name:    "serve",
+			summary: "Apply Jira webhooks to .beads as they arrive",
+			flags:   registerServeFlags,
+			run:     func(e *env, args []string) error { return runServe(e) },
+		},
+		{
+			name:    "mcp",
+			summary: "Serve fetch, annotate and push as MCP tools over stdio",
+			run:     func(e *env, args []string) error { return runMCP(e) },
+		},
+		{
+			name:    "status",
+			summary: "Show how up to date .beads is, without contacting Jira",
+			run:     func(e *env, args []string) error { return runStatus(e) },
+		},
+		{
+			name: "diff", args: "[issue-key|jira-url|saved-query|jql...]",
+			summary: "Show what importing from Jira would change in .beads, without writing",
+			maxArgs: -1,
+			run:     runDiff,
+		},
+		{
+			name: "graph", args: "[issue-key|jira-url|saved-query|jql...]",
+			summary: "Render issues, epics and dependencies as Graphviz DOT or Mermaid",
+			maxArgs: -1,
+			flags:   registerGraphFlags,
+			run:     runGraph,
+		},
+		{
+			name: "analyze", aliases: []string{"plan"}, args: "[issue-key|jira-url|saved-query|jql...]",
+			summary: "List ready and blocked issues, a work order and the critical path",
+			maxArgs: -1,
+			flags:   registerAnalyzeFlags,
+			run:     runAnalyze,
+		},
+		{
+			name:    "reconcile",
+			summary: "Close, tombstone or delete issues deleted or moved in Jira",
+			flags:   registerReconcileFlags,
+			run:     func(e *env, args []string) error { return runReconcile(e) },
+		},
+		{
+			name: "annotate", args: "<issue-id> <repository>",
+			summary: "Annotate issue with repository info",
+			minArgs: 2, maxArgs: 2,
+			run: func(e *env, args []string) error { return runAnnotate(e, args[0], args[1]) },
+		},
+		{
+			name: "log-work", args: "<issue-id> <time> [comment]",
+			summary: "Record time spent locally (e.g. 1h 30m)",
+			minArgs: 2, maxArgs: -1,
+			run: func(e *env, args []string) error { return runLogWork(e, args[0], args[1], strings.Join(args[2:], " ")) },
+		},
+		{
+			name:    "push-worklogs",
+			summary: "Push locally recorded time to Jira",
+			run:     func(e *env, args []string) error { return runPushWorklogs(e) },
+		},
+		{
+			name: "convert", args: "<jira-export-file>",
+			summary: "Convert Jira export to beads format",
+			minArgs: 1, maxArgs: 1,
+			run: func(e *env, args []string) error { return runConvert(e, args[0]) },
+		},
+		{
+			name:    "configure",
+			summary: "Configure Jira credentials",
+			run:     func(e *env, args []string) error { return runConfigure(e) },
+		},
+		{
+			name: "config", args: "[show]",
+			summary: "Show the effective configuration and its sources",
+			maxArgs: 1,
+			run: func(e *env, args []string) error {
+				if len(args) == 0 {
+					return runConfigure(e)
+				}
+				if args[0] != "show" {
+					return &usageError{fmt.Sprintf("unknown config subcommand %q", args[0])}
+				}
+				return runConfigShow(e)
+			},
+		},
+		{
+			name:    "login",
+			summary: "Authorize with Jira Cloud via OAuth 2.0",
+			run:     func(e *env, args []string) error { return runLogin(e) },
+		},
+		{
+			name:    "whoami",
+			summary: "Test Jira authentication and show user info",
+			run:     func(e *env, args []string) error { return runWhoami(e) },
+		},
+		{
+			name:    "version",
+			summary: "Show version information",
+			run: func(e *env, args []string) error {
+				e.printf("jira-beads-sync %s\n", version)
+				e.printf("  commit: %s\n", commit)
+				e.printf("  built:  %s\n", date)
+				result.Data = map[string]string{"version": version, "commit": commit, "date": date}
+				return nil
+			},
+		},
+	}
+}
+
+// findCommand looks up a command by name or alias
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+		for _, alias := range cmd.aliases {
+			if alias == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+// registerGlobalFlags adds the options every command accepts
+func registerGlobalFlags(fs *flag.FlagSet) {
+	fs.StringVar(&profileName, "profile", profileName, "use the named Jira `profile` from the config file")
+	fs.StringVar(&outputDir, "output-dir", outputDir, "work in `dir` (reading config and writing .beads there) instead of the current directory")
+	fs.StringVar(&outputDir, "C", outputDir, "shorthand for --output-dir `dir`")
+	fs.StringVar(&outputFormat, "output", outputFormat, "output `format`: text, or json for a single machine-readable result on stdout")
+	fs.BoolVar(&quiet, "quiet", quiet, "only print warnings and errors")
+	fs.BoolVar(&quiet, "q", quiet, "shorthand for --quiet")
+	fs.BoolVar(&verbose, "v", verbose, "log each issue fetched and file written")
+	fs.BoolVar(&veryVerbose, "vv", veryVerbose, "also log every HTTP request")
+	fs.StringVar(&validateMode, "validate", validateMode, "what to do about dangling references and dependency cycles before writing .beads: keep, drop or fail (`mode`)")
+}
+
+// newReporter creates the reporter for the selected verbosity, drawing a
+// progress bar when stderr is a terminal
+func newReporter() *progress.Logger {
+	level := slog.LevelInfo
+	switch {
+	case quiet:
+		level = slog.LevelWarn
+	case veryVerbose:
+		level = progress.LevelTrace
+	case verbose:
+		level = slog.LevelDebug
+	}
+	return progress.NewLogger(os.Stderr, level, !quiet && progress.IsTerminal(os.Stderr))
+}
+
+// run parses the command line and runs the command, returning the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("jira-beads-sync", flag.ContinueOnError)
+	global.SetOutput(io.Discard)
+	registerGlobalFlags(global)
+
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printUsage(stdout)
+			return exitOK
+		}
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n\n", err)
+		printUsage(stderr)
+		return exitUsage
+	}
+
+	args = global.Args()
+	if len(args) == 0 {
+		printUsage(stderr)
+		return exitUsage
+	}
+
+	name, args := args[0], args[1:]
+	if name == "help" {
+		return runHelp(args, stdout, stderr)
+	}
+
+	cmd := findCommand(name)
+	if cmd == nil {
+		_, _ = fmt.Fprintf(stderr, "Error: unknown command %q\n\n", name)
+		printUsage(stderr)
+		return exitUsage
+	}
+
+	fs := cmd.flagSet()
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			cmd.printHelp(stdout)
+			return exitOK
+		}
+		return usageFailure(cmd, err, stderr)
+	}
+
+	// The configured format applies unless --output is given
+	if !flagGiven(global, "output") && !flagGiven(fs, "output") {
+		outputFormat = configuredOutputFormat()
+	}
+	if outputFormat != outputText && outputFormat != outputJSON {
+		return usageFailure(cmd, fmt.Errorf("invalid output format %q (use text or json)", outputFormat), stderr)
+	}
+
+	if len(positional) < cmd.minArgs {
+		return usageFailure(cmd, fmt.Errorf("%s requires %s", cmd.name, cmd.args), stderr)
+	}
+	if cmd.maxArgs >= 0 && len(positional) > cmd.maxArgs {
+		return usageFailure(cmd, fmt.Errorf("too many arguments for %s", cmd.name), stderr)
+	}
+
+	result = &report{Command: cmd.name}
+	reporter = newReporter()
+	defer reporter.Done()
+	e, err := newEnv(stdout, stderr)
+	if err == nil {
+		err = cmd.run(e, positional)
+	}
+	code := result.finish(err)
+
+	if outputFormat == outputJSON {
+		if writeErr := result.write(stdout); writeErr != nil {
+			_, _ = fmt.Fprintf(stderr, "Error: failed to write result: %v\n", writeErr)
+		}
+		return code
+	}
+
+	if code == exitUsage {
+		return usageFailure(cmd, err, stderr)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+	}
+	return code
+}
+
+// flagGiven reports whether the named flag was set on the command line
+func flagGiven(fs *flag.FlagSet, name string) bool {
+	given := false
+	fs.Visit(func(f *flag.Flag) {
+		given = given || f.Name == name
+	})
+	return given
+}
+
+// configuredOutputFormat returns the output format set in the config for
+// the selected directory, or text. Errors loading the config are left for
+// the command to report.
+func configuredOutputFormat() string {
+	dir := outputDir
+	if dir != "" {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+	}
+	if cfg, err := config.LoadProfile(dir, profileName); err == nil && cfg.Output.Format != "" {
+		return cfg.Output.Format
+	}
+	return outputText
+}
+
+// newEnv creates the env for the selected output directory and format
+func newEnv(stdout, stderr io.Writer) (*env, error) {
+	e := &env{out: stdout, data: stdout, aside: stderr, start: time.Now()}
+	if outputFormat == outputJSON {
+		e.out, e.data = stderr, stderr
+	}
+	if quiet {
+		e.out, e.aside = io.Discard, io.Discard
+	}
+
+	if outputDir == "" {
+		dir, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current directory: %w", err)
+		}
+		e.dir = dir
+		return e, nil
+	}
+	dir, err := filepath.Abs(outputDir)
+	if err == nil {
+		var info os.FileInfo
+		if info, err = os.Stat(dir); err == nil && !info.IsDir() {
+			err = fmt.Errorf("%s is not a directory", dir)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to use output directory: %w", err)
+	}
+	e.dir = dir
+	return e, nil
+}
+
+// runHelp shows general help, or help for one command
+func runHelp(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stdout)
+		return exitOK
+	}
+
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		_, _ = fmt.Fprintf(stderr, "Error: unknown command %q\n\n", args[0])
+		printUsage(stderr)
+		return exitUsage
+	}
+
+	cmd.printHelp(stdout)
+	return exitOK
+}
+
+// usageFailure reports a bad command line for cmd
+func usageFailure(cmd *command, err error, stderr io.Writer) int {
+	_, _ = fmt.Fprintf(stderr, "Error: %v\n\n", err)
+	cmd.printHelp(stderr)
+	return exitUsage
+}
+
+// flagSet returns the flags cmd accepts
+func (cmd *command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	registerGlobalFlags(fs)
+	return fs
+}
+
+// synopsis returns the command name followed by its arguments
+func (cmd *command) synopsis() string {
+	if cmd.args == "" {
+		return cmd.name
+	}
+	return cmd.name + " " + cmd.args
+}
+
+// printHelp shows the command's usage, aliases and flags
+func (cmd *command) printHelp(w io.Writer) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Usage: jira-beads-sync %s [options] %s\n\n", cmd.name, cmd.args)
+	fmt.Fprintln(&b, cmd.summary)
+	if len(cmd.aliases) > 0 {
+		fmt.Fprintf(&b, "\nAliases: %s\n", strings.Join(cmd.aliases, ", "))
+	}
+	fmt.Fprintln(&b, "\nOptions:")
+	fs := cmd.flagSet()
+	fs.SetOutput(&b)
+	fs.PrintDefaults()
+
+	_, _ = io.WriteString(w, b.String())
+}
+
+// parseInterspersed parses flags wherever they appear among the arguments,
+// returning the positional arguments. Everything after "--" is positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		rest := fs.Args()
+		consumed := args[:len(args)-len(rest)]
+		if len(consumed) > 0 && consumed[len(consumed)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
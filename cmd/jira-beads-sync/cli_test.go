@@ -0,0 +1,798 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+	"github.com/conallob/jira-beads-sync/internal/diff"
+	"github.com/conallob/jira-beads-sync/internal/graph"
+	"github.com/conallob/jira-beads-sync/internal/jira"
+)
+
+// resetGlobalFlags restores the global flags a test's run() set
+func resetGlobalFlags() {
+	profileName, outputDir, outputFormat = "", "", outputText
+	quiet, verbose, veryVerbose = false, false, false
+	validateMode = ""
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "no command",
+			args:       nil,
+			wantCode:   exitUsage,
+			wantStderr: "Usage:",
+		},
+		{
+			name:       "help",
+			args:       []string{"help"},
+			wantCode:   exitOK,
+			wantStdout: "jira-beads-sync quickstart <jira-url|issue-key>",
+		},
+		{
+			name:       "global help flag",
+			args:       []string{"--help"},
+			wantCode:   exitOK,
+			wantStdout: "Global Options:",
+		},
+		{
+			name:       "help for a command",
+			args:       []string{"help", "fetch"},
+			wantCode:   exitOK,
+			wantStdout: "Usage: jira-beads-sync quickstart [options] <jira-url|issue-key>",
+		},
+		{
+			name:       "command help flag",
+			args:       []string{"annotate", "--help"},
+			wantCode:   exitOK,
+			wantStdout: "-output-dir dir",
+		},
+		{
+			name:       "unknown command",
+			args:       []string{"frobnicate"},
+			wantCode:   exitUsage,
+			wantStderr: `unknown command "frobnicate"`,
+		},
+		{
+			name:       "missing arguments",
+			args:       []string{"annotate", "PROJ-1"},
+			wantCode:   exitUsage,
+			wantStderr: "annotate requires <issue-id> <repository>",
+		},
+		{
+			name:       "too many arguments",
+			args:       []string{"whoami", "extra"},
+			wantCode:   exitUsage,
+			wantStderr: "too many arguments for whoami",
+		},
+		{
+			name:       "unknown flag",
+			args:       []string{"whoami", "--frobnicate"},
+			wantCode:   exitUsage,
+			wantStderr: "flag provided but not defined: -frobnicate",
+		},
+		{
+			name:       "unknown config subcommand",
+			args:       []string{"config", "edit"},
+			wantCode:   exitUsage,
+			wantStderr: `unknown config subcommand "edit"`,
+		},
+		{
+			name:       "invalid output format",
+			args:       []string{"version", "--output", "yaml"},
+			wantCode:   exitUsage,
+			wantStderr: `invalid output format "yaml"`,
+		},
+		{
+			name:       "non-numeric filter ID",
+			args:       []string{"fetch-filter", "12345/../../myself"},
+			wantCode:   exitUsage,
+			wantStderr: `invalid filter ID "12345/../../myself"`,
+		},
+		{
+			name:       "not a filter URL",
+			args:       []string{"fetch-filter", "https://jira.example.com/browse/PROJ-1"},
+			wantCode:   exitUsage,
+			wantStderr: "not a saved filter URL",
+		},
+		{
+			name:       "missing output directory",
+			args:       []string{"-C", "/nonexistent/dir", "version"},
+			wantCode:   exitError,
+			wantStderr: "failed to use output directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(resetGlobalFlags)
+
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, &stdout, &stderr)
+
+			if code != tt.wantCode {
+				t.Errorf("Expected exit code %d, got %d (stderr: %s)", tt.wantCode, code, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("Expected stdout to contain %q, got:\n%s", tt.wantStdout, stdout.String())
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("Expected stderr to contain %q, got:\n%s", tt.wantStderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunOutputDir(t *testing.T) {
+	t.Cleanup(resetGlobalFlags)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	original, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+
+	// The export is given relative to the output directory, as with git -C
+	dir := t.TempDir()
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "sample-jira-export.json"))
+	if err != nil {
+		t.Fatalf("Failed to read sample export: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "export.json"), data, 0644); err != nil {
+		t.Fatalf("Failed to write export: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"convert", "export.json", "--output-dir", dir}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected success, got %d: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".beads", "issues.jsonl")); err != nil {
+		t.Errorf("Expected .beads in the output directory: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "Conversion complete") {
+		t.Errorf("Expected progress on the given stdout, got %q", stdout.String())
+	}
+
+	// The process's working directory is left alone
+	if cwd, err := os.Getwd(); err != nil || cwd != original {
+		t.Errorf("Expected to stay in %s, now in %s", original, cwd)
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantArgs    string
+		wantProfile string
+	}{
+		{
+			name:     "no flags",
+			args:     []string{"PROJ-1"},
+			wantArgs: "PROJ-1",
+		},
+		{
+			name:        "flag before arguments",
+			args:        []string{"--profile", "work", "PROJ-1"},
+			wantArgs:    "PROJ-1",
+			wantProfile: "work",
+		},
+		{
+			name:        "flag after arguments",
+			args:        []string{"42", "Sprint", "--profile=onprem", "23"},
+			wantArgs:    "42 Sprint 23",
+			wantProfile: "onprem",
+		},
+		{
+			name:     "arguments after double dash",
+			args:     []string{"--", "status", "-profile"},
+			wantArgs: "status -profile",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(resetGlobalFlags)
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			registerGlobalFlags(fs)
+
+			args, err := parseInterspersed(fs, tt.args)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if got := strings.Join(args, " "); got != tt.wantArgs {
+				t.Errorf("Expected args %q, got %q", tt.wantArgs, got)
+			}
+			if profileName != tt.wantProfile {
+				t.Errorf("Expected profile %q, got %q", tt.wantProfile, profileName)
+			}
+		})
+	}
+}
+
+// newJiraServer serves PROJ-1, which blocks PROJ-2 and the deleted GONE-1,
+// and rejects requests without the right token
+func newJiraServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, password, _ := r.BasicAuth(); password != "token123" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		key := strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/")
+		if key != "PROJ-1" && key != "PROJ-2" {
+			http.Error(w, `{"errorMessages":["Issue does not exist"]}`, http.StatusNotFound)
+			return
+		}
+
+		var links string
+		if key == "PROJ-1" {
+			links = `{"type":{"name":"Blocks"},"outwardIssue":{"key":"PROJ-2"}},
+				{"type":{"name":"Blocks"},"outwardIssue":{"key":"GONE-1"}}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"key":%q,"id":"1","fields":{"summary":"Issue %s",
+			"issuetype":{"name":"Task"},"status":{"name":"Open","statusCategory":{"key":"new"}},
+			"priority":{"name":"Medium"},"created":"2024-01-01T10:00:00.000+0000",
+			"updated":"2024-01-01T10:00:00.000+0000","issuelinks":[%s]}}`, key, key, links)
+	}))
+}
+
+func TestRunJSONOutput(t *testing.T) {
+	server := newJiraServer(t)
+	defer server.Close()
+
+	t.Cleanup(resetGlobalFlags)
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("JIRA_PROFILE", "")
+	t.Setenv("JIRA_BASE_URL", server.URL)
+	t.Setenv("JIRA_USERNAME", "user@example.com")
+
+	tests := []struct {
+		name       string
+		token      string
+		key        string
+		wantCode   int
+		wantStatus string
+		wantErrors int
+	}{
+		{name: "partial", token: "token123", key: "PROJ-1", wantCode: exitPartial, wantStatus: "partial", wantErrors: 1},
+		{name: "complete", token: "token123", key: "PROJ-2", wantCode: exitOK, wantStatus: "ok"},
+		{name: "not found", token: "token123", key: "NOPE-1", wantCode: exitNotFound, wantStatus: "error"},
+		{name: "unauthorized", token: "wrong", key: "PROJ-1", wantCode: exitAuth, wantStatus: "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JIRA_API_TOKEN", tt.token)
+			outputFormat = outputText
+
+			var stdout, stderr bytes.Buffer
+			code := run([]string{"quickstart", tt.key, "--output", "json", "-C", t.TempDir()}, &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Errorf("Expected exit code %d, got %d (stderr: %s)", tt.wantCode, code, stderr.String())
+			}
+
+			var got report
+			if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+				t.Fatalf("Expected a single JSON result on stdout, got %q: %v", stdout.String(), err)
+			}
+			if got.Status != tt.wantStatus || got.ExitCode != tt.wantCode {
+				t.Errorf("Expected status %s (%d), got %s (%d)", tt.wantStatus, tt.wantCode, got.Status, got.ExitCode)
+			}
+			if len(got.Errors) != tt.wantErrors {
+				t.Errorf("Expected %d issue errors, got %v", tt.wantErrors, got.Errors)
+			}
+			if tt.wantStatus != "error" && len(got.Files) == 0 {
+				t.Error("Expected written files to be reported")
+			}
+			if tt.name == "partial" && (got.Counts["issues"] != 2 || got.Errors[0].Key != "GONE-1") {
+				t.Errorf("Expected 2 issues and GONE-1 to fail, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestRunWatchOnce(t *testing.T) {
+	issues := newJiraServer(t)
+	defer issues.Close()
+	renamed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rest/api/2/search" {
+			_, _ = io.WriteString(w, `{"issues":[{"key":"PROJ-2"}],"total":1}`)
+			return
+		}
+		if !renamed {
+			issues.Config.Handler.ServeHTTP(w, r)
+			return
+		}
+		recorder := httptest.NewRecorder()
+		issues.Config.Handler.ServeHTTP(recorder, r)
+		_, _ = io.WriteString(w, strings.Replace(recorder.Body.String(), `"Issue PROJ-2"`, `"Issue PROJ-2, renamed"`, 1))
+	}))
+	defer server.Close()
+
+	t.Cleanup(resetGlobalFlags)
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("JIRA_PROFILE", "")
+	t.Setenv("JIRA_BASE_URL", server.URL)
+	t.Setenv("JIRA_USERNAME", "user@example.com")
+	t.Setenv("JIRA_API_TOKEN", "token123")
+
+	dir := t.TempDir()
+	watch := func(args ...string) (int, report) {
+		t.Helper()
+		resetGlobalFlags()
+		var stdout, stderr bytes.Buffer
+		code := run(append([]string{"watch", "--once", "--output", "json", "-C", dir}, args...), &stdout, &stderr)
+		var got report
+		if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+			t.Fatalf("Expected JSON on stdout, got %q (stderr: %s)", stdout.String(), stderr.String())
+		}
+		return code, got
+	}
+
+	if code, _ := watch(); code != exitUsage {
+		t.Errorf("Expected exit code %d without queries, got %d", exitUsage, code)
+	}
+
+	code, got := watch("project = PROJ")
+	if code != exitOK || got.Counts["added"] != 1 {
+		t.Errorf("Expected one issue added, got exit code %d and %+v", code, got)
+	}
+
+	// A local edit to an issue changed in Jira too is a conflict
+	issuesFile := filepath.Join(dir, ".beads", "issues.jsonl")
+	data, err := os.ReadFile(issuesFile)
+	if err != nil {
+		t.Fatalf("Failed to read issues: %v", err)
+	}
+	edited := strings.Replace(string(data), `"status":"open"`, `"status":"in_progress"`, 1)
+	if err := os.WriteFile(issuesFile, []byte(edited), 0644); err != nil {
+		t.Fatalf("Failed to edit issues: %v", err)
+	}
+
+	if code, got := watch("project = PROJ"); code != exitOK {
+		t.Errorf("Expected no conflict while Jira is unchanged, got exit code %d and %+v", code, got)
+	}
+
+	renamed = true
+	code, got = watch("project = PROJ")
+	if code != exitConflict || !strings.Contains(got.Error, "proj-2") {
+		t.Errorf("Expected a conflict on proj-2, got exit code %d and %+v", code, got)
+	}
+}
+
+func TestRunMCP(t *testing.T) {
+	server := newJiraServer(t)
+	defer server.Close()
+	// Another Jira, which no profile is configured for, must not be sent the token
+	otherRequests := 0
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherRequests++
+		http.NotFound(w, r)
+	}))
+	defer other.Close()
+
+	t.Cleanup(func() {
+		resetGlobalFlags()
+		mcpStdin, mcpStdout = os.Stdin, os.Stdout
+	})
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("JIRA_PROFILE", "")
+	t.Setenv("JIRA_BASE_URL", server.URL)
+	t.Setenv("JIRA_USERNAME", "user@example.com")
+	t.Setenv("JIRA_API_TOKEN", "token123")
+
+	requests := []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"fetch_issue_tree","arguments":{"issue":"PROJ-1"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"annotate_issue","arguments":{"issue_id":"proj-1","repository":"https://github.com/org/repo"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"sync_status"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"fetch_issue_tree","arguments":{"issue":"PROJ-404"}}}`,
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"fetch_issue_tree","arguments":{"issue":"%s/browse/PROJ-1"}}}`, other.URL),
+	}
+	var stdout bytes.Buffer
+	mcpStdin = strings.NewReader(strings.Join(requests, "\n") + "\n")
+	mcpStdout = &stdout
+
+	var stderr bytes.Buffer
+	if code := run([]string{"mcp", "-C", t.TempDir()}, io.Discard, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	type toolResponse struct {
+		ID     int `json:"id"`
+		Result struct {
+			StructuredContent json.RawMessage `json:"structuredContent"`
+			IsError           bool            `json:"isError"`
+		} `json:"result"`
+	}
+	var responses []toolResponse
+	decoder := json.NewDecoder(&stdout)
+	for decoder.More() {
+		var resp toolResponse
+		if err := decoder.Decode(&resp); err != nil {
+			t.Fatalf("Expected only JSON-RPC responses on stdout: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	if len(responses) != 6 {
+		t.Fatalf("Expected 6 responses, got %d", len(responses))
+	}
+
+	var fetched fetchResult
+	if err := json.Unmarshal(responses[1].Result.StructuredContent, &fetched); err != nil {
+		t.Fatalf("Failed to decode fetch result: %v", err)
+	}
+	if len(fetched.Issues) != 2 || len(fetched.Skipped) != 1 || fetched.Skipped[0].Key != "GONE-1" {
+		t.Errorf("Expected PROJ-1, PROJ-2 and GONE-1 skipped, got %+v", fetched)
+	}
+
+	if responses[2].Result.IsError {
+		t.Errorf("Expected annotate_issue to succeed, got %s", responses[2].Result.StructuredContent)
+	}
+
+	var status syncStatus
+	if err := json.Unmarshal(responses[3].Result.StructuredContent, &status); err != nil {
+		t.Fatalf("Failed to decode sync status: %v", err)
+	}
+	if status.Issues["open"] != 2 {
+		t.Errorf("Expected 2 open issues, got %+v", status.Issues)
+	}
+
+	if !responses[4].Result.IsError {
+		t.Error("Expected fetching a missing issue to be a tool error")
+	}
+
+	if !responses[5].Result.IsError || otherRequests != 0 {
+		t.Errorf("Expected a URL on another host to be refused without contacting it, got %d request(s)", otherRequests)
+	}
+}
+
+// writeBeadsFiles writes files, such as issues.jsonl, into dir/.beads
+func writeBeadsFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, ".beads"), 0755); err != nil {
+		t.Fatalf("Failed to create .beads: %v", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, ".beads", name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// runJSON runs a command with --output json and decodes its result into
+// got, returning the exit code and what went to stderr
+func runJSON(t *testing.T, args []string, got any) (int, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(append(args, "--output", "json"), &stdout, &stderr)
+	if err := json.Unmarshal(stdout.Bytes(), got); err != nil {
+		t.Fatalf("Expected JSON on stdout, got %q (stderr: %s)", stdout.String(), stderr.String())
+	}
+	return code, stderr.String()
+}
+
+func TestRunDiff(t *testing.T) {
+	server := newJiraServer(t)
+	defer server.Close()
+
+	t.Cleanup(resetGlobalFlags)
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("JIRA_PROFILE", "")
+	t.Setenv("JIRA_BASE_URL", server.URL)
+	t.Setenv("JIRA_USERNAME", "user@example.com")
+	t.Setenv("JIRA_API_TOKEN", "token123")
+
+	// proj-2 was edited locally and proj-9 isn't in Jira's results
+	dir := t.TempDir()
+	issues := `{"id":"proj-2","title":"Issue PROJ-2","status":"in_progress","priority":"p2","created":"2024-01-01T10:00:00Z","updated":"2024-01-01T10:00:00Z","metadata":{"jiraId":"1","jiraIssueType":"Task","jiraKey":"PROJ-2"}}
+{"id":"proj-9","title":"Old issue","status":"open"}
+`
+	writeBeadsFiles(t, dir, map[string]string{"issues.jsonl": issues})
+
+	var got struct {
+		report
+		Data diff.Result `json:"data"`
+	}
+	if code, stderr := runJSON(t, []string{"diff", "-C", dir, "PROJ-1"}, &got); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr)
+	}
+
+	want := map[string]diff.Kind{"proj-1": diff.Added, "proj-2": diff.Modified, "proj-9": diff.Removed}
+	if len(got.Data.Changes) != len(want) {
+		t.Fatalf("Expected %d changes, got %+v", len(want), got.Data.Changes)
+	}
+	for _, change := range got.Data.Changes {
+		if want[change.ID] != change.Kind {
+			t.Errorf("Expected %s %s, got %s", change.ID, want[change.ID], change.Kind)
+		}
+		if change.ID == "proj-2" && (len(change.Fields) != 1 || change.Fields[0] != (diff.FieldChange{Field: "status", Old: "in_progress", New: "open"})) {
+			t.Errorf("Expected only proj-2's status changed, got %+v", change.Fields)
+		}
+	}
+
+	// Nothing is written
+	if data, err := os.ReadFile(filepath.Join(dir, ".beads", "issues.jsonl")); err != nil || string(data) != issues {
+		t.Errorf("Expected .beads left unchanged, got %q, %v", data, err)
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	t.Cleanup(resetGlobalFlags)
+
+	// Query names are shown even when the selected profile doesn't exist
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("JIRA_PROFILE", "missing")
+	if err := os.MkdirAll(filepath.Join(configHome, "jira-beads-sync"), 0755); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configHome, "jira-beads-sync", "config.yml"), []byte("queries:\n  proj: project = PROJ\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	// proj-1 was edited since it was synced, and conflicted in the last
+	// cycle; local-1 was created locally
+	dir := t.TempDir()
+	writeBeadsFiles(t, dir, map[string]string{
+		"issues.jsonl": `{"id":"proj-1","title":"Synced","status":"open","priority":"p1","metadata":{"jiraKey":"PROJ-1"}}
+{"id":"local-1","title":"Local","status":"closed"}
+`,
+		"sync-state.json": `{"queries":{"project = PROJ":"2024-01-01T10:00:00Z"},"hashes":{"proj-1":"stale"},"conflicts":["proj-1"]}`,
+	})
+
+	var got struct {
+		report
+		Data syncStatus `json:"data"`
+	}
+	code, stderr := runJSON(t, []string{"status", "-C", dir}, &got)
+	if code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr)
+	}
+
+	want := map[string]int{"issues": 2, "edited_locally": 1, "conflicts": 1, "without_jira_key": 1}
+	for name, n := range want {
+		if got.Counts[name] != n {
+			t.Errorf("Expected %s %d, got %d", name, n, got.Counts[name])
+		}
+	}
+	if got.Data.Priorities["p1"] != 1 || got.Data.Priorities["none"] != 1 || got.Data.Issues["closed"] != 1 {
+		t.Errorf("Expected counts by priority and status, got %+v", got.Data)
+	}
+	if got.Data.LastSynced["project = PROJ"].IsZero() {
+		t.Errorf("Expected the query's last sync, got %+v", got.Data.LastSynced)
+	}
+	if !strings.Contains(stderr, "proj (project = PROJ)") {
+		t.Errorf("Expected the query's name, got:\n%s", stderr)
+	}
+}
+
+func TestRunStatusAfterFetch(t *testing.T) {
+	server := newJiraServer(t)
+	defer server.Close()
+
+	t.Cleanup(resetGlobalFlags)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("JIRA_PROFILE", "")
+	t.Setenv("JIRA_BASE_URL", server.URL)
+	t.Setenv("JIRA_USERNAME", "user@example.com")
+	t.Setenv("JIRA_API_TOKEN", "token123")
+
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"quickstart", "PROJ-2", "-C", dir}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	// Edit proj-2 locally after the fetch
+	renderer := beads.NewJSONLRenderer(dir)
+	export, err := renderer.ReadExport()
+	if err != nil {
+		t.Fatalf("ReadExport failed: %v", err)
+	}
+	export.Issues[0].Title = "Edited locally"
+	if err := renderer.RenderExport(export); err != nil {
+		t.Fatalf("RenderExport failed: %v", err)
+	}
+
+	resetGlobalFlags()
+	var got struct {
+		report
+		Data syncStatus `json:"data"`
+	}
+	if code, stderr := runJSON(t, []string{"status", "-C", dir}, &got); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr)
+	}
+	if got.Data.LastSynced["PROJ-2"].IsZero() {
+		t.Errorf("Expected the fetch to be recorded as the last sync, got %+v", got.Data.LastSynced)
+	}
+	if edited := got.Data.EditedLocally; len(edited) != 1 || edited[0] != "proj-2" {
+		t.Errorf("Expected proj-2 edited locally, got %v", edited)
+	}
+}
+
+func TestRunGraph(t *testing.T) {
+	t.Cleanup(resetGlobalFlags)
+
+	dir := t.TempDir()
+	writeBeadsFiles(t, dir, map[string]string{"issues.jsonl": `{"id":"proj-1","title":"Schema","status":"closed"}
+{"id":"proj-2","title":"API","status":"open","dependsOn":["proj-1"]}
+{"id":"proj-3","title":"UI","status":"open","dependsOn":["proj-2"]}
+`})
+
+	var got struct {
+		report
+		Data struct {
+			CriticalPath []string `json:"criticalPath"`
+		} `json:"data"`
+	}
+	if code, stderr := runJSON(t, []string{"graph", "-C", dir, "--format", "mermaid", "--critical-path"}, &got); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr)
+	}
+	if got.Counts["issues"] != 3 || got.Counts["dependencies"] != 2 {
+		t.Errorf("Expected 3 issues and 2 dependencies, got %v", got.Counts)
+	}
+	if strings.Join(got.Data.CriticalPath, ",") != "proj-2,proj-3" {
+		t.Errorf("Expected critical path proj-2,proj-3, got %v", got.Data.CriticalPath)
+	}
+
+	// An unknown format is a usage error
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"graph", "-C", dir, "--format", "svg"}, &stdout, &stderr); code != exitUsage {
+		t.Errorf("Expected exit code %d, got %d", exitUsage, code)
+	}
+}
+
+func TestRunAnalyze(t *testing.T) {
+	t.Cleanup(resetGlobalFlags)
+
+	dir := t.TempDir()
+	writeBeadsFiles(t, dir, map[string]string{"issues.jsonl": `{"id":"proj-1","title":"API","status":"open","metadata":{"jiraStoryPoints":"1"}}
+{"id":"proj-2","title":"UI","status":"open","dependsOn":["proj-1"],"metadata":{"jiraStoryPoints":"2"}}
+{"id":"proj-3","title":"Infra","status":"in_progress","metadata":{"jiraStoryPoints":"8"}}
+{"id":"proj-4","title":"Launch","status":"open","dependsOn":["proj-2","proj-3"]}
+`})
+
+	var got struct {
+		report
+		Data graph.Analysis `json:"data"`
+	}
+	if code, stderr := runJSON(t, []string{"analyze", "-C", dir, "--weight", "points"}, &got); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr)
+	}
+	if got.Counts["ready"] != 2 || got.Counts["blocked"] != 2 {
+		t.Errorf("Expected 2 ready and 2 blocked, got %v", got.Counts)
+	}
+	if strings.Join(got.Data.CriticalPath, ",") != "proj-3,proj-4" || got.Data.Length != 8 {
+		t.Errorf("Expected critical path proj-3,proj-4 of 8 points, got %v of %v", got.Data.CriticalPath, got.Data.Length)
+	}
+	if strings.Join(got.Data.Order, ",") != "proj-1,proj-2,proj-3,proj-4" {
+		t.Errorf("Expected dependencies first, got %v", got.Data.Order)
+	}
+}
+
+func TestPushPendingWorklogsAfterFailure(t *testing.T) {
+	posts := map[string]int{}
+	failing := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/"), "/worklog")
+		posts[key]++
+		if key == "PROJ-2" && failing {
+			http.Error(w, `{"errorMessages":["Worklog must not be empty"]}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"id":"%d"}`, 100+len(posts))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	renderer := beads.NewJSONLRenderer(dir)
+	for _, key := range []string{"PROJ-1", "PROJ-2"} {
+		entry := &beads.WorklogEntry{IssueID: strings.ToLower(key), JiraKey: key, TimeSpentSeconds: 3600, Started: "2024-01-01T10:00:00Z"}
+		if err := renderer.AppendWorklog(entry); err != nil {
+			t.Fatalf("Failed to append worklog: %v", err)
+		}
+	}
+	client := jira.NewClient(server.URL, "user@example.com", "token123")
+
+	pushed, failedKey, err := pushPendingWorklogs(client, renderer)
+	if err == nil || failedKey != "PROJ-2" || len(pushed) != 1 {
+		t.Fatalf("Expected PROJ-2 to fail after 1 push, got %d pushed, failed %q: %v", len(pushed), failedKey, err)
+	}
+
+	failing = false
+	pushed, _, err = pushPendingWorklogs(client, renderer)
+	if err != nil {
+		t.Fatalf("Expected the retry to succeed, got %v", err)
+	}
+	if len(pushed) != 1 || pushed[0].JiraKey != "PROJ-2" {
+		t.Errorf("Expected only PROJ-2 to be pushed on retry, got %+v", pushed)
+	}
+	if posts["PROJ-1"] != 1 {
+		t.Errorf("Expected PROJ-1 to be pushed once, got %d", posts["PROJ-1"])
+	}
+
+	entries, err := renderer.ReadWorklogs()
+	if err != nil {
+		t.Fatalf("Failed to read worklogs: %v", err)
+	}
+	for _, entry := range entries {
+		if !entry.Pushed() {
+			t.Errorf("Expected %s worklog to be recorded as pushed", entry.JiraKey)
+		}
+	}
+}
+
+func TestRunProjectConfig(t *testing.T) {
+	server := newJiraServer(t)
+	defer server.Close()
+
+	t.Cleanup(resetGlobalFlags)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("JIRA_PROFILE", "")
+	t.Setenv("JIRA_BASE_URL", server.URL)
+	t.Setenv("JIRA_USERNAME", "user@example.com")
+	t.Setenv("JIRA_API_TOKEN", "token123")
+
+	dir := t.TempDir()
+	project := "mapping:\n  statuses:\n    Open: blocked\n  priorities:\n    Medium: p0\noutput:\n  format: json\n"
+	if err := os.WriteFile(filepath.Join(dir, ".jira-beads-sync.yml"), []byte(project), 0644); err != nil {
+		t.Fatalf("Failed to write project config: %v", err)
+	}
+
+	// The configured format applies without --output
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"quickstart", "PROJ-2", "-C", dir}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	var got report
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("Expected a JSON result on stdout, got %q: %v", stdout.String(), err)
+	}
+
+	export, err := beads.NewJSONLRenderer(dir).ReadExport()
+	if err != nil {
+		t.Fatalf("ReadExport failed: %v", err)
+	}
+	if len(export.Issues) != 1 || export.Issues[0].Status != beadspb.Status_STATUS_BLOCKED || export.Issues[0].Priority != beadspb.Priority_PRIORITY_P0 {
+		t.Errorf("Expected proj-2 blocked at p0 by the mapping, got %v", export.Issues)
+	}
+
+	// --output overrides it
+	resetGlobalFlags()
+	stdout.Reset()
+	if code := run([]string{"version", "--output", "text", "-C", dir}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if json.Valid(stdout.Bytes()) {
+		t.Errorf("Expected text output with --output text, got %q", stdout.String())
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	jirapb "github.com/conallob/jira-beads-sync/gen/jira"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+	"github.com/conallob/jira-beads-sync/internal/config"
+	"github.com/conallob/jira-beads-sync/internal/diff"
+	"github.com/conallob/jira-beads-sync/internal/jira"
+	"github.com/conallob/jira-beads-sync/internal/validate"
+)
+
+// issueKeyPattern matches a Jira issue key such as PROJ-123
+var issueKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*-[0-9]+$`)
+
+// runDiff fetches and converts issues as an import would, and shows how
+// .beads would change without writing it. Each argument is a Jira URL, an
+// issue key, a saved query name or JQL; without arguments every saved query
+// is compared.
+func runDiff(e *env, args []string) error {
+	var targetURL string
+	for _, arg := range args {
+		if isURL(arg) {
+			targetURL = arg
+			break
+		}
+	}
+	cfg, err := loadConfig(e, targetURL)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		args = watchQueries(cfg, nil)
+	}
+	if len(args) == 0 {
+		return &usageError{"nothing to compare: pass issue keys, Jira URLs, saved query names or JQL, or add queries to the config"}
+	}
+	mode, err := validationMode(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Keep stdout for the diff while the fetch reports its progress
+	jiraExport, err := fetchScope(e.withOut(e.aside), cfg, args)
+	if err != nil {
+		return err
+	}
+
+	conv, err := converterFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	converted, err := conv.Convert(jiraExport)
+	if err != nil {
+		return fmt.Errorf("failed to convert: %w", err)
+	}
+	problems, err := validate.Validate(converted, mode)
+	reportProblems(problems, mode)
+	if err != nil {
+		return err
+	}
+
+	existing, err := beads.NewJSONLRenderer(e.dir).ReadExport()
+	if err != nil {
+		return fmt.Errorf("failed to read existing beads: %w", err)
+	}
+
+	changes := diff.Compare(existing, converted)
+	result.Data = changes
+	result.count("added", changes.Count(diff.Added))
+	result.count("removed", changes.Count(diff.Removed))
+	result.count("modified", changes.Count(diff.Modified))
+	result.count("unchanged", changes.Unchanged)
+
+	if err := diff.WriteUnified(e.data, changes); err != nil {
+		return fmt.Errorf("failed to write diff: %w", err)
+	}
+	reporter.Info("Compared Jira with .beads", "added", changes.Count(diff.Added),
+		"removed", changes.Count(diff.Removed), "modified", changes.Count(diff.Modified), "unchanged", changes.Unchanged)
+	return nil
+}
+
+// fetchScope fetches the issues each argument refers to into one export.
+// Each URL is fetched with the profile for its own host.
+func fetchScope(e *env, cfg *config.Config, args []string) (*jirapb.Export, error) {
+	combined := &jirapb.Export{}
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		argCfg := cfg
+		target := &jira.URLTarget{Kind: jira.URLKindJQL, JQL: cfg.ResolveQuery(arg), BaseURL: cfg.Jira.BaseURL}
+		switch {
+		case isURL(arg):
+			var err error
+			if target, err = jira.ParseURL(arg); err != nil {
+				return nil, err
+			}
+			if argCfg, err = loadConfig(e, target.BaseURL); err != nil {
+				return nil, err
+			}
+		case issueKeyPattern.MatchString(arg):
+			target = &jira.URLTarget{Kind: jira.URLKindIssue, IssueKey: arg, BaseURL: cfg.Jira.BaseURL}
+		}
+
+		client, err := newClient(argCfg, target.BaseURL)
+		if err != nil {
+			return nil, err
+		}
+		export, err := fetchTarget(e, client, target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch issues for %s: %w", arg, err)
+		}
+		for _, issue := range export.Issues {
+			if !seen[issue.Key] {
+				seen[issue.Key] = true
+				combined.Issues = append(combined.Issues, issue)
+			}
+		}
+	}
+	return combined, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+	"github.com/conallob/jira-beads-sync/internal/graph"
+)
+
+// Options for the graph command
+var (
+	graphFormat       string
+	graphCriticalPath bool
+)
+
+// registerGraphFlags adds the graph command's options
+func registerGraphFlags(fs *flag.FlagSet) {
+	fs.StringVar(&graphFormat, "format", "dot", "output format: dot (Graphviz) or mermaid")
+	fs.BoolVar(&graphCriticalPath, "critical-path", false, "highlight the longest chain of unfinished dependencies")
+}
+
+// runGraph writes the dependency graph of .beads, or of issues fetched
+// from Jira when arguments are given, as DOT or Mermaid on stdout
+func runGraph(e *env, args []string) error {
+	format, err := graph.ParseFormat(graphFormat)
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+
+	export, err := scopeExport(e, args)
+	if err != nil {
+		return err
+	}
+
+	g := graph.New(export)
+	var opts graph.Options
+	if graphCriticalPath {
+		opts.Highlight = g.CriticalPath()
+		result.Data = map[string][]string{"criticalPath": opts.Highlight}
+		if len(opts.Highlight) > 0 {
+			reporter.Info("Critical path", "issues", len(opts.Highlight), "path", strings.Join(opts.Highlight, " → "))
+		}
+	}
+	result.count("issues", len(g.Nodes))
+	result.count("epics", len(export.Epics))
+	result.count("dependencies", len(g.Edges))
+
+	if err := graph.Write(e.data, g, format, opts); err != nil {
+		return fmt.Errorf("failed to write graph: %w", err)
+	}
+	return nil
+}
+
+// scopeExport reads .beads, or fetches and converts the issues args refer
+// to as diff does
+func scopeExport(e *env, args []string) (*beadspb.Export, error) {
+	if len(args) == 0 {
+		export, err := beads.NewJSONLRenderer(e.dir).ReadExport()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read existing beads: %w", err)
+		}
+		return export, nil
+	}
+
+	var targetURL string
+	for _, arg := range args {
+		if isURL(arg) {
+			targetURL = arg
+			break
+		}
+	}
+	cfg, err := loadConfig(e, targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep stdout for the graph while the fetch reports its progress
+	jiraExport, err := fetchScope(e.withOut(e.aside), cfg, args)
+	if err != nil {
+		return nil, err
+	}
+
+	conv, err := converterFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	export, err := conv.Convert(jiraExport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert: %w", err)
+	}
+	return export, nil
+}
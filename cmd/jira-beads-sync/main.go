@@ -1,14 +1,27 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
+	jirapb "github.com/conallob/jira-beads-sync/gen/jira"
 	"github.com/conallob/jira-beads-sync/internal/beads"
 	"github.com/conallob/jira-beads-sync/internal/config"
 	"github.com/conallob/jira-beads-sync/internal/converter"
 	"github.com/conallob/jira-beads-sync/internal/jira"
+	"github.com/conallob/jira-beads-sync/internal/oauth"
+	"github.com/conallob/jira-beads-sync/internal/reconcile"
+	"github.com/conallob/jira-beads-sync/internal/syncer"
+	"github.com/conallob/jira-beads-sync/internal/validate"
 )
 
 // Build-time variables injected via ldflags by goreleaser
@@ -19,173 +32,621 @@ var (
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		printUsage()
-		os.Exit(1)
-	}
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
 
-	command := os.Args[1]
+func runQuickstart(e *env, urlOrKey string) error {
+	e.println("jira-beads-sync quickstart")
+	e.println("========================")
+	e.println()
 
-	switch command {
-	case "quickstart", "fetch":
-		if len(os.Args) < 3 {
-			fmt.Fprintf(os.Stderr, "Error: quickstart requires a Jira URL or issue key\n\n")
-			printUsage()
-			os.Exit(1)
+	// Work out what to fetch: an issue key, or whatever a URL points at.
+	// A URL also picks the profile for its Jira instance.
+	var target *jira.URLTarget
+	var targetURL string
+	if isURL(urlOrKey) {
+		e.printf("Parsing Jira URL...\n")
+		var err error
+		target, err = jira.ParseURL(urlOrKey)
+		if err != nil {
+			return err
 		}
-		if err := runQuickstart(os.Args[2]); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+		targetURL = target.BaseURL
+		e.printf("  %s\n", target)
+		e.printf("  Base URL: %s\n", target.BaseURL)
+	}
+
+	cfg, err := loadOrPromptConfig(e, targetURL)
+	if err != nil {
+		return err
+	}
+
+	if target == nil {
+		target = &jira.URLTarget{Kind: jira.URLKindIssue, IssueKey: urlOrKey, BaseURL: cfg.Jira.BaseURL}
+		e.printf("Using issue key: %s\n", target.IssueKey)
+	}
+	if cfg.Profile != "" {
+		e.printf("Using profile: %s\n", cfg.Profile)
+	}
+	e.println()
+
+	// Create Jira client
+	client, err := newClient(cfg, target.BaseURL)
+	if err != nil {
+		return err
+	}
+
+	jiraExport, err := fetchTarget(e, client, target)
+	if err != nil {
+		return fmt.Errorf("failed to fetch issues: %w", err)
+	}
+
+	e.printf("\n✓ Fetched %d issue(s)\n\n", len(jiraExport.Issues))
+
+	return writeBeads(e, cfg, targetScope(target), jiraExport)
+}
+
+// newClient creates a Jira client for baseURL using the configured
+// authentication, traversal limits and hierarchy
+func newClient(cfg *config.Config, baseURL string) (*jira.Client, error) {
+	direction, err := jira.ParseLinkDirection(cfg.Traversal.Direction)
+	if err != nil {
+		return nil, fmt.Errorf("invalid traversal configuration: %w", err)
+	}
+
+	var client *jira.Client
+	if strings.EqualFold(cfg.Jira.AuthType, jira.AuthTypeOAuth) {
+		// OAuth requests go through the Atlassian API gateway for the site
+		source := oauth.NewSource(newOAuthConfig(cfg), oauthStore(cfg))
+		token, err := source.Token()
+		if err != nil {
+			return nil, err
 		}
-	case "fetch-by-label", "label":
-		if len(os.Args) < 3 {
-			fmt.Fprintf(os.Stderr, "Error: fetch-by-label requires a label argument\n\n")
-			printUsage()
-			os.Exit(1)
+		if !oauth.SameHost(token.SiteURL, baseURL) {
+			return nil, fmt.Errorf("logged in to %s, not %s: run 'jira-beads-sync login' again", token.SiteURL, baseURL)
 		}
-		if err := runFetchByLabel(os.Args[2]); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+		client = jira.NewClientWithAuth(token.APIBaseURL(), &jira.OAuthAuth{Source: source})
+	} else {
+		// The credentials are only good for the instance they were made for
+		if !oauth.SameHost(cfg.Jira.BaseURL, baseURL) {
+			host := baseURL
+			if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+				host = u.Host
+			}
+			return nil, fmt.Errorf("%w %s", config.ErrNoProfile, host)
 		}
-	case "annotate":
-		if len(os.Args) < 4 {
-			fmt.Fprintf(os.Stderr, "Error: annotate requires <issue-id> and <repository> arguments\n\n")
-			printUsage()
-			os.Exit(1)
+		if err := cfg.ResolveToken(); err != nil {
+			return nil, err
 		}
-		if err := runAnnotate(os.Args[2], os.Args[3]); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+		auth, err := jira.NewAuthenticator(cfg.Jira.AuthType, baseURL, cfg.Jira.Username, cfg.Jira.APIToken)
+		if err != nil {
+			return nil, err
 		}
-	case "convert":
-		if len(os.Args) < 3 {
-			fmt.Fprintf(os.Stderr, "Error: convert requires a file argument\n\n")
-			printUsage()
-			os.Exit(1)
+		client = jira.NewClientWithAuth(baseURL, auth)
+	}
+
+	client.SetTraversalOptions(jira.TraversalOptions{
+		MaxDepth:  cfg.Traversal.MaxDepth,
+		LinkTypes: cfg.Traversal.LinkTypes,
+		Direction: direction,
+		Projects:  cfg.Traversal.Projects,
+	})
+	client.SetHierarchy(hierarchyFromConfig(cfg))
+	client.SetStoryPointsField(cfg.Fields.StoryPoints)
+	client.SetReporter(reporter)
+	return client, nil
+}
+
+// newOAuthConfig creates the OAuth app config from the user's configuration
+func newOAuthConfig(cfg *config.Config) *oauth.Config {
+	o := cfg.Jira.OAuth
+	return oauth.NewConfig(o.ClientID, o.ClientSecret, o.CallbackURL, o.Scopes)
+}
+
+// oauthStore returns where OAuth tokens are kept: the keyring when
+// configured, otherwise a file per profile next to the config file
+func oauthStore(cfg *config.Config) oauth.Store {
+	if cfg.Jira.UseKeyring {
+		if u, err := url.Parse(cfg.Jira.BaseURL); err == nil {
+			return &oauth.KeyringStore{Host: u.Host}
 		}
-		if err := runConvert(os.Args[2]); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+	}
+	name := "oauth-token.json"
+	if cfg.Profile != "" {
+		name = fmt.Sprintf("oauth-token-%s.json", cfg.Profile)
+	}
+	return &oauth.FileStore{Path: filepath.Join(config.Dir(), name)}
+}
+
+// hierarchyFromConfig applies the configured hierarchy over the defaults
+func hierarchyFromConfig(cfg *config.Config) jira.Hierarchy {
+	hierarchy := jira.DefaultHierarchy()
+	if len(cfg.Hierarchy.EpicTypes) > 0 {
+		hierarchy.EpicTypes = cfg.Hierarchy.EpicTypes
+	}
+	if cfg.Hierarchy.EpicLevel != nil {
+		hierarchy.EpicLevel = *cfg.Hierarchy.EpicLevel
+	}
+	hierarchy.ParentLinkField = cfg.Hierarchy.ParentLinkField
+	return hierarchy
+}
+
+// mappingFromConfig parses the configured status and priority mapping
+func mappingFromConfig(cfg *config.Config) (converter.Mapping, error) {
+	mapping, err := converter.ParseMapping(cfg.Mapping.Statuses, cfg.Mapping.Priorities)
+	if err != nil {
+		return mapping, fmt.Errorf("invalid mapping configuration: %w", err)
+	}
+	return mapping, nil
+}
+
+// converterFromConfig creates a converter using the configured hierarchy
+// and status and priority mapping
+func converterFromConfig(cfg *config.Config) (*converter.ProtoConverter, error) {
+	mapping, err := mappingFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	conv := converter.NewProtoConverterWithHierarchy(hierarchyFromConfig(cfg))
+	conv.SetMapping(mapping)
+	return conv, nil
+}
+
+// removedPolicy returns the configured policy for issues deleted or moved
+// in Jira
+func removedPolicy(cfg *config.Config) (reconcile.Policy, error) {
+	policy, err := reconcile.ParsePolicy(cfg.Sync.Removed)
+	if err != nil {
+		return "", fmt.Errorf("invalid sync configuration: %w", err)
+	}
+	return policy, nil
+}
+
+// validationMode returns the mode selected with --validate, or else the
+// configured one. cfg may be nil when there is no configuration.
+func validationMode(cfg *config.Config) (validate.Mode, error) {
+	if validateMode != "" {
+		mode, err := validate.ParseMode(validateMode)
+		if err != nil {
+			return "", &usageError{err.Error()}
 		}
-	case "configure", "config":
-		if err := runConfigure(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+		return mode, nil
+	}
+	if cfg == nil {
+		return validate.ModeKeep, nil
+	}
+	mode, err := validate.ParseMode(cfg.Validation.Mode)
+	if err != nil {
+		return "", fmt.Errorf("invalid validation configuration: %w", err)
+	}
+	return mode, nil
+}
+
+// reportProblems records the problems validation found as warnings
+func reportProblems(problems []validate.Problem, mode validate.Mode) {
+	for _, problem := range problems {
+		result.warn("%s", problem)
+		reporter.Warn(problem.String())
+	}
+	if len(problems) > 0 && mode == validate.ModeDrop {
+		reporter.Info("Dropped the invalid references", "count", len(problems))
+	}
+	result.count("problems", len(problems))
+}
+
+// targetScope describes what a parsed Jira URL fetches, for the sync state
+func targetScope(target *jira.URLTarget) string {
+	switch target.Kind {
+	case jira.URLKindIssue:
+		return target.IssueKey
+	case jira.URLKindFilter:
+		return "filter " + target.FilterID
+	case jira.URLKindJQL:
+		return target.JQL
+	case jira.URLKindBoard:
+		return fmt.Sprintf("board %d", target.BoardID)
+	case jira.URLKindSprint:
+		return fmt.Sprintf("sprint %d", target.SprintID)
+	case jira.URLKindBacklog:
+		return fmt.Sprintf("board %d backlog", target.BoardID)
+	default:
+		return target.String()
+	}
+}
+
+// fetchTarget fetches the issues a parsed Jira URL refers to, along with
+// their dependencies
+func fetchTarget(e *env, client *jira.Client, target *jira.URLTarget) (*jirapb.Export, error) {
+	switch target.Kind {
+	case jira.URLKindIssue:
+		e.printf("Fetching %s and its dependencies...\n", target.IssueKey)
+		return client.FetchIssueWithDependencies(target.IssueKey)
+	case jira.URLKindFilter:
+		return client.FetchIssuesByFilter(target.FilterID)
+	case jira.URLKindJQL:
+		return client.FetchIssuesByJQL(target.JQL)
+	case jira.URLKindBoard:
+		sprints, err := client.GetActiveSprints(target.BoardID)
+		if err != nil {
+			return nil, err
 		}
-	case "whoami":
-		if err := runWhoami(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+		return fetchSprints(client, sprints)
+	case jira.URLKindSprint:
+		sprint, err := client.GetSprint(target.SprintID)
+		if err != nil {
+			return nil, err
 		}
-	case "version":
-		fmt.Printf("jira-beads-sync %s\n", version)
-		fmt.Printf("  commit: %s\n", commit)
-		fmt.Printf("  built:  %s\n", date)
-	case "help", "--help", "-h":
-		printUsage()
+		return fetchSprints(client, []*jirapb.Sprint{sprint})
+	case jira.URLKindBacklog:
+		return client.FetchBoardBacklog(target.BoardID)
 	default:
-		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", command)
-		printUsage()
-		os.Exit(1)
+		return nil, fmt.Errorf("unsupported Jira URL target: %s", target)
 	}
 }
 
-func runQuickstart(urlOrKey string) error {
-	fmt.Println("jira-beads-sync quickstart")
-	fmt.Println("========================")
-	fmt.Println()
+// fetchSprints fetches the issues of one or more sprints into a single export
+func fetchSprints(client *jira.Client, sprints []*jirapb.Sprint) (*jirapb.Export, error) {
+	jiraExport := &jirapb.Export{}
+	seen := make(map[string]bool)
+	for _, sprint := range sprints {
+		sprintExport, err := client.FetchSprintIssues(sprint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch sprint %q: %w", sprint.Name, err)
+		}
+		for _, issue := range sprintExport.Issues {
+			if !seen[issue.Key] {
+				seen[issue.Key] = true
+				jiraExport.Issues = append(jiraExport.Issues, issue)
+			}
+		}
+		jiraExport.Skipped = append(jiraExport.Skipped, sprintExport.Skipped...)
+	}
+
+	// A reference skipped by one sprint may be part of another
+	skipped := jiraExport.Skipped[:0]
+	for _, skip := range jiraExport.Skipped {
+		if !seen[skip.Key] {
+			seen[skip.Key] = true
+			skipped = append(skipped, skip)
+		}
+	}
+	jiraExport.Skipped = skipped
+	return jiraExport, nil
+}
 
-	// Load configuration
-	cfg, err := config.Load()
+// loadOrPromptConfig loads the configuration for the selected profile, or
+// the profile matching targetURL, prompting for it (and saving it) when none
+// exists yet
+func loadOrPromptConfig(e *env, targetURL string) (*config.Config, error) {
+	cfg, err := config.LoadForURL(e.dir, profileName, targetURL)
+	if errors.Is(err, config.ErrNoProfile) {
+		return nil, fmt.Errorf("%w. Run 'jira-beads-sync configure --profile NAME' to add one", err)
+	}
 	if err != nil {
-		fmt.Println("⚠ No configuration found. Let's set it up!")
-		fmt.Println()
-		cfg, err = config.PromptForConfig()
+		e.println("⚠ No configuration found. Let's set it up!")
+		e.println()
+		cfg, err = promptForProfile(e)
 		if err != nil {
-			return fmt.Errorf("failed to configure: %w", err)
+			return nil, fmt.Errorf("failed to configure: %w", err)
 		}
 		if err := cfg.Save(); err != nil {
-			fmt.Printf("⚠ Warning: failed to save config: %v\n", err)
+			e.printf("⚠ Warning: failed to save config: %v\n", err)
 		} else {
-			fmt.Println("✓ Configuration saved")
-			fmt.Println()
+			e.println("✓ Configuration saved")
+			e.println()
 		}
 	}
 
 	if err := cfg.Validate(); err != nil {
-		return fmt.Errorf("invalid configuration: %w. Run 'jira-beads-sync configure' to set up", err)
+		return nil, fmt.Errorf("invalid configuration: %w. Run 'jira-beads-sync configure' to set up", err)
 	}
 
-	// Parse issue key from URL if needed
-	var issueKey string
-	var baseURL string
+	return cfg, nil
+}
 
-	if isURL(urlOrKey) {
-		fmt.Printf("Parsing Jira URL...\n")
-		issueKey, err = jira.ParseIssueKeyFromURL(urlOrKey)
-		if err != nil {
-			return err
-		}
-		// Extract base URL from the provided URL
-		baseURL, err = jira.GetBaseURLFromIssueURL(urlOrKey)
-		if err != nil {
-			return err
-		}
-		fmt.Printf("  Issue key: %s\n", issueKey)
-		fmt.Printf("  Base URL: %s\n", baseURL)
-	} else {
-		issueKey = urlOrKey
-		baseURL = cfg.Jira.BaseURL
-		fmt.Printf("Using issue key: %s\n", issueKey)
+// loadConfig loads and validates the configuration for a Jira URL, or for
+// the selected profile if targetURL is empty, without prompting
+func loadConfig(e *env, targetURL string) (*config.Config, error) {
+	cfg, err := config.LoadForURL(e.dir, profileName, targetURL)
+	if errors.Is(err, config.ErrNoProfile) {
+		return nil, fmt.Errorf("%w. Run 'jira-beads-sync configure --profile NAME' to add one", err)
 	}
-	fmt.Println()
+	if err != nil {
+		return nil, fmt.Errorf("no configuration found. Run 'jira-beads-sync configure' to set up")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w. Run 'jira-beads-sync configure' to fix", err)
+	}
+	return cfg, nil
+}
 
-	// Create Jira client
-	client := jira.NewClient(baseURL, cfg.Jira.Username, cfg.Jira.APIToken)
+// writeBeads converts fetched Jira issues to beads format, writes them to
+// e.dir and records the fetch of scope in the sync state
+func writeBeads(e *env, cfg *config.Config, scope string, jiraExport *jirapb.Export) error {
+	outputDir := e.dir
 
-	// Fetch issue and dependencies
-	fmt.Printf("Fetching %s and its dependencies...\n", issueKey)
-	jiraExport, err := client.FetchIssueWithDependencies(issueKey)
+	mode, err := validationMode(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to fetch issues: %w", err)
+		return err
 	}
 
-	fmt.Printf("\n✓ Fetched %d issue(s)\n\n", len(jiraExport.Issues))
-
-	// Convert to beads format
-	outputDir, err := os.Getwd()
+	protoConverter, err := converterFromConfig(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+		return err
 	}
 
-	fmt.Println("Converting to beads format...")
-	protoConverter := converter.NewProtoConverter()
+	e.println("Converting to beads format...")
 	beadsExport, err := protoConverter.Convert(jiraExport)
 	if err != nil {
 		return fmt.Errorf("failed to convert: %w", err)
 	}
 
+	problems, err := validate.Validate(beadsExport, mode)
+	reportProblems(problems, mode)
+	if err != nil {
+		return err
+	}
+
 	// Render to JSONL
 	jsonlRenderer := beads.NewJSONLRenderer(outputDir)
+	jsonlRenderer.SetReporter(reporter)
 	if err := jsonlRenderer.RenderExport(beadsExport); err != nil {
 		return fmt.Errorf("failed to render: %w", err)
 	}
+	if err := syncer.RecordFetch(outputDir, scope, e.start, beadsExport); err != nil {
+		return err
+	}
 
-	fmt.Println("\n✓ Conversion complete!")
+	e.println("\n✓ Conversion complete!")
 	if len(beadsExport.Epics) > 0 {
-		fmt.Printf("  %d epic(s) written to %s/.beads/epics.jsonl\n", len(beadsExport.Epics), outputDir)
+		e.printf("  %d epic(s) written to %s/.beads/epics.jsonl\n", len(beadsExport.Epics), outputDir)
+		result.file(filepath.Join(outputDir, ".beads", "epics.jsonl"))
+	}
+	e.printf("  %d issue(s) written to %s/.beads/issues.jsonl\n", len(beadsExport.Issues), outputDir)
+	result.file(filepath.Join(outputDir, ".beads", "issues.jsonl"))
+	result.count("issues", len(beadsExport.Issues))
+	result.count("epics", len(beadsExport.Epics))
+
+	failed := 0
+	for _, skip := range jiraExport.Skipped {
+		if skip.Failed {
+			failed++
+			result.fail(skip.Key, fmt.Errorf("%s (referenced by %s)", skip.Reason, skip.ReferencedBy))
+			continue
+		}
+		result.warn("%s left as a dangling reference: referenced by %s, %s", skip.Key, skip.ReferencedBy, skip.Reason)
+		reporter.Info("Left outside the traversal limits as a dangling reference",
+			"key", skip.Key, "referenced_by", skip.ReferencedBy, "reason", skip.Reason)
+	}
+	result.count("skipped", len(jiraExport.Skipped))
+
+	if failed > 0 {
+		reporter.Warn("Some referenced issues could not be fetched and were left as dangling references", "count", failed)
+	}
+
+	return nil
+}
+
+func runFetchJQL(e *env, queryOrName string) error {
+	e.println("jira-beads-sync fetch-jql")
+	e.println("=========================")
+	e.println()
+
+	cfg, err := loadOrPromptConfig(e, "")
+	if err != nil {
+		return err
+	}
+
+	jql := cfg.ResolveQuery(queryOrName)
+	if jql != queryOrName {
+		e.printf("Using saved query %q\n", queryOrName)
+	}
+
+	client, err := newClient(cfg, cfg.Jira.BaseURL)
+	if err != nil {
+		return err
+	}
+
+	jiraExport, err := client.FetchIssuesByJQL(jql)
+	if err != nil {
+		return fmt.Errorf("failed to fetch issues: %w", err)
+	}
+
+	e.printf("\n✓ Fetched %d issue(s) total (including dependencies)\n\n", len(jiraExport.Issues))
+
+	return writeBeads(e, cfg, jql, jiraExport)
+}
+
+func runFetchFilter(e *env, filterOrURL string) error {
+	e.println("jira-beads-sync fetch-filter")
+	e.println("============================")
+	e.println()
+
+	// The argument is checked before any configuration prompt
+	var targetURL, baseURL string
+	filterID := filterOrURL
+	if isURL(filterOrURL) {
+		target, err := jira.ParseURL(filterOrURL)
+		if err != nil {
+			return &usageError{err.Error()}
+		}
+		if target.Kind != jira.URLKindFilter {
+			return &usageError{fmt.Sprintf("not a saved filter URL: %s", filterOrURL)}
+		}
+		targetURL = filterOrURL
+		filterID = target.FilterID
+		baseURL = target.BaseURL
+	} else if err := jira.ValidateFilterID(filterID); err != nil {
+		return &usageError{err.Error()}
+	}
+
+	cfg, err := loadOrPromptConfig(e, targetURL)
+	if err != nil {
+		return err
+	}
+	if baseURL == "" {
+		baseURL = cfg.Jira.BaseURL
+	}
+
+	client, err := newClient(cfg, baseURL)
+	if err != nil {
+		return err
+	}
+
+	jiraExport, err := client.FetchIssuesByFilter(filterID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch issues: %w", err)
+	}
+
+	e.printf("\n✓ Fetched %d issue(s) total (including dependencies)\n\n", len(jiraExport.Issues))
+
+	return writeBeads(e, cfg, "filter "+filterID, jiraExport)
+}
+
+func runFetchSprint(e *env, boardArg, sprintName string) error {
+	e.println("jira-beads-sync fetch-sprint")
+	e.println("============================")
+	e.println()
+
+	boardID, err := strconv.Atoi(boardArg)
+	if err != nil {
+		return fmt.Errorf("invalid board ID %q", boardArg)
+	}
+
+	cfg, err := loadOrPromptConfig(e, "")
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(cfg, cfg.Jira.BaseURL)
+	if err != nil {
+		return err
+	}
+
+	var sprints []*jirapb.Sprint
+	if sprintName == "" {
+		sprints, err = client.GetActiveSprints(boardID)
+	} else {
+		var sprint *jirapb.Sprint
+		sprint, err = client.FindSprintByName(boardID, sprintName)
+		sprints = []*jirapb.Sprint{sprint}
+	}
+	if err != nil {
+		return err
+	}
+
+	jiraExport, err := fetchSprints(client, sprints)
+	if err != nil {
+		return err
+	}
+
+	e.printf("\n✓ Fetched %d issue(s) total (including dependencies)\n\n", len(jiraExport.Issues))
+
+	scope := fmt.Sprintf("board %d", boardID)
+	if sprintName != "" {
+		scope += " sprint " + sprintName
+	}
+	return writeBeads(e, cfg, scope, jiraExport)
+}
+
+func runFetchBacklog(e *env, boardArg string) error {
+	e.println("jira-beads-sync fetch-backlog")
+	e.println("=============================")
+	e.println()
+
+	boardID, err := strconv.Atoi(boardArg)
+	if err != nil {
+		return fmt.Errorf("invalid board ID %q", boardArg)
+	}
+
+	cfg, err := loadOrPromptConfig(e, "")
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(cfg, cfg.Jira.BaseURL)
+	if err != nil {
+		return err
+	}
+
+	jiraExport, err := client.FetchBoardBacklog(boardID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch backlog: %w", err)
+	}
+
+	e.printf("\n✓ Fetched %d issue(s) total (including dependencies)\n\n", len(jiraExport.Issues))
+
+	return writeBeads(e, cfg, fmt.Sprintf("board %d backlog", boardID), jiraExport)
+}
+
+func runLogin(e *env) error {
+	e.println("jira-beads-sync login")
+	e.println("=====================")
+	e.println()
+
+	cfg, err := config.LoadProfile(e.dir, profileName)
+	if err != nil {
+		return fmt.Errorf("no configuration found. Run 'jira-beads-sync configure' to set up")
+	}
+	if cfg.Jira.BaseURL == "" {
+		return fmt.Errorf("jira base URL is required. Run 'jira-beads-sync configure' to set up")
+	}
+	if err := cfg.Jira.OAuth.Validate(); err != nil {
+		return fmt.Errorf("invalid OAuth configuration: %w", err)
+	}
+
+	token, err := newOAuthConfig(cfg).Login(cfg.Jira.BaseURL, func(target string) error {
+		return openBrowser(e, target)
+	})
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+
+	if err := oauthStore(cfg).Save(token); err != nil {
+		return err
+	}
+
+	e.println()
+	e.printf("✓ Logged in to %s\n", token.SiteURL)
+	if !strings.EqualFold(cfg.Jira.AuthType, jira.AuthTypeOAuth) {
+		e.println("  Set 'auth_type: oauth' under 'jira:' in your config to use it")
 	}
-	fmt.Printf("  %d issue(s) written to %s/.beads/issues.jsonl\n", len(beadsExport.Issues), outputDir)
 
 	return nil
 }
 
-func runConfigure() error {
-	fmt.Println("jira-beads-sync configuration")
-	fmt.Println("===========================")
-	fmt.Println()
+// openBrowser shows a URL to the user, opening it in their browser when possible
+func openBrowser(e *env, target string) error {
+	e.println("Opening your browser to authorize jira-beads-sync. If it doesn't open, visit:")
+	e.printf("  %s\n\n", target)
 
-	cfg, err := config.PromptForConfig()
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	if err := cmd.Start(); err != nil {
+		e.println("⚠ Could not open a browser; open the URL above manually")
+	}
+
+	e.println("Waiting for authorization...")
+	return nil
+}
+
+func runConfigure(e *env) error {
+	e.println("jira-beads-sync configuration")
+	e.println("===========================")
+	e.println()
+
+	if profileName != "" {
+		e.printf("Profile: %s\n\n", profileName)
+	}
+
+	cfg, err := promptForProfile(e)
 	if err != nil {
 		return err
 	}
@@ -194,15 +655,72 @@ func runConfigure() error {
 		return fmt.Errorf("failed to save configuration: %w", err)
 	}
 
-	fmt.Println()
-	fmt.Println("✓ Configuration saved successfully")
+	e.println()
+	e.println("✓ Configuration saved successfully")
+
+	return nil
+}
+
+func runConfigShow(e *env) error {
+	cfg, err := config.LoadProfile(e.dir, profileName)
+	if err != nil {
+		return err
+	}
+
+	e.println("jira-beads-sync config")
+	e.println("======================")
+	e.println()
+	e.printf("User config:     %s\n", config.Path())
+	if cfg.ProjectFile != "" {
+		e.printf("Project config:  %s\n", cfg.ProjectFile)
+	} else {
+		e.printf("Project config:  none (%s not found)\n", config.ProjectFileName)
+	}
+	if cfg.Profile != "" {
+		e.printf("Profile:         %s\n", cfg.Profile)
+	}
+	e.println()
+
+	settings := cfg.Settings()
+	result.Data = settings
+	if len(settings) == 0 {
+		e.println("No settings configured. Run 'jira-beads-sync configure' to set up")
+		return nil
+	}
+
+	width := 0
+	for _, setting := range settings {
+		if len(setting.Key) > width {
+			width = len(setting.Key)
+		}
+	}
+	for _, setting := range settings {
+		e.printf("  %-*s  %s  (%s)\n", width, setting.Key, setting.Value, setting.Source)
+	}
 
 	return nil
 }
 
-func runWhoami() error {
+// promptForProfile prompts for Jira settings and stores them in the selected
+// profile of the existing config file, keeping its other settings
+func promptForProfile(e *env) (*config.Config, error) {
+	cfg, err := config.ReadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	prompted, err := config.PromptForConfig(e.out)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.SetProfile(profileName, prompted.Jira)
+	return cfg, nil
+}
+
+func runWhoami(e *env) error {
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := config.LoadProfile(e.dir, profileName)
 	if err != nil {
 		return fmt.Errorf("no configuration found. Run 'jira-beads-sync configure' to set up")
 	}
@@ -211,83 +729,103 @@ func runWhoami() error {
 		return fmt.Errorf("invalid configuration: %w. Run 'jira-beads-sync configure' to fix", err)
 	}
 
-	fmt.Println("jira-beads-sync whoami")
-	fmt.Println("======================")
-	fmt.Println()
+	e.println("jira-beads-sync whoami")
+	e.println("======================")
+	e.println()
 
 	// Create Jira client
-	client := jira.NewClient(cfg.Jira.BaseURL, cfg.Jira.Username, cfg.Jira.APIToken)
+	client, err := newClient(cfg, cfg.Jira.BaseURL)
+	if err != nil {
+		return err
+	}
 
 	// Test authentication by fetching current user
-	fmt.Println("Testing Jira connection...")
+	e.println("Testing Jira connection...")
 	userInfo, err := client.GetCurrentUser()
 	if err != nil {
 		return fmt.Errorf("failed to authenticate: %w", err)
 	}
 
-	fmt.Println()
-	fmt.Println("✓ Authentication successful")
-	fmt.Println()
-	fmt.Println("Jira User Information:")
-	fmt.Printf("  Display Name:  %s\n", userInfo.DisplayName)
-	fmt.Printf("  Email:         %s\n", userInfo.EmailAddress)
-	fmt.Printf("  Account ID:    %s\n", userInfo.AccountID)
-	fmt.Printf("  Active:        %t\n", userInfo.Active)
-	fmt.Println()
-	fmt.Println("Jira Instance:")
-	fmt.Printf("  Base URL:      %s\n", cfg.Jira.BaseURL)
-	fmt.Printf("  Username:      %s\n", cfg.Jira.Username)
+	e.println()
+	e.println("✓ Authentication successful")
+	e.println()
+	e.println("Jira User Information:")
+	e.printf("  Display Name:  %s\n", userInfo.DisplayName)
+	e.printf("  Email:         %s\n", userInfo.EmailAddress)
+	e.printf("  Account ID:    %s\n", userInfo.AccountID)
+	e.printf("  Active:        %t\n", userInfo.Active)
+	e.println()
+	e.println("Jira Instance:")
+	if cfg.Profile != "" {
+		e.printf("  Profile:       %s\n", cfg.Profile)
+	}
+	e.printf("  Base URL:      %s\n", cfg.Jira.BaseURL)
+	if cfg.Jira.Username != "" {
+		e.printf("  Username:      %s\n", cfg.Jira.Username)
+	}
+	e.printf("  Auth Mode:     %s\n", client.AuthMode())
+
+	result.Data = struct {
+		*jira.UserInfo
+		BaseURL  string `json:"base_url"`
+		Profile  string `json:"profile,omitempty"`
+		AuthMode string `json:"auth_mode"`
+	}{userInfo, cfg.Jira.BaseURL, cfg.Profile, client.AuthMode()}
 
 	return nil
 }
 
-func runConvert(jiraFile string) error {
-	// Get current directory as output directory
-	outputDir, err := os.Getwd()
+func runConvert(e *env, jiraFile string) error {
+	outputDir := e.dir
+
+	// Converting needs no configuration, but uses its hierarchy, mapping
+	// and validation mode so the export converts as it would when fetched
+	cfg, _ := config.LoadProfile(e.dir, profileName)
+	mode, err := validationMode(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+		return err
 	}
 
 	pipeline := converter.NewPipeline(outputDir)
+	pipeline.SetReporter(reporter)
+	pipeline.SetValidation(mode)
+	if cfg != nil {
+		mapping, err := mappingFromConfig(cfg)
+		if err != nil {
+			return err
+		}
+		pipeline.SetHierarchy(hierarchyFromConfig(cfg))
+		pipeline.SetMapping(mapping)
+	}
 
-	fmt.Printf("Converting %s to beads format...\n", jiraFile)
-	if err := pipeline.ConvertFile(jiraFile); err != nil {
+	e.printf("Converting %s to beads format...\n", jiraFile)
+	err = pipeline.ConvertFile(e.path(jiraFile))
+	reportProblems(pipeline.Problems(), mode)
+	if err != nil {
 		return err
 	}
 
-	fmt.Println("✓ Conversion complete!")
-	fmt.Printf("  Issues and epics written to %s/.beads/\n", outputDir)
+	e.println("✓ Conversion complete!")
+	e.printf("  Issues and epics written to %s/.beads/\n", outputDir)
+	result.file(filepath.Join(outputDir, ".beads"))
 	return nil
 }
 
-func runFetchByLabel(label string) error {
-	fmt.Println("jira-beads-sync fetch-by-label")
-	fmt.Println("==============================")
-	fmt.Println()
+func runFetchByLabel(e *env, label string) error {
+	e.println("jira-beads-sync fetch-by-label")
+	e.println("==============================")
+	e.println()
 
-	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := loadOrPromptConfig(e, "")
 	if err != nil {
-		fmt.Println("⚠ No configuration found. Let's set it up!")
-		fmt.Println()
-		cfg, err = config.PromptForConfig()
-		if err != nil {
-			return fmt.Errorf("failed to configure: %w", err)
-		}
-		if err := cfg.Save(); err != nil {
-			fmt.Printf("⚠ Warning: failed to save config: %v\n", err)
-		} else {
-			fmt.Println("✓ Configuration saved")
-			fmt.Println()
-		}
-	}
-
-	if err := cfg.Validate(); err != nil {
-		return fmt.Errorf("invalid configuration: %w. Run 'jira-beads-sync configure' to set up", err)
+		return err
 	}
 
 	// Create Jira client
-	client := jira.NewClient(cfg.Jira.BaseURL, cfg.Jira.Username, cfg.Jira.APIToken)
+	client, err := newClient(cfg, cfg.Jira.BaseURL)
+	if err != nil {
+		return err
+	}
 
 	// Fetch issues by label
 	jiraExport, err := client.FetchIssuesByLabel(label)
@@ -295,79 +833,196 @@ func runFetchByLabel(label string) error {
 		return fmt.Errorf("failed to fetch issues by label: %w", err)
 	}
 
-	fmt.Printf("\n✓ Fetched %d issue(s) total (including dependencies)\n\n", len(jiraExport.Issues))
+	e.printf("\n✓ Fetched %d issue(s) total (including dependencies)\n\n", len(jiraExport.Issues))
 
-	// Convert to beads format
-	outputDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+	return writeBeads(e, cfg, "label "+label, jiraExport)
+}
+
+func runAnnotate(e *env, issueID, repository string) error {
+	e.println("jira-beads-sync annotate")
+	e.println("========================")
+	e.println()
+
+	outputDir := e.dir
+	jsonlRenderer := beads.NewJSONLRenderer(outputDir)
+
+	// Add repository annotation
+	if err := jsonlRenderer.AddRepositoryAnnotation(issueID, repository); err != nil {
+		return fmt.Errorf("failed to annotate issue: %w", err)
 	}
 
-	fmt.Println("Converting to beads format...")
-	protoConverter := converter.NewProtoConverter()
-	beadsExport, err := protoConverter.Convert(jiraExport)
+	e.printf("✓ Added repository '%s' to issue %s\n", repository, issueID)
+	e.printf("  Updated: %s/.beads/issues.jsonl\n", outputDir)
+	result.file(filepath.Join(outputDir, ".beads", "issues.jsonl"))
+
+	return nil
+}
+
+func runLogWork(e *env, issueID, duration, comment string) error {
+	seconds, err := jira.ParseDuration(duration)
 	if err != nil {
-		return fmt.Errorf("failed to convert: %w", err)
+		return fmt.Errorf("invalid duration %q: %w", duration, err)
 	}
 
-	// Render to JSONL
+	outputDir := e.dir
 	jsonlRenderer := beads.NewJSONLRenderer(outputDir)
-	if err := jsonlRenderer.RenderExport(beadsExport); err != nil {
-		return fmt.Errorf("failed to render: %w", err)
+
+	jiraKey, err := jsonlRenderer.LookupJiraKey(issueID)
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("\n✓ Conversion complete!")
-	if len(beadsExport.Epics) > 0 {
-		fmt.Printf("  %d epic(s) written to %s/.beads/epics.jsonl\n", len(beadsExport.Epics), outputDir)
+	entry := &beads.WorklogEntry{
+		IssueID:          issueID,
+		JiraKey:          jiraKey,
+		TimeSpentSeconds: seconds,
+		Started:          time.Now().Format(time.RFC3339),
+		Comment:          comment,
+	}
+	if err := jsonlRenderer.AppendWorklog(entry); err != nil {
+		return fmt.Errorf("failed to record worklog: %w", err)
 	}
-	fmt.Printf("  %d issue(s) written to %s/.beads/issues.jsonl\n", len(beadsExport.Issues), outputDir)
+
+	e.printf("✓ Logged %s against %s (%s)\n", jira.FormatDuration(seconds), issueID, jiraKey)
+	result.file(filepath.Join(outputDir, ".beads", "worklogs.jsonl"))
+	result.count("worklogs", 1)
+	e.println("  Run 'jira-beads-sync push-worklogs' to send it to Jira")
 
 	return nil
 }
 
-func runAnnotate(issueID, repository string) error {
-	fmt.Println("jira-beads-sync annotate")
-	fmt.Println("========================")
-	fmt.Println()
+func runPushWorklogs(e *env) error {
+	e.println("jira-beads-sync push-worklogs")
+	e.println("=============================")
+	e.println()
 
-	outputDir, err := os.Getwd()
+	cfg, err := loadConfig(e, "")
 	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+		return err
+	}
+	outputDir := e.dir
+
+	client, err := newClient(cfg, cfg.Jira.BaseURL)
+	if err != nil {
+		return err
 	}
 
 	jsonlRenderer := beads.NewJSONLRenderer(outputDir)
+	entries, failedKey, pushErr := pushPendingWorklogs(client, jsonlRenderer)
+	if pushErr != nil && failedKey == "" {
+		return pushErr
+	}
+	pushed := len(entries)
+	for _, entry := range entries {
+		e.printf("✓ Pushed %s to %s\n", jira.FormatDuration(entry.TimeSpentSeconds), entry.JiraKey)
+	}
+	if pushed > 0 {
+		result.count("pushed", pushed)
+		result.file(filepath.Join(outputDir, ".beads", "worklogs.jsonl"))
+	}
+	if pushErr != nil {
+		result.fail(failedKey, pushErr)
+	}
 
-	// Add repository annotation
-	if err := jsonlRenderer.AddRepositoryAnnotation(issueID, repository); err != nil {
-		return fmt.Errorf("failed to annotate issue: %w", err)
+	// Having pushed some worklogs is a partial success; the rest are
+	// pushed next time
+	if pushErr != nil && pushed == 0 {
+		return pushErr
+	}
+	if pushErr != nil {
+		e.printf("\n⚠ Pushed %d worklog(s), then failed: %v\n", pushed, pushErr)
+		return nil
+	}
+
+	if pushed == 0 {
+		e.println("No pending worklogs to push")
+		return nil
 	}
 
-	fmt.Printf("✓ Added repository '%s' to issue %s\n", repository, issueID)
-	fmt.Printf("  Updated: %s/.beads/issues.jsonl\n", outputDir)
+	e.printf("\n✓ Pushed %d worklog(s) to Jira\n", pushed)
 
 	return nil
 }
 
-func printUsage() {
-	fmt.Println("jira-beads-sync - Convert Jira task trees to beads issues")
-	fmt.Println()
-	fmt.Println("Usage:")
-	fmt.Println("  jira-beads-sync quickstart <jira-url>         Fetch issue from Jira and convert to beads")
-	fmt.Println("  jira-beads-sync fetch-by-label <label>        Fetch all issues with label from Jira")
-	fmt.Println("  jira-beads-sync annotate <issue-id> <repo>    Annotate issue with repository info")
-	fmt.Println("  jira-beads-sync convert <jira-export-file>    Convert Jira export to beads format")
-	fmt.Println("  jira-beads-sync configure                     Configure Jira credentials")
-	fmt.Println("  jira-beads-sync whoami                        Test Jira authentication and show user info")
-	fmt.Println("  jira-beads-sync version                       Show version information")
-	fmt.Println("  jira-beads-sync help                          Show this help message")
-	fmt.Println()
-	fmt.Println("Examples:")
-	fmt.Println("  jira-beads-sync quickstart https://jira.example.com/browse/PROJ-123")
-	fmt.Println("  jira-beads-sync quickstart PROJ-123")
-	fmt.Println("  jira-beads-sync fetch-by-label sprint-23")
-	fmt.Println("  jira-beads-sync annotate proj-123 https://github.com/org/repo")
-	fmt.Println("  jira-beads-sync convert jira-export.json")
-	fmt.Println("  jira-beads-sync configure")
+// pushPendingWorklogs pushes the worklogs not yet in Jira, in order, and
+// returns those pushed. It stops at the first failure, returning the key of
+// the issue it failed for. Each worklog is saved as pushed as soon as Jira
+// accepts it, so an interrupted push never sends it twice.
+func pushPendingWorklogs(client *jira.Client, renderer *beads.JSONLRenderer) (pushed []*beads.WorklogEntry, failedKey string, err error) {
+	entries, err := renderer.ReadWorklogs()
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, entry := range entries {
+		if entry.Pushed() {
+			continue
+		}
+
+		started, err := time.Parse(time.RFC3339, entry.Started)
+		if err != nil {
+			return pushed, entry.JiraKey, fmt.Errorf("invalid start time for %s worklog: %w", entry.JiraKey, err)
+		}
+
+		id, err := client.AddWorklog(entry.JiraKey, entry.TimeSpentSeconds, started, entry.Comment)
+		if err != nil {
+			return pushed, entry.JiraKey, err
+		}
+
+		entry.JiraWorklogID = id
+		if err := renderer.WriteWorklogs(entries); err != nil {
+			return pushed, entry.JiraKey, fmt.Errorf("failed to record worklog %s on %s as pushed: %w", id, entry.JiraKey, err)
+		}
+		pushed = append(pushed, entry)
+	}
+	return pushed, "", nil
+}
+
+// printUsage shows the commands, global options and examples
+func printUsage(w io.Writer) {
+	var b strings.Builder
+	fmt.Fprintln(&b, "jira-beads-sync - Convert Jira task trees to beads issues")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "Usage:")
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "  jira-beads-sync %-38s %s\n", cmd.synopsis(), cmd.summary)
+	}
+	fmt.Fprintf(&b, "  jira-beads-sync %-38s %s\n", "help [command]", "Show help for all commands or one command")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "Global Options:")
+	fmt.Fprintln(&b, "  --profile <name>               Use a named Jira profile from the config file")
+	fmt.Fprintln(&b, "  -C, --output-dir <dir>         Work in dir instead of the current directory")
+	fmt.Fprintln(&b, "  --output <text|json>           Print a single JSON result for scripts")
+	fmt.Fprintln(&b, "  -q, --quiet                    Only print warnings and errors")
+	fmt.Fprintln(&b, "  -v, -vv                        Log more detail; -vv also traces HTTP requests")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "Run 'jira-beads-sync <command> --help' for a command's options.")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "Examples:")
+	fmt.Fprintln(&b, "  jira-beads-sync quickstart https://jira.example.com/browse/PROJ-123")
+	fmt.Fprintln(&b, "  jira-beads-sync quickstart PROJ-123")
+	fmt.Fprintln(&b, "  jira-beads-sync quickstart https://jira.example.com/issues/?filter=12345")
+	fmt.Fprintln(&b, "  jira-beads-sync fetch-by-label sprint-23")
+	fmt.Fprintln(&b, "  jira-beads-sync fetch-jql \"project = PROJ AND fixVersion = 2.0\"")
+	fmt.Fprintln(&b, "  jira-beads-sync fetch-sprint 42")
+	fmt.Fprintln(&b, "  jira-beads-sync fetch-sprint 42 Sprint 23")
+	fmt.Fprintln(&b, "  jira-beads-sync watch --interval 10m --commit my-sprint")
+	fmt.Fprintln(&b, "  jira-beads-sync status")
+	fmt.Fprintln(&b, "  jira-beads-sync diff PROJ-123")
+	fmt.Fprintln(&b, "  jira-beads-sync graph --format mermaid --critical-path")
+	fmt.Fprintln(&b, "  jira-beads-sync analyze --weight points")
+	fmt.Fprintln(&b, "  jira-beads-sync reconcile --policy tombstone --dry-run")
+	fmt.Fprintln(&b, "  JIRA_WEBHOOK_SECRET=... jira-beads-sync serve --addr :9000")
+	fmt.Fprintln(&b, "  claude mcp add jira-beads-sync -- jira-beads-sync mcp")
+	fmt.Fprintln(&b, "  jira-beads-sync annotate proj-123 https://github.com/org/repo")
+	fmt.Fprintln(&b, "  jira-beads-sync log-work proj-123 \"1h 30m\" Pairing on auth flow")
+	fmt.Fprintln(&b, "  jira-beads-sync convert jira-export.json")
+	fmt.Fprintln(&b, "  jira-beads-sync --validate fail fetch-jql \"project = PROJ\"")
+	fmt.Fprintln(&b, "  jira-beads-sync configure")
+	fmt.Fprintln(&b, "  jira-beads-sync --profile work-cloud whoami")
+	fmt.Fprintln(&b, "  jira-beads-sync -C ../other-repo quickstart PROJ-123")
+
+	_, _ = io.WriteString(w, b.String())
 }
 
 // isURL checks if a string is a URL (starts with http:// or https://)
@@ -1,6 +1,10 @@
 package main
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/conallob/jira-beads-sync/internal/config"
+)
 
 func TestIsURL(t *testing.T) {
 	tests := []struct {
@@ -231,3 +235,25 @@ func BenchmarkIsURL(b *testing.B) {
 		})
 	}
 }
+
+func TestHierarchyFromConfig(t *testing.T) {
+	zero, two := int32(0), int32(2)
+	tests := []struct {
+		name      string
+		epicLevel *int32
+		want      int32
+	}{
+		{name: "default", want: 1},
+		{name: "disabled", epicLevel: &zero, want: 0},
+		{name: "raised", epicLevel: &two, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Hierarchy: config.HierarchyConfig{EpicLevel: tt.epicLevel}}
+			if got := hierarchyFromConfig(cfg).EpicLevel; got != tt.want {
+				t.Errorf("Expected epic level %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	jirapb "github.com/conallob/jira-beads-sync/gen/jira"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+	"github.com/conallob/jira-beads-sync/internal/config"
+	"github.com/conallob/jira-beads-sync/internal/jira"
+	"github.com/conallob/jira-beads-sync/internal/mcp"
+	"github.com/conallob/jira-beads-sync/internal/reconcile"
+	"github.com/conallob/jira-beads-sync/internal/syncer"
+	"github.com/conallob/jira-beads-sync/internal/validate"
+)
+
+// The streams the MCP server speaks over; overridden in tests
+var (
+	mcpStdin  io.Reader = os.Stdin
+	mcpStdout io.Writer = os.Stdout
+)
+
+// runMCP serves the tools over stdio until the client closes stdin
+func runMCP(e *env) error {
+	// Anything the tools print would corrupt the protocol, so it goes to
+	// stderr with the logs
+	e = e.withOut(e.aside)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := mcp.NewServer("jira-beads-sync", version, mcpTools(e))
+	server.SetReporter(reporter)
+	reporter.Debug("Serving MCP over stdio")
+	return server.Serve(ctx, mcpStdin, mcpStdout)
+}
+
+// mcpTools returns the tools the MCP server exposes, working in e
+func mcpTools(e *env) []*mcp.Tool {
+	return []*mcp.Tool{
+		{
+			Name: "fetch_issue_tree",
+			Description: "Fetch a Jira issue with its subtasks, epic children and linked dependencies, " +
+				"or whatever a Jira URL points at, and merge them into .beads. Returns the issues and epics fetched.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"issue": {"type": "string", "description": "Issue key such as PROJ-123, or a Jira issue, filter, board or sprint URL"}
+				},
+				"required": ["issue"],
+				"additionalProperties": false
+			}`),
+			Handler: withEnv(e, mcpFetchIssueTree),
+		},
+		{
+			Name: "fetch_jql",
+			Description: "Fetch the Jira issues matching a JQL query or saved query name, with their dependencies, " +
+				"and merge them into .beads. Returns the issues and epics fetched.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"jql": {"type": "string", "description": "JQL query, or the name of a query saved in the config"}
+				},
+				"required": ["jql"],
+				"additionalProperties": false
+			}`),
+			Handler: withEnv(e, mcpFetchJQL),
+		},
+		{
+			Name:        "annotate_issue",
+			Description: "Record that a beads issue is worked on in a repository, for polyrepo tracking.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"issue_id": {"type": "string", "description": "Beads issue ID such as proj-123"},
+					"repository": {"type": "string", "description": "Repository URL or name"}
+				},
+				"required": ["issue_id", "repository"],
+				"additionalProperties": false
+			}`),
+			Handler: withEnv(e, mcpAnnotate),
+		},
+		{
+			Name: "sync_status",
+			Description: "Show what is in .beads: issue and epic counts by status and priority, when each watched query last synced, " +
+				"issues edited locally since, unresolved sync conflicts, issues without a Jira key, and worklogs not yet pushed to Jira.",
+			InputSchema: json.RawMessage(`{"type": "object", "properties": {}, "additionalProperties": false}`),
+			Handler:     withEnv(e, mcpSyncStatus),
+		},
+		{
+			Name:        "push_changes",
+			Description: "Push time logged locally with log-work to Jira. Returns the worklogs pushed.",
+			InputSchema: json.RawMessage(`{"type": "object", "properties": {}, "additionalProperties": false}`),
+			Handler:     withEnv(e, mcpPushChanges),
+		},
+	}
+}
+
+// withEnv binds a tool handler to the env it works in
+func withEnv(e *env, handler func(context.Context, *env, json.RawMessage) (interface{}, error)) func(context.Context, json.RawMessage) (interface{}, error) {
+	return func(ctx context.Context, args json.RawMessage) (interface{}, error) {
+		return handler(ctx, e, args)
+	}
+}
+
+// fetchResult is what the fetch tools merged into .beads
+type fetchResult struct {
+	Issues  []*beads.BeadsIssue `json:"issues"`
+	Epics   []*beads.BeadsEpic  `json:"epics"`
+	Skipped []skippedIssue      `json:"skipped,omitempty"`
+	// Problems are dangling references, cycles and other problems found in
+	// .beads after merging
+	Problems []string `json:"problems,omitempty"`
+	Dir      string   `json:"dir"`
+}
+
+// skippedIssue is a referenced issue left as a dangling reference
+type skippedIssue struct {
+	Key          string `json:"key"`
+	ReferencedBy string `json:"referencedBy"`
+	Reason       string `json:"reason"`
+	Failed       bool   `json:"failed,omitempty"`
+}
+
+func mcpFetchIssueTree(ctx context.Context, e *env, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Issue string `json:"issue"`
+	}
+	if err := mcp.DecodeArgs(args, &params); err != nil {
+		return nil, err
+	}
+	if params.Issue == "" {
+		return nil, fmt.Errorf("issue is required")
+	}
+
+	var target *jira.URLTarget
+	var targetURL string
+	if isURL(params.Issue) {
+		var err error
+		if target, err = jira.ParseURL(params.Issue); err != nil {
+			return nil, err
+		}
+		targetURL = target.BaseURL
+	}
+
+	// A URL on a host no profile is configured for is refused here, before
+	// any request could send it credentials
+	cfg, err := loadConfig(e, targetURL)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		target = &jira.URLTarget{Kind: jira.URLKindIssue, IssueKey: params.Issue, BaseURL: cfg.Jira.BaseURL}
+	}
+	return mcpFetch(ctx, e, cfg, target.BaseURL, func(client *jira.Client) (*jirapb.Export, error) {
+		return fetchTarget(e, client, target)
+	})
+}
+
+func mcpFetchJQL(ctx context.Context, e *env, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		JQL string `json:"jql"`
+	}
+	if err := mcp.DecodeArgs(args, &params); err != nil {
+		return nil, err
+	}
+	if params.JQL == "" {
+		return nil, fmt.Errorf("jql is required")
+	}
+
+	cfg, err := loadConfig(e, "")
+	if err != nil {
+		return nil, err
+	}
+	jql := cfg.ResolveQuery(params.JQL)
+	return mcpFetch(ctx, e, cfg, cfg.Jira.BaseURL, func(client *jira.Client) (*jirapb.Export, error) {
+		return client.FetchIssuesByJQL(jql)
+	})
+}
+
+// mcpFetch fetches with a client for baseURL, then converts and merges the
+// issues into .beads, keeping issues from earlier fetches
+func mcpFetch(ctx context.Context, e *env, cfg *config.Config, baseURL string, fetch func(*jira.Client) (*jirapb.Export, error)) (*fetchResult, error) {
+	policy, err := removedPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := validationMode(cfg)
+	if err != nil {
+		return nil, err
+	}
+	conv, err := converterFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newClient(cfg, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	jiraExport, err := fetch(client.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch issues: %w", err)
+	}
+
+	converted, err := conv.Convert(jiraExport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert: %w", err)
+	}
+
+	dir := e.dir
+	renderer := beads.NewJSONLRenderer(dir)
+	renderer.SetReporter(reporter)
+	existing, err := renderer.ReadExport()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read existing beads: %w", err)
+	}
+	reconcile.Apply(existing, reconcile.DetectMoves(existing, converted), policy)
+	merged := beads.Merge(existing, converted)
+	problems, err := validate.Validate(merged, mode)
+	if err != nil {
+		return nil, err
+	}
+	if err := renderer.RenderExport(merged); err != nil {
+		return nil, fmt.Errorf("failed to render: %w", err)
+	}
+	if err := syncer.RecordSynced(dir, converted); err != nil {
+		return nil, err
+	}
+
+	fetched := &fetchResult{
+		Issues: make([]*beads.BeadsIssue, 0, len(converted.Issues)),
+		Epics:  make([]*beads.BeadsEpic, 0, len(converted.Epics)),
+		Dir:    filepath.Join(dir, ".beads"),
+	}
+	for _, issue := range converted.Issues {
+		fetched.Issues = append(fetched.Issues, beads.IssueToJSON(issue))
+	}
+	for _, epic := range converted.Epics {
+		fetched.Epics = append(fetched.Epics, beads.EpicToJSON(epic))
+	}
+	for _, problem := range problems {
+		fetched.Problems = append(fetched.Problems, problem.String())
+	}
+	for _, skip := range jiraExport.Skipped {
+		fetched.Skipped = append(fetched.Skipped, skippedIssue{
+			Key:          skip.Key,
+			ReferencedBy: skip.ReferencedBy,
+			Reason:       skip.Reason,
+			Failed:       skip.Failed,
+		})
+	}
+	return fetched, nil
+}
+
+func mcpAnnotate(ctx context.Context, e *env, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		IssueID    string `json:"issue_id"`
+		Repository string `json:"repository"`
+	}
+	if err := mcp.DecodeArgs(args, &params); err != nil {
+		return nil, err
+	}
+	if params.IssueID == "" || params.Repository == "" {
+		return nil, fmt.Errorf("issue_id and repository are required")
+	}
+
+	dir := e.dir
+	if err := beads.NewJSONLRenderer(dir).AddRepositoryAnnotation(params.IssueID, params.Repository); err != nil {
+		return nil, fmt.Errorf("failed to annotate issue: %w", err)
+	}
+	return map[string]string{"issueId": params.IssueID, "repository": params.Repository}, nil
+}
+
+func mcpSyncStatus(ctx context.Context, e *env, args json.RawMessage) (interface{}, error) {
+	if err := mcp.DecodeArgs(args, &struct{}{}); err != nil {
+		return nil, err
+	}
+
+	dir := e.dir
+	return readSyncStatus(dir)
+}
+
+func mcpPushChanges(ctx context.Context, e *env, args json.RawMessage) (interface{}, error) {
+	if err := mcp.DecodeArgs(args, &struct{}{}); err != nil {
+		return nil, err
+	}
+
+	cfg, err := loadConfig(e, "")
+	if err != nil {
+		return nil, err
+	}
+	client, err := newClient(cfg, cfg.Jira.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	dir := e.dir
+
+	pushed, failedKey, err := pushPendingWorklogs(client.WithContext(ctx), beads.NewJSONLRenderer(dir))
+	if err != nil && len(pushed) == 0 {
+		return nil, err
+	}
+	if pushed == nil {
+		pushed = []*beads.WorklogEntry{}
+	}
+
+	// Worklogs pushed before a failure are reported with it
+	pushResult := map[string]interface{}{"pushed": pushed}
+	if err != nil {
+		pushResult["failed"] = map[string]string{"key": failedKey, "error": err.Error()}
+	}
+	return pushResult, nil
+}
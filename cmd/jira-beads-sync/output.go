@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/conallob/jira-beads-sync/internal/jira"
+	"github.com/conallob/jira-beads-sync/internal/oauth"
+	"github.com/conallob/jira-beads-sync/internal/syncer"
+	"github.com/conallob/jira-beads-sync/internal/validate"
+)
+
+// Output formats selected with --output
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// report is the result of a command, printed as a single JSON object with
+// --output json
+type report struct {
+	Command  string         `json:"command"`
+	Status   string         `json:"status"` // ok, partial or error
+	ExitCode int            `json:"exit_code"`
+	Counts   map[string]int `json:"counts,omitempty"`
+	Files    []string       `json:"files,omitempty"`
+	Warnings []string       `json:"warnings,omitempty"`
+	Errors   []issueError   `json:"errors,omitempty"`
+	Error    string         `json:"error,omitempty"`
+
+	// Data holds command-specific details, e.g. the user for whoami
+	Data interface{} `json:"data,omitempty"`
+}
+
+// issueError is a failure affecting one issue while the command went on
+type issueError struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// result collects the outcome of the running command
+var result = &report{}
+
+// count adds n to a named count
+func (r *report) count(name string, n int) {
+	if r.Counts == nil {
+		r.Counts = make(map[string]int)
+	}
+	r.Counts[name] += n
+}
+
+// file records a file the command wrote
+func (r *report) file(path string) {
+	r.Files = append(r.Files, path)
+}
+
+// warn records something the user should know about
+func (r *report) warn(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// fail records a failure for one issue. Commands that finish with issue
+// failures exit with exitPartial.
+func (r *report) fail(key string, err error) {
+	r.Errors = append(r.Errors, issueError{Key: key, Error: err.Error()})
+}
+
+// finish records the command's outcome and returns its exit code
+func (r *report) finish(err error) int {
+	r.ExitCode = exitCode(err, len(r.Errors) > 0)
+	switch {
+	case err != nil:
+		r.Status = "error"
+		r.Error = err.Error()
+	case r.ExitCode == exitPartial:
+		r.Status = "partial"
+	default:
+		r.Status = "ok"
+	}
+	return r.ExitCode
+}
+
+// write prints the report as indented JSON
+func (r *report) write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// exitCode maps a command's error to the exit code scripts can rely on
+func exitCode(err error, partial bool) int {
+	var usageErr *usageError
+	switch {
+	case err == nil && partial:
+		return exitPartial
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.Is(err, jira.ErrUnauthorized), errors.Is(err, jira.ErrForbidden), errors.Is(err, oauth.ErrNotLoggedIn):
+		return exitAuth
+	case errors.Is(err, jira.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, jira.ErrConflict), errors.Is(err, syncer.ErrConflict):
+		return exitConflict
+	case errors.Is(err, validate.ErrInvalid):
+		return exitInvalid
+	default:
+		return exitError
+	}
+}
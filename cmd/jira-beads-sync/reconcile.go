@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+	"github.com/conallob/jira-beads-sync/internal/reconcile"
+	"github.com/conallob/jira-beads-sync/internal/syncer"
+)
+
+// Options for the reconcile command
+var (
+	reconcilePolicy string
+	reconcileDryRun bool
+)
+
+// registerReconcileFlags adds the reconcile command's options
+func registerReconcileFlags(fs *flag.FlagSet) {
+	fs.StringVar(&reconcilePolicy, "policy", "", "what happens to issues deleted or moved in Jira: close, tombstone or delete (default: sync.removed from the config, or close)")
+	fs.BoolVar(&reconcileDryRun, "dry-run", false, "list deleted and moved issues without changing .beads")
+}
+
+// runReconcile checks every tracked issue against Jira and applies the
+// policy to those deleted or moved
+func runReconcile(e *env) error {
+	e.println("jira-beads-sync reconcile")
+	e.println("=========================")
+	e.println()
+
+	cfg, err := loadConfig(e, "")
+	if err != nil {
+		return err
+	}
+	policy, err := removedPolicy(cfg)
+	if err != nil {
+		return err
+	}
+	if reconcilePolicy != "" {
+		if policy, err = reconcile.ParsePolicy(reconcilePolicy); err != nil {
+			return &usageError{err.Error()}
+		}
+	}
+	mapping, err := mappingFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(cfg, cfg.Jira.BaseURL)
+	if err != nil {
+		return err
+	}
+	dir := e.dir
+	renderer := beads.NewJSONLRenderer(dir)
+	renderer.SetReporter(reporter)
+	existing, err := renderer.ReadExport()
+	if err != nil {
+		return fmt.Errorf("failed to read existing beads: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	e.println("Checking tracked issues in Jira...")
+	changes, moved, err := reconcile.Check(ctx, client, hierarchyFromConfig(cfg), mapping, existing, reporter)
+	if err != nil {
+		return fmt.Errorf("failed to check issues: %w", err)
+	}
+
+	for _, change := range changes {
+		e.printf("  %s\n", change)
+		if change.Deleted() {
+			result.count("deleted", 1)
+		} else {
+			result.count("moved", 1)
+		}
+	}
+	if len(changes) == 0 {
+		e.println("✓ Every tracked issue is still in Jira under the same key")
+		return nil
+	}
+	if reconcileDryRun {
+		e.printf("\nDry run: %d issue(s) would be handled with the %s policy\n", len(changes), policy)
+		return nil
+	}
+
+	reconcile.Apply(existing, changes, policy)
+	if err := renderer.RenderExport(beads.Merge(existing, moved)); err != nil {
+		return fmt.Errorf("failed to render: %w", err)
+	}
+	if err := syncer.RecordSynced(dir, reconciled(existing, changes, moved)); err != nil {
+		return err
+	}
+
+	e.printf("\n✓ Applied the %s policy to %d issue(s)\n", policy, len(changes))
+	result.file(filepath.Join(dir, ".beads", "issues.jsonl"))
+	return nil
+}
+
+// reconciled returns the issues and epics the policy changed, with the
+// issues fetched under their new keys
+func reconciled(existing *beadspb.Export, changes []reconcile.Change, moved *beadspb.Export) *beadspb.Export {
+	changed := make(map[string]bool)
+	for _, change := range changes {
+		changed[change.ID] = true
+	}
+
+	synced := &beadspb.Export{}
+	for _, issue := range existing.Issues {
+		if changed[issue.Id] {
+			synced.Issues = append(synced.Issues, issue)
+		}
+	}
+	for _, epic := range existing.Epics {
+		if changed[epic.Id] {
+			synced.Epics = append(synced.Epics, epic)
+		}
+	}
+	synced.Issues = append(synced.Issues, moved.GetIssues()...)
+	synced.Epics = append(synced.Epics, moved.GetEpics()...)
+	return synced
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/conallob/jira-beads-sync/internal/config"
+	"github.com/conallob/jira-beads-sync/internal/jira"
+	"github.com/conallob/jira-beads-sync/internal/webhook"
+)
+
+// webhookSecretEnv names the environment variable holding the secret Jira
+// signs webhook payloads with
+const webhookSecretEnv = "JIRA_WEBHOOK_SECRET"
+
+// Options for the serve command
+var (
+	serveAddr string
+	servePath string
+)
+
+// registerServeFlags adds the serve command's options
+func registerServeFlags(fs *flag.FlagSet) {
+	fs.StringVar(&serveAddr, "addr", ":8080", "address to listen on")
+	fs.StringVar(&servePath, "path", "/webhook", "URL path Jira posts webhooks to")
+}
+
+// runServe receives Jira webhooks and applies them to .beads until
+// interrupted
+func runServe(e *env) error {
+	secret := os.Getenv(webhookSecretEnv)
+	if secret == "" {
+		return &usageError{fmt.Sprintf("%s must be set to the webhook's secret", webhookSecretEnv)}
+	}
+
+	cfg, err := config.LoadProfile(e.dir, profileName)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	policy, err := removedPolicy(cfg)
+	if err != nil {
+		return err
+	}
+	mode, err := validationMode(cfg)
+	if err != nil {
+		return err
+	}
+	mapping, err := mappingFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	dir := e.dir
+
+	// Payloads are read with the same custom fields as fetch
+	hierarchy := hierarchyFromConfig(cfg)
+	adapter := jira.NewAdapter()
+	adapter.ParentLinkField = hierarchy.ParentLinkField
+	adapter.StoryPointsField = cfg.Fields.StoryPoints
+
+	handler := webhook.NewHandler(dir, secret, hierarchy, adapter)
+	handler.SetPolicy(policy)
+	handler.SetValidation(mode)
+	handler.SetMapping(mapping)
+	handler.SetReporter(reporter)
+	mux := http.NewServeMux()
+	mux.Handle(servePath, handler)
+	server := &http.Server{
+		Addr:              serveAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// SIGTERM and Ctrl-C finish the requests in progress and stop cleanly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.ListenAndServe()
+	}()
+	reporter.Info("Listening for Jira webhooks", "addr", serveAddr, "path", servePath, "dir", dir)
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("failed to serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+	reporter.Info("Stopped serving")
+	return nil
+}
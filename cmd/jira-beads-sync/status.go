@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/conallob/jira-beads-sync/internal/beads"
+	"github.com/conallob/jira-beads-sync/internal/config"
+	"github.com/conallob/jira-beads-sync/internal/syncer"
+)
+
+// statusOrder is the order statuses and priorities are listed in; others
+// follow alphabetically
+var statusOrder = []string{"open", "in_progress", "blocked", "closed", "tombstone", "p0", "p1", "p2", "p3", "p4", "none"}
+
+// syncStatus summarizes .beads for the status command and sync_status tool
+type syncStatus struct {
+	Dir             string         `json:"dir"`
+	Issues          map[string]int `json:"issues"`     // By status
+	Epics           map[string]int `json:"epics"`      // By status
+	Priorities      map[string]int `json:"priorities"` // Issues by priority; none if unset
+	WithoutJiraKey  []string       `json:"withoutJiraKey"`
+	PendingWorklogs int            `json:"pendingWorklogs"`
+	*syncer.Status
+}
+
+// readSyncStatus summarizes the .beads directory in dir without contacting
+// Jira
+func readSyncStatus(dir string) (*syncStatus, error) {
+	renderer := beads.NewJSONLRenderer(dir)
+	existing, err := renderer.ReadExport()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read existing beads: %w", err)
+	}
+	worklogs, err := renderer.ReadWorklogs()
+	if err != nil {
+		return nil, err
+	}
+	synced, err := syncer.ReadStatus(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &syncStatus{
+		Dir:            filepath.Join(dir, ".beads"),
+		Issues:         make(map[string]int),
+		Epics:          make(map[string]int),
+		Priorities:     make(map[string]int),
+		WithoutJiraKey: []string{},
+		Status:         synced,
+	}
+	for _, issue := range existing.Issues {
+		j := beads.IssueToJSON(issue)
+		status.Issues[j.Status]++
+		priority := j.Priority
+		if priority == "" {
+			priority = "none"
+		}
+		status.Priorities[priority]++
+		if issue.GetMetadata().GetJiraKey() == "" {
+			status.WithoutJiraKey = append(status.WithoutJiraKey, issue.Id)
+		}
+	}
+	for _, epic := range existing.Epics {
+		status.Epics[beads.EpicToJSON(epic).Status]++
+		if epic.GetMetadata().GetJiraKey() == "" {
+			status.WithoutJiraKey = append(status.WithoutJiraKey, epic.Id)
+		}
+	}
+	for _, entry := range worklogs {
+		if !entry.Pushed() {
+			status.PendingWorklogs++
+		}
+	}
+	return status, nil
+}
+
+// runStatus reports how stale .beads is, from the local files alone
+func runStatus(e *env) error {
+	status, err := readSyncStatus(e.dir)
+	if err != nil {
+		return err
+	}
+	result.Data = status
+	result.count("issues", total(status.Issues))
+	result.count("epics", total(status.Epics))
+	result.count("edited_locally", len(status.EditedLocally))
+	result.count("conflicts", len(status.Conflicts))
+	result.count("without_jira_key", len(status.WithoutJiraKey))
+	result.count("pending_worklogs", status.PendingWorklogs)
+
+	e.println("jira-beads-sync status")
+	e.println("======================")
+	e.println()
+	e.printf("Directory: %s\n\n", status.Dir)
+
+	// Show saved query names rather than their JQL where there's a config.
+	// The files are read as written, so a broken profile or credential
+	// setting doesn't matter here.
+	names := make(map[string]string)
+	if cfg, err := config.ReadFile(); err == nil {
+		for name, jql := range cfg.Queries {
+			names[jql] = name
+		}
+	}
+	if project, _, err := config.ReadProjectFile(e.dir); err == nil && project != nil {
+		for name, jql := range project.Queries {
+			names[jql] = name
+		}
+	}
+	if len(status.LastSynced) == 0 {
+		e.println("Last synced: never (run 'jira-beads-sync watch' to keep .beads in sync)")
+	} else {
+		e.println("Last synced:")
+		queries := make([]string, 0, len(status.LastSynced))
+		for jql := range status.LastSynced {
+			queries = append(queries, jql)
+		}
+		sort.Strings(queries)
+		for _, jql := range queries {
+			label := jql
+			if name, ok := names[jql]; ok {
+				label = fmt.Sprintf("%s (%s)", name, jql)
+			}
+			synced := status.LastSynced[jql]
+			e.printf("  %s: %s (%s)\n", label, synced.Local().Format("2006-01-02 15:04"), formatAge(time.Since(synced)))
+		}
+	}
+	e.println()
+
+	e.printf("Issues: %d%s\n", total(status.Issues), formatCounts(status.Issues))
+	if len(status.Priorities) > 0 {
+		e.printf("  By priority%s\n", formatCounts(status.Priorities))
+	}
+	e.printf("Epics: %d%s\n", total(status.Epics), formatCounts(status.Epics))
+	e.printf("Worklogs not yet pushed: %d\n", status.PendingWorklogs)
+	e.println()
+
+	if len(status.EditedLocally)+len(status.Conflicts)+len(status.WithoutJiraKey) == 0 {
+		e.println("✓ No local edits, conflicts or issues without a Jira key")
+		return nil
+	}
+	printIDs(e, "Edited locally since last synced", status.EditedLocally)
+	printIDs(e, "Changed both locally and in Jira, left as edited locally", status.Conflicts)
+	printIDs(e, "Without a Jira key, so not synced", status.WithoutJiraKey)
+	return nil
+}
+
+// formatAge describes how long ago something happened, to the minute
+func formatAge(age time.Duration) string {
+	if age < time.Minute {
+		return "just now"
+	}
+	return strings.TrimSuffix(age.Round(time.Minute).String(), "0s") + " ago"
+}
+
+// total sums counts
+func total(counts map[string]int) int {
+	n := 0
+	for _, count := range counts {
+		n += count
+	}
+	return n
+}
+
+// formatCounts lists counts as " (open 3, closed 2)", in statusOrder, or
+// nothing if there are none
+func formatCounts(counts map[string]int) string {
+	if len(counts) == 0 {
+		return ""
+	}
+	rank := make(map[string]int)
+	for i, name := range statusOrder {
+		rank[name] = i + 1
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ri, rj := rank[names[i]], rank[names[j]]
+		if ri == 0 || rj == 0 {
+			return rj == 0 && (ri != 0 || names[i] < names[j])
+		}
+		return ri < rj
+	})
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s %d", name, counts[name]))
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// printIDs prints a labelled list of issue IDs, if there are any
+func printIDs(e *env, label string, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	e.printf("⚠ %s (%d): %s\n", label, len(ids), strings.Join(ids, ", "))
+}
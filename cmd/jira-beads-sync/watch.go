@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/conallob/jira-beads-sync/internal/config"
+	"github.com/conallob/jira-beads-sync/internal/syncer"
+)
+
+// Options for the watch command
+var (
+	watchInterval   time.Duration
+	watchMaxBackoff time.Duration
+	watchCommit     bool
+	watchOnce       bool
+	watchOverwrite  bool
+)
+
+// registerWatchFlags adds the watch command's options
+func registerWatchFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&watchInterval, "interval", 5*time.Minute, "how often to sync")
+	fs.DurationVar(&watchMaxBackoff, "max-backoff", time.Hour, "longest wait between retries after failed cycles")
+	fs.BoolVar(&watchCommit, "commit", false, "commit changed .beads files to git after each cycle")
+	fs.BoolVar(&watchOnce, "once", false, "run a single cycle and exit, e.g. from cron")
+	fs.BoolVar(&watchOverwrite, "overwrite", false, "replace issues edited locally with the Jira version instead of reporting a conflict")
+}
+
+// runWatch keeps .beads in sync with the given queries, or every saved
+// query, until interrupted
+func runWatch(e *env, queries []string) error {
+	cfg, err := loadConfig(e, "")
+	if err != nil {
+		return err
+	}
+
+	jqls := watchQueries(cfg, queries)
+	if len(jqls) == 0 {
+		return &usageError{"no queries to watch: pass saved query names or JQL, or add queries to the config"}
+	}
+
+	client, err := newClient(cfg, cfg.Jira.BaseURL)
+	if err != nil {
+		return err
+	}
+	policy, err := removedPolicy(cfg)
+	if err != nil {
+		return err
+	}
+	mode, err := validationMode(cfg)
+	if err != nil {
+		return err
+	}
+	mapping, err := mappingFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	dir := e.dir
+
+	s := syncer.New(client, hierarchyFromConfig(cfg), dir, syncer.Options{
+		Queries:    jqls,
+		Commit:     watchCommit,
+		Overwrite:  watchOverwrite,
+		Removed:    policy,
+		Validation: mode,
+		Mapping:    mapping,
+	})
+	s.SetReporter(reporter)
+
+	// SIGTERM and Ctrl-C cancel the cycle in progress and stop cleanly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !watchOnce {
+		reporter.Info("Watching Jira", "queries", len(jqls), "interval", watchInterval, "dir", dir)
+		err := s.Run(ctx, watchInterval, watchMaxBackoff)
+		reporter.Info("Stopped watching")
+		return err
+	}
+
+	summary, err := s.Cycle(ctx)
+	if err != nil {
+		return err
+	}
+	result.count("fetched", summary.Fetched)
+	result.count("added", summary.Added)
+	result.count("updated", summary.Updated)
+	result.count("moved", summary.Moved)
+	result.count("problems", len(summary.Problems))
+	for _, problem := range summary.Problems {
+		result.warn("%s", problem)
+	}
+	if summary.Changed() {
+		result.file(filepath.Join(dir, ".beads", "issues.jsonl"))
+	}
+	if len(summary.Conflicts) > 0 {
+		return fmt.Errorf("%s %w; resolve the local edits or run with --overwrite",
+			strings.Join(summary.Conflicts, ", "), syncer.ErrConflict)
+	}
+	return nil
+}
+
+// watchQueries resolves saved query names to JQL. Without arguments every
+// saved query is watched.
+func watchQueries(cfg *config.Config, queries []string) []string {
+	if len(queries) == 0 {
+		for name := range cfg.Queries {
+			queries = append(queries, name)
+		}
+		sort.Strings(queries)
+	}
+
+	jqls := make([]string, 0, len(queries))
+	for _, query := range queries {
+		jqls = append(jqls, cfg.ResolveQuery(query))
+	}
+	return jqls
+}
@@ -31,6 +31,7 @@ const (
 	Status_STATUS_IN_PROGRESS Status = 2
 	Status_STATUS_BLOCKED     Status = 3
 	Status_STATUS_CLOSED      Status = 4
+	Status_STATUS_TOMBSTONE   Status = 5 // Deleted or moved in Jira, kept so references resolve
 )
 
 // Enum value maps for Status.
@@ -41,6 +42,7 @@ var (
 		2: "STATUS_IN_PROGRESS",
 		3: "STATUS_BLOCKED",
 		4: "STATUS_CLOSED",
+		5: "STATUS_TOMBSTONE",
 	}
 	Status_value = map[string]int32{
 		"STATUS_UNSPECIFIED": 0,
@@ -48,6 +50,7 @@ var (
 		"STATUS_IN_PROGRESS": 2,
 		"STATUS_BLOCKED":     3,
 		"STATUS_CLOSED":      4,
+		"STATUS_TOMBSTONE":   5,
 	}
 )
 
@@ -139,21 +142,22 @@ func (Priority) EnumDescriptor() ([]byte, []int) {
 
 // Issue represents a beads issue stored as YAML in .beads/issues/
 type Issue struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	Status        Status                 `protobuf:"varint,4,opt,name=status,proto3,enum=beads.Status" json:"status,omitempty"`
-	Priority      Priority               `protobuf:"varint,5,opt,name=priority,proto3,enum=beads.Priority" json:"priority,omitempty"`
-	Epic          string                 `protobuf:"bytes,6,opt,name=epic,proto3" json:"epic,omitempty"`
-	Assignee      string                 `protobuf:"bytes,7,opt,name=assignee,proto3" json:"assignee,omitempty"`
-	Labels        []string               `protobuf:"bytes,8,rep,name=labels,proto3" json:"labels,omitempty"`
-	DependsOn     []string               `protobuf:"bytes,9,rep,name=depends_on,json=dependsOn,proto3" json:"depends_on,omitempty"`
-	Created       *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=created,proto3" json:"created,omitempty"`
-	Updated       *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=updated,proto3" json:"updated,omitempty"`
-	Metadata      *Metadata              `protobuf:"bytes,12,opt,name=metadata,proto3" json:"metadata,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title            string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Description      string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Status           Status                 `protobuf:"varint,4,opt,name=status,proto3,enum=beads.Status" json:"status,omitempty"`
+	Priority         Priority               `protobuf:"varint,5,opt,name=priority,proto3,enum=beads.Priority" json:"priority,omitempty"`
+	Epic             string                 `protobuf:"bytes,6,opt,name=epic,proto3" json:"epic,omitempty"`
+	Assignee         string                 `protobuf:"bytes,7,opt,name=assignee,proto3" json:"assignee,omitempty"`
+	Labels           []string               `protobuf:"bytes,8,rep,name=labels,proto3" json:"labels,omitempty"`
+	DependsOn        []string               `protobuf:"bytes,9,rep,name=depends_on,json=dependsOn,proto3" json:"depends_on,omitempty"`
+	Created          *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=created,proto3" json:"created,omitempty"`
+	Updated          *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=updated,proto3" json:"updated,omitempty"`
+	Metadata         *Metadata              `protobuf:"bytes,12,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	EstimatedMinutes int32                  `protobuf:"varint,13,opt,name=estimated_minutes,json=estimatedMinutes,proto3" json:"estimated_minutes,omitempty"` // Original estimate carried over from Jira
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *Issue) Reset() {
@@ -270,6 +274,13 @@ func (x *Issue) GetMetadata() *Metadata {
 	return nil
 }
 
+func (x *Issue) GetEstimatedMinutes() int32 {
+	if x != nil {
+		return x.EstimatedMinutes
+	}
+	return 0
+}
+
 // Metadata stores additional information about the issue
 type Metadata struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -357,6 +368,7 @@ type Epic struct {
 	Created       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created,proto3" json:"created,omitempty"`
 	Updated       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated,proto3" json:"updated,omitempty"`
 	Metadata      *Metadata              `protobuf:"bytes,7,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Parent        string                 `protobuf:"bytes,8,opt,name=parent,proto3" json:"parent,omitempty"` // ID of the higher-level epic (e.g., an initiative) containing this one
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -440,6 +452,13 @@ func (x *Epic) GetMetadata() *Metadata {
 	return nil
 }
 
+func (x *Epic) GetParent() string {
+	if x != nil {
+		return x.Parent
+	}
+	return ""
+}
+
 // Export represents a collection of beads issues and epics for export
 type Export struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -497,7 +516,7 @@ var File_beads_proto protoreflect.FileDescriptor
 
 const file_beads_proto_rawDesc = "" +
 	"\n" +
-	"\vbeads.proto\x12\x05beads\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa3\x03\n" +
+	"\vbeads.proto\x12\x05beads\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd0\x03\n" +
 	"\x05Issue\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
 	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
@@ -512,7 +531,8 @@ const file_beads_proto_rawDesc = "" +
 	"\acreated\x18\n" +
 	" \x01(\v2\x1a.google.protobuf.TimestampR\acreated\x124\n" +
 	"\aupdated\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\aupdated\x12+\n" +
-	"\bmetadata\x18\f \x01(\v2\x0f.beads.MetadataR\bmetadata\"\xfa\x01\n" +
+	"\bmetadata\x18\f \x01(\v2\x0f.beads.MetadataR\bmetadata\x12+\n" +
+	"\x11estimated_minutes\x18\r \x01(\x05R\x10estimatedMinutes\"\xfa\x01\n" +
 	"\bMetadata\x12\x19\n" +
 	"\bjira_key\x18\x01 \x01(\tR\ajiraKey\x12\x17\n" +
 	"\ajira_id\x18\x02 \x01(\tR\x06jiraId\x12&\n" +
@@ -521,7 +541,7 @@ const file_beads_proto_rawDesc = "" +
 	"\frepositories\x18\x05 \x03(\tR\frepositories\x1a9\n" +
 	"\vCustomEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x8c\x02\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa4\x02\n" +
 	"\x04Epic\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
@@ -529,16 +549,18 @@ const file_beads_proto_rawDesc = "" +
 	"\x06status\x18\x04 \x01(\x0e2\r.beads.StatusR\x06status\x124\n" +
 	"\acreated\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\acreated\x124\n" +
 	"\aupdated\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\aupdated\x12+\n" +
-	"\bmetadata\x18\a \x01(\v2\x0f.beads.MetadataR\bmetadata\"Q\n" +
+	"\bmetadata\x18\a \x01(\v2\x0f.beads.MetadataR\bmetadata\x12\x16\n" +
+	"\x06parent\x18\b \x01(\tR\x06parent\"Q\n" +
 	"\x06Export\x12$\n" +
 	"\x06issues\x18\x01 \x03(\v2\f.beads.IssueR\x06issues\x12!\n" +
-	"\x05epics\x18\x02 \x03(\v2\v.beads.EpicR\x05epics*p\n" +
+	"\x05epics\x18\x02 \x03(\v2\v.beads.EpicR\x05epics*\x86\x01\n" +
 	"\x06Status\x12\x16\n" +
 	"\x12STATUS_UNSPECIFIED\x10\x00\x12\x0f\n" +
 	"\vSTATUS_OPEN\x10\x01\x12\x16\n" +
 	"\x12STATUS_IN_PROGRESS\x10\x02\x12\x12\n" +
 	"\x0eSTATUS_BLOCKED\x10\x03\x12\x11\n" +
-	"\rSTATUS_CLOSED\x10\x04*y\n" +
+	"\rSTATUS_CLOSED\x10\x04\x12\x14\n" +
+	"\x10STATUS_TOMBSTONE\x10\x05*y\n" +
 	"\bPriority\x12\x18\n" +
 	"\x14PRIORITY_UNSPECIFIED\x10\x00\x12\x0f\n" +
 	"\vPRIORITY_P0\x10\x01\x12\x0f\n" +
@@ -26,6 +26,7 @@ const (
 type Export struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Issues        []*Issue               `protobuf:"bytes,1,rep,name=issues,proto3" json:"issues,omitempty"`
+	Skipped       []*SkippedIssue        `protobuf:"bytes,2,rep,name=skipped,proto3" json:"skipped,omitempty"` // References left unfetched
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -67,6 +68,13 @@ func (x *Export) GetIssues() []*Issue {
 	return nil
 }
 
+func (x *Export) GetSkipped() []*SkippedIssue {
+	if x != nil {
+		return x.Skipped
+	}
+	return nil
+}
+
 // Issue represents a Jira issue (story, epic, subtask, etc.)
 type Issue struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -153,6 +161,11 @@ type Fields struct {
 	Parent        *Parent                `protobuf:"bytes,12,opt,name=parent,proto3" json:"parent,omitempty"`
 	Epic          *Epic                  `protobuf:"bytes,13,opt,name=epic,proto3" json:"epic,omitempty"`
 	Subtasks      []*Subtask             `protobuf:"bytes,14,rep,name=subtasks,proto3" json:"subtasks,omitempty"`
+	TimeTracking  *TimeTracking          `protobuf:"bytes,15,opt,name=time_tracking,json=timeTracking,proto3" json:"time_tracking,omitempty"`
+	Worklogs      []*Worklog             `protobuf:"bytes,16,rep,name=worklogs,proto3" json:"worklogs,omitempty"`
+	Sprint        *Sprint                `protobuf:"bytes,17,opt,name=sprint,proto3" json:"sprint,omitempty"` // Active or future sprint the issue is in
+	ClosedSprints []*Sprint              `protobuf:"bytes,18,rep,name=closed_sprints,json=closedSprints,proto3" json:"closed_sprints,omitempty"`
+	StoryPoints   float64                `protobuf:"fixed64,19,opt,name=story_points,json=storyPoints,proto3" json:"story_points,omitempty"` // From the configured story points custom field
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -285,14 +298,50 @@ func (x *Fields) GetSubtasks() []*Subtask {
 	return nil
 }
 
+func (x *Fields) GetTimeTracking() *TimeTracking {
+	if x != nil {
+		return x.TimeTracking
+	}
+	return nil
+}
+
+func (x *Fields) GetWorklogs() []*Worklog {
+	if x != nil {
+		return x.Worklogs
+	}
+	return nil
+}
+
+func (x *Fields) GetSprint() *Sprint {
+	if x != nil {
+		return x.Sprint
+	}
+	return nil
+}
+
+func (x *Fields) GetClosedSprints() []*Sprint {
+	if x != nil {
+		return x.ClosedSprints
+	}
+	return nil
+}
+
+func (x *Fields) GetStoryPoints() float64 {
+	if x != nil {
+		return x.StoryPoints
+	}
+	return 0
+}
+
 // IssueType represents the type of a Jira issue
 type IssueType struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
-	Subtask       bool                   `protobuf:"varint,3,opt,name=subtask,proto3" json:"subtask,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Name           string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description    string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Subtask        bool                   `protobuf:"varint,3,opt,name=subtask,proto3" json:"subtask,omitempty"`
+	HierarchyLevel int32                  `protobuf:"varint,4,opt,name=hierarchy_level,json=hierarchyLevel,proto3" json:"hierarchy_level,omitempty"` // Jira Cloud hierarchy: -1 subtask, 0 standard, 1 epic, 2+ above
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *IssueType) Reset() {
@@ -346,6 +395,13 @@ func (x *IssueType) GetSubtask() bool {
 	return false
 }
 
+func (x *IssueType) GetHierarchyLevel() int32 {
+	if x != nil {
+		return x.HierarchyLevel
+	}
+	return 0
+}
+
 // Status represents the current status of a Jira issue
 type Status struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
@@ -1049,19 +1105,362 @@ func (x *Subtask) GetFields() *LinkedFields {
 	return nil
 }
 
+// TimeTracking holds the estimates and logged time for an issue
+type TimeTracking struct {
+	state                    protoimpl.MessageState `protogen:"open.v1"`
+	OriginalEstimate         string                 `protobuf:"bytes,1,opt,name=original_estimate,json=originalEstimate,proto3" json:"original_estimate,omitempty"` // e.g., "1d 2h"
+	RemainingEstimate        string                 `protobuf:"bytes,2,opt,name=remaining_estimate,json=remainingEstimate,proto3" json:"remaining_estimate,omitempty"`
+	TimeSpent                string                 `protobuf:"bytes,3,opt,name=time_spent,json=timeSpent,proto3" json:"time_spent,omitempty"`
+	OriginalEstimateSeconds  int64                  `protobuf:"varint,4,opt,name=original_estimate_seconds,json=originalEstimateSeconds,proto3" json:"original_estimate_seconds,omitempty"`
+	RemainingEstimateSeconds int64                  `protobuf:"varint,5,opt,name=remaining_estimate_seconds,json=remainingEstimateSeconds,proto3" json:"remaining_estimate_seconds,omitempty"`
+	TimeSpentSeconds         int64                  `protobuf:"varint,6,opt,name=time_spent_seconds,json=timeSpentSeconds,proto3" json:"time_spent_seconds,omitempty"`
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
+}
+
+func (x *TimeTracking) Reset() {
+	*x = TimeTracking{}
+	mi := &file_jira_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimeTracking) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeTracking) ProtoMessage() {}
+
+func (x *TimeTracking) ProtoReflect() protoreflect.Message {
+	mi := &file_jira_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeTracking.ProtoReflect.Descriptor instead.
+func (*TimeTracking) Descriptor() ([]byte, []int) {
+	return file_jira_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *TimeTracking) GetOriginalEstimate() string {
+	if x != nil {
+		return x.OriginalEstimate
+	}
+	return ""
+}
+
+func (x *TimeTracking) GetRemainingEstimate() string {
+	if x != nil {
+		return x.RemainingEstimate
+	}
+	return ""
+}
+
+func (x *TimeTracking) GetTimeSpent() string {
+	if x != nil {
+		return x.TimeSpent
+	}
+	return ""
+}
+
+func (x *TimeTracking) GetOriginalEstimateSeconds() int64 {
+	if x != nil {
+		return x.OriginalEstimateSeconds
+	}
+	return 0
+}
+
+func (x *TimeTracking) GetRemainingEstimateSeconds() int64 {
+	if x != nil {
+		return x.RemainingEstimateSeconds
+	}
+	return 0
+}
+
+func (x *TimeTracking) GetTimeSpentSeconds() int64 {
+	if x != nil {
+		return x.TimeSpentSeconds
+	}
+	return 0
+}
+
+// Worklog represents a single unit of work logged against an issue
+type Worklog struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Author           *User                  `protobuf:"bytes,2,opt,name=author,proto3" json:"author,omitempty"`
+	Comment          string                 `protobuf:"bytes,3,opt,name=comment,proto3" json:"comment,omitempty"`
+	Started          *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=started,proto3" json:"started,omitempty"`
+	TimeSpent        string                 `protobuf:"bytes,5,opt,name=time_spent,json=timeSpent,proto3" json:"time_spent,omitempty"`
+	TimeSpentSeconds int64                  `protobuf:"varint,6,opt,name=time_spent_seconds,json=timeSpentSeconds,proto3" json:"time_spent_seconds,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Worklog) Reset() {
+	*x = Worklog{}
+	mi := &file_jira_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Worklog) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Worklog) ProtoMessage() {}
+
+func (x *Worklog) ProtoReflect() protoreflect.Message {
+	mi := &file_jira_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Worklog.ProtoReflect.Descriptor instead.
+func (*Worklog) Descriptor() ([]byte, []int) {
+	return file_jira_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Worklog) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Worklog) GetAuthor() *User {
+	if x != nil {
+		return x.Author
+	}
+	return nil
+}
+
+func (x *Worklog) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+func (x *Worklog) GetStarted() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Started
+	}
+	return nil
+}
+
+func (x *Worklog) GetTimeSpent() string {
+	if x != nil {
+		return x.TimeSpent
+	}
+	return ""
+}
+
+func (x *Worklog) GetTimeSpentSeconds() int64 {
+	if x != nil {
+		return x.TimeSpentSeconds
+	}
+	return 0
+}
+
+// Sprint represents a Jira Software (Agile) sprint
+type Sprint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	State         string                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"` // "future", "active", "closed"
+	StartDate     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	EndDate       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	CompleteDate  *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=complete_date,json=completeDate,proto3" json:"complete_date,omitempty"`
+	OriginBoardId int64                  `protobuf:"varint,7,opt,name=origin_board_id,json=originBoardId,proto3" json:"origin_board_id,omitempty"`
+	Goal          string                 `protobuf:"bytes,8,opt,name=goal,proto3" json:"goal,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Sprint) Reset() {
+	*x = Sprint{}
+	mi := &file_jira_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sprint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sprint) ProtoMessage() {}
+
+func (x *Sprint) ProtoReflect() protoreflect.Message {
+	mi := &file_jira_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sprint.ProtoReflect.Descriptor instead.
+func (*Sprint) Descriptor() ([]byte, []int) {
+	return file_jira_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Sprint) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Sprint) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Sprint) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Sprint) GetStartDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartDate
+	}
+	return nil
+}
+
+func (x *Sprint) GetEndDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndDate
+	}
+	return nil
+}
+
+func (x *Sprint) GetCompleteDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompleteDate
+	}
+	return nil
+}
+
+func (x *Sprint) GetOriginBoardId() int64 {
+	if x != nil {
+		return x.OriginBoardId
+	}
+	return 0
+}
+
+func (x *Sprint) GetGoal() string {
+	if x != nil {
+		return x.Goal
+	}
+	return ""
+}
+
+// SkippedIssue records a referenced issue that was not fetched because it
+// fell outside the traversal limits or could not be fetched, leaving a
+// dangling reference
+type SkippedIssue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	ReferencedBy  string                 `protobuf:"bytes,2,opt,name=referenced_by,json=referencedBy,proto3" json:"referenced_by,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	Failed        bool                   `protobuf:"varint,4,opt,name=failed,proto3" json:"failed,omitempty"` // Fetching failed, e.g. not found or no permission
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SkippedIssue) Reset() {
+	*x = SkippedIssue{}
+	mi := &file_jira_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SkippedIssue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SkippedIssue) ProtoMessage() {}
+
+func (x *SkippedIssue) ProtoReflect() protoreflect.Message {
+	mi := &file_jira_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SkippedIssue.ProtoReflect.Descriptor instead.
+func (*SkippedIssue) Descriptor() ([]byte, []int) {
+	return file_jira_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SkippedIssue) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SkippedIssue) GetReferencedBy() string {
+	if x != nil {
+		return x.ReferencedBy
+	}
+	return ""
+}
+
+func (x *SkippedIssue) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *SkippedIssue) GetFailed() bool {
+	if x != nil {
+		return x.Failed
+	}
+	return false
+}
+
 var File_jira_proto protoreflect.FileDescriptor
 
 const file_jira_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"jira.proto\x12\x04jira\x1a\x1fgoogle/protobuf/timestamp.proto\"-\n" +
+	"jira.proto\x12\x04jira\x1a\x1fgoogle/protobuf/timestamp.proto\"[\n" +
 	"\x06Export\x12#\n" +
-	"\x06issues\x18\x01 \x03(\v2\v.jira.IssueR\x06issues\"c\n" +
+	"\x06issues\x18\x01 \x03(\v2\v.jira.IssueR\x06issues\x12,\n" +
+	"\askipped\x18\x02 \x03(\v2\x12.jira.SkippedIssueR\askipped\"c\n" +
 	"\x05Issue\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
 	"\x03key\x18\x02 \x01(\tR\x03key\x12\x12\n" +
 	"\x04self\x18\x03 \x01(\tR\x04self\x12$\n" +
-	"\x06fields\x18\x04 \x01(\v2\f.jira.FieldsR\x06fields\"\xbd\x04\n" +
+	"\x06fields\x18\x04 \x01(\v2\f.jira.FieldsR\x06fields\"\x9f\x06\n" +
 	"\x06Fields\x12\x18\n" +
 	"\asummary\x18\x01 \x01(\tR\asummary\x12 \n" +
 	"\vdescription\x18\x02 \x01(\tR\vdescription\x12.\n" +
@@ -1082,11 +1481,17 @@ const file_jira_proto_rawDesc = "" +
 	"\x06parent\x18\f \x01(\v2\f.jira.ParentR\x06parent\x12\x1e\n" +
 	"\x04epic\x18\r \x01(\v2\n" +
 	".jira.EpicR\x04epic\x12)\n" +
-	"\bsubtasks\x18\x0e \x03(\v2\r.jira.SubtaskR\bsubtasks\"[\n" +
+	"\bsubtasks\x18\x0e \x03(\v2\r.jira.SubtaskR\bsubtasks\x127\n" +
+	"\rtime_tracking\x18\x0f \x01(\v2\x12.jira.TimeTrackingR\ftimeTracking\x12)\n" +
+	"\bworklogs\x18\x10 \x03(\v2\r.jira.WorklogR\bworklogs\x12$\n" +
+	"\x06sprint\x18\x11 \x01(\v2\f.jira.SprintR\x06sprint\x123\n" +
+	"\x0eclosed_sprints\x18\x12 \x03(\v2\f.jira.SprintR\rclosedSprints\x12!\n" +
+	"\fstory_points\x18\x13 \x01(\x01R\vstoryPoints\"\x84\x01\n" +
 	"\tIssueType\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
 	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x18\n" +
-	"\asubtask\x18\x03 \x01(\bR\asubtask\"[\n" +
+	"\asubtask\x18\x03 \x01(\bR\asubtask\x12'\n" +
+	"\x0fhierarchy_level\x18\x04 \x01(\x05R\x0ehierarchyLevel\"[\n" +
 	"\x06Status\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12=\n" +
 	"\x0fstatus_category\x18\x02 \x01(\v2\x14.jira.StatusCategoryR\x0estatusCategory\"6\n" +
@@ -1136,7 +1541,39 @@ const file_jira_proto_rawDesc = "" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
 	"\x03key\x18\x02 \x01(\tR\x03key\x12\x12\n" +
 	"\x04self\x18\x03 \x01(\tR\x04self\x12*\n" +
-	"\x06fields\x18\x04 \x01(\v2\x12.jira.LinkedFieldsR\x06fieldsB.Z,github.com/conallob/jira-beads-sync/gen/jirab\x06proto3"
+	"\x06fields\x18\x04 \x01(\v2\x12.jira.LinkedFieldsR\x06fields\"\xb1\x02\n" +
+	"\fTimeTracking\x12+\n" +
+	"\x11original_estimate\x18\x01 \x01(\tR\x10originalEstimate\x12-\n" +
+	"\x12remaining_estimate\x18\x02 \x01(\tR\x11remainingEstimate\x12\x1d\n" +
+	"\n" +
+	"time_spent\x18\x03 \x01(\tR\ttimeSpent\x12:\n" +
+	"\x19original_estimate_seconds\x18\x04 \x01(\x03R\x17originalEstimateSeconds\x12<\n" +
+	"\x1aremaining_estimate_seconds\x18\x05 \x01(\x03R\x18remainingEstimateSeconds\x12,\n" +
+	"\x12time_spent_seconds\x18\x06 \x01(\x03R\x10timeSpentSeconds\"\xda\x01\n" +
+	"\aWorklog\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\"\n" +
+	"\x06author\x18\x02 \x01(\v2\n" +
+	".jira.UserR\x06author\x12\x18\n" +
+	"\acomment\x18\x03 \x01(\tR\acomment\x124\n" +
+	"\astarted\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\astarted\x12\x1d\n" +
+	"\n" +
+	"time_spent\x18\x05 \x01(\tR\ttimeSpent\x12,\n" +
+	"\x12time_spent_seconds\x18\x06 \x01(\x03R\x10timeSpentSeconds\"\xb1\x02\n" +
+	"\x06Sprint\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\x129\n" +
+	"\n" +
+	"start_date\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tstartDate\x125\n" +
+	"\bend_date\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\aendDate\x12?\n" +
+	"\rcomplete_date\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\fcompleteDate\x12&\n" +
+	"\x0forigin_board_id\x18\a \x01(\x03R\roriginBoardId\x12\x12\n" +
+	"\x04goal\x18\b \x01(\tR\x04goal\"u\n" +
+	"\fSkippedIssue\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12#\n" +
+	"\rreferenced_by\x18\x02 \x01(\tR\freferencedBy\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x16\n" +
+	"\x06failed\x18\x04 \x01(\bR\x06failedB.Z,github.com/conallob/jira-beads-sync/gen/jirab\x06proto3"
 
 var (
 	file_jira_proto_rawDescOnce sync.Once
@@ -1150,7 +1587,7 @@ func file_jira_proto_rawDescGZIP() []byte {
 	return file_jira_proto_rawDescData
 }
 
-var file_jira_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_jira_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
 var file_jira_proto_goTypes = []any{
 	(*Export)(nil),                // 0: jira.Export
 	(*Issue)(nil),                 // 1: jira.Issue
@@ -1167,36 +1604,50 @@ var file_jira_proto_goTypes = []any{
 	(*Parent)(nil),                // 12: jira.Parent
 	(*Epic)(nil),                  // 13: jira.Epic
 	(*Subtask)(nil),               // 14: jira.Subtask
-	(*timestamppb.Timestamp)(nil), // 15: google.protobuf.Timestamp
+	(*TimeTracking)(nil),          // 15: jira.TimeTracking
+	(*Worklog)(nil),               // 16: jira.Worklog
+	(*Sprint)(nil),                // 17: jira.Sprint
+	(*SkippedIssue)(nil),          // 18: jira.SkippedIssue
+	(*timestamppb.Timestamp)(nil), // 19: google.protobuf.Timestamp
 }
 var file_jira_proto_depIdxs = []int32{
 	1,  // 0: jira.Export.issues:type_name -> jira.Issue
-	2,  // 1: jira.Issue.fields:type_name -> jira.Fields
-	3,  // 2: jira.Fields.issue_type:type_name -> jira.IssueType
-	4,  // 3: jira.Fields.status:type_name -> jira.Status
-	6,  // 4: jira.Fields.priority:type_name -> jira.Priority
-	7,  // 5: jira.Fields.assignee:type_name -> jira.User
-	7,  // 6: jira.Fields.reporter:type_name -> jira.User
-	15, // 7: jira.Fields.created:type_name -> google.protobuf.Timestamp
-	15, // 8: jira.Fields.updated:type_name -> google.protobuf.Timestamp
-	8,  // 9: jira.Fields.issue_links:type_name -> jira.IssueLink
-	12, // 10: jira.Fields.parent:type_name -> jira.Parent
-	13, // 11: jira.Fields.epic:type_name -> jira.Epic
-	14, // 12: jira.Fields.subtasks:type_name -> jira.Subtask
-	5,  // 13: jira.Status.status_category:type_name -> jira.StatusCategory
-	9,  // 14: jira.IssueLink.type:type_name -> jira.IssueLinkType
-	10, // 15: jira.IssueLink.inward_issue:type_name -> jira.LinkedIssue
-	10, // 16: jira.IssueLink.outward_issue:type_name -> jira.LinkedIssue
-	11, // 17: jira.LinkedIssue.fields:type_name -> jira.LinkedFields
-	4,  // 18: jira.LinkedFields.status:type_name -> jira.Status
-	3,  // 19: jira.LinkedFields.issue_type:type_name -> jira.IssueType
-	11, // 20: jira.Parent.fields:type_name -> jira.LinkedFields
-	11, // 21: jira.Subtask.fields:type_name -> jira.LinkedFields
-	22, // [22:22] is the sub-list for method output_type
-	22, // [22:22] is the sub-list for method input_type
-	22, // [22:22] is the sub-list for extension type_name
-	22, // [22:22] is the sub-list for extension extendee
-	0,  // [0:22] is the sub-list for field type_name
+	18, // 1: jira.Export.skipped:type_name -> jira.SkippedIssue
+	2,  // 2: jira.Issue.fields:type_name -> jira.Fields
+	3,  // 3: jira.Fields.issue_type:type_name -> jira.IssueType
+	4,  // 4: jira.Fields.status:type_name -> jira.Status
+	6,  // 5: jira.Fields.priority:type_name -> jira.Priority
+	7,  // 6: jira.Fields.assignee:type_name -> jira.User
+	7,  // 7: jira.Fields.reporter:type_name -> jira.User
+	19, // 8: jira.Fields.created:type_name -> google.protobuf.Timestamp
+	19, // 9: jira.Fields.updated:type_name -> google.protobuf.Timestamp
+	8,  // 10: jira.Fields.issue_links:type_name -> jira.IssueLink
+	12, // 11: jira.Fields.parent:type_name -> jira.Parent
+	13, // 12: jira.Fields.epic:type_name -> jira.Epic
+	14, // 13: jira.Fields.subtasks:type_name -> jira.Subtask
+	15, // 14: jira.Fields.time_tracking:type_name -> jira.TimeTracking
+	16, // 15: jira.Fields.worklogs:type_name -> jira.Worklog
+	17, // 16: jira.Fields.sprint:type_name -> jira.Sprint
+	17, // 17: jira.Fields.closed_sprints:type_name -> jira.Sprint
+	5,  // 18: jira.Status.status_category:type_name -> jira.StatusCategory
+	9,  // 19: jira.IssueLink.type:type_name -> jira.IssueLinkType
+	10, // 20: jira.IssueLink.inward_issue:type_name -> jira.LinkedIssue
+	10, // 21: jira.IssueLink.outward_issue:type_name -> jira.LinkedIssue
+	11, // 22: jira.LinkedIssue.fields:type_name -> jira.LinkedFields
+	4,  // 23: jira.LinkedFields.status:type_name -> jira.Status
+	3,  // 24: jira.LinkedFields.issue_type:type_name -> jira.IssueType
+	11, // 25: jira.Parent.fields:type_name -> jira.LinkedFields
+	11, // 26: jira.Subtask.fields:type_name -> jira.LinkedFields
+	7,  // 27: jira.Worklog.author:type_name -> jira.User
+	19, // 28: jira.Worklog.started:type_name -> google.protobuf.Timestamp
+	19, // 29: jira.Sprint.start_date:type_name -> google.protobuf.Timestamp
+	19, // 30: jira.Sprint.end_date:type_name -> google.protobuf.Timestamp
+	19, // 31: jira.Sprint.complete_date:type_name -> google.protobuf.Timestamp
+	32, // [32:32] is the sub-list for method output_type
+	32, // [32:32] is the sub-list for method input_type
+	32, // [32:32] is the sub-list for extension type_name
+	32, // [32:32] is the sub-list for extension extendee
+	0,  // [0:32] is the sub-list for field type_name
 }
 
 func init() { file_jira_proto_init() }
@@ -1210,7 +1661,7 @@ func file_jira_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_jira_proto_rawDesc), len(file_jira_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   15,
+			NumMessages:   19,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
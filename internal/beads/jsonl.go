@@ -9,21 +9,37 @@ import (
 	"strings"
 
 	pb "github.com/conallob/jira-beads-sync/gen/beads"
+	"github.com/conallob/jira-beads-sync/internal/progress"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// ParentKey is the metadata key recording a subtask's parent Jira key, so
+// subtasks whose parent is missing can be told apart from other dangling
+// dependencies
+const ParentKey = "jiraParentKey"
+
+// PointsKey is the custom metadata key holding an issue's story points
+const PointsKey = "jiraStoryPoints"
+
 // JSONLRenderer handles rendering protobuf beads to JSONL files
 type JSONLRenderer struct {
 	outputDir string
+	reporter  progress.Reporter
 }
 
 // NewJSONLRenderer creates a new JSONL renderer
 func NewJSONLRenderer(outputDir string) *JSONLRenderer {
 	return &JSONLRenderer{
 		outputDir: outputDir,
+		reporter:  progress.Nop{},
 	}
 }
 
+// SetReporter sets where the renderer logs the files it writes
+func (r *JSONLRenderer) SetReporter(reporter progress.Reporter) {
+	r.reporter = progress.OrNop(reporter)
+}
+
 // RenderExport renders a beads export to JSONL files
 func (r *JSONLRenderer) RenderExport(export *pb.Export) error {
 	if err := r.ensureDirectory(); err != nil {
@@ -36,9 +52,10 @@ func (r *JSONLRenderer) RenderExport(export *pb.Export) error {
 		return fmt.Errorf("failed to render issues: %w", err)
 	}
 
-	// Render all epics to a single JSONL file
-	if len(export.Epics) > 0 {
-		epicsFile := filepath.Join(r.outputDir, ".beads", "epics.jsonl")
+	// Render all epics to a single JSONL file, emptying an existing one if
+	// none are left
+	epicsFile := filepath.Join(r.outputDir, ".beads", "epics.jsonl")
+	if _, err := os.Stat(epicsFile); len(export.Epics) > 0 || err == nil {
 		if err := r.renderEpicsToJSONL(epicsFile, export.Epics); err != nil {
 			return fmt.Errorf("failed to render epics: %w", err)
 		}
@@ -73,6 +90,7 @@ func (r *JSONLRenderer) renderIssuesToJSONL(filename string, issues []*pb.Issue)
 		}
 	}
 
+	r.reporter.Debug("Wrote issues", "count", len(issues), "file", filename)
 	return nil
 }
 
@@ -96,6 +114,7 @@ func (r *JSONLRenderer) renderEpicsToJSONL(filename string, epics []*pb.Epic) (e
 		}
 	}
 
+	r.reporter.Debug("Wrote epics", "count", len(epics), "file", filename)
 	return nil
 }
 
@@ -113,6 +132,8 @@ type BeadsIssue struct {
 	Created     string            `json:"created,omitempty"`
 	Updated     string            `json:"updated,omitempty"`
 	Metadata    map[string]string `json:"metadata,omitempty"`
+
+	EstimatedMinutes int32 `json:"estimatedMinutes,omitempty"`
 }
 
 // BeadsEpic represents a beads epic in JSON format
@@ -121,6 +142,7 @@ type BeadsEpic struct {
 	Name        string            `json:"name"`
 	Description string            `json:"description,omitempty"`
 	Status      string            `json:"status"`
+	Parent      string            `json:"parent,omitempty"`
 	Created     string            `json:"created,omitempty"`
 	Updated     string            `json:"updated,omitempty"`
 	Metadata    map[string]string `json:"metadata,omitempty"`
@@ -138,6 +160,8 @@ func (r *JSONLRenderer) issueToJSON(issue *pb.Issue) *BeadsIssue {
 		Assignee:    issue.Assignee,
 		Labels:      issue.Labels,
 		DependsOn:   issue.DependsOn,
+
+		EstimatedMinutes: issue.EstimatedMinutes,
 	}
 
 	if issue.Created != nil {
@@ -173,6 +197,7 @@ func (r *JSONLRenderer) epicToJSON(epic *pb.Epic) *BeadsEpic {
 		Name:        epic.Name,
 		Description: epic.Description,
 		Status:      r.statusToString(epic.Status),
+		Parent:      epic.Parent,
 	}
 
 	if epic.Created != nil {
@@ -193,6 +218,9 @@ func (r *JSONLRenderer) epicToJSON(epic *pb.Epic) *BeadsEpic {
 		if epic.Metadata.JiraIssueType != "" {
 			jsonEpic.Metadata["jiraIssueType"] = epic.Metadata.JiraIssueType
 		}
+		for k, v := range epic.Metadata.Custom {
+			jsonEpic.Metadata[k] = v
+		}
 	}
 
 	return jsonEpic
@@ -209,6 +237,8 @@ func (r *JSONLRenderer) statusToString(status pb.Status) string {
 		return "blocked"
 	case pb.Status_STATUS_CLOSED:
 		return "closed"
+	case pb.Status_STATUS_TOMBSTONE:
+		return "tombstone"
 	default:
 		return "open"
 	}
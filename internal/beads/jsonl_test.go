@@ -278,3 +278,20 @@ func TestJSONLFormat(t *testing.T) {
 		}
 	}
 }
+
+func TestEpicToJSONParent(t *testing.T) {
+	renderer := NewJSONLRenderer("/tmp/test")
+
+	nested := renderer.epicToJSON(&pb.Epic{Id: "proj-2", Name: "Epic", Parent: "proj-1"})
+	if nested.Parent != "proj-1" {
+		t.Errorf("Expected parent proj-1, got %q", nested.Parent)
+	}
+
+	data, err := json.Marshal(renderer.epicToJSON(&pb.Epic{Id: "proj-1", Name: "Initiative"}))
+	if err != nil {
+		t.Fatalf("Failed to marshal epic: %v", err)
+	}
+	if strings.Contains(string(data), `"parent"`) {
+		t.Errorf("Expected top-level epic to omit parent, got %s", data)
+	}
+}
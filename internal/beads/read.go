@@ -0,0 +1,364 @@
+package beads
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	pb "github.com/conallob/jira-beads-sync/gen/beads"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// localMetadataKeys are metadata added locally rather than converted from
+// Jira, such as repository annotations, and survive a Merge
+var localMetadataKeys = []string{"repositories"}
+
+// ReadExport reads the issues and epics previously rendered to the output
+// directory. Missing files yield an empty export.
+func (r *JSONLRenderer) ReadExport() (*pb.Export, error) {
+	export := &pb.Export{}
+
+	issues, err := readJSONL[BeadsIssue](filepath.Join(r.outputDir, ".beads", "issues.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read issues: %w", err)
+	}
+	for _, issue := range issues {
+		converted, err := issueFromJSON(issue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read issue %s: %w", issue.ID, err)
+		}
+		export.Issues = append(export.Issues, converted)
+	}
+
+	epics, err := readJSONL[BeadsEpic](filepath.Join(r.outputDir, ".beads", "epics.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read epics: %w", err)
+	}
+	for _, epic := range epics {
+		converted, err := epicFromJSON(epic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read epic %s: %w", epic.ID, err)
+		}
+		export.Epics = append(export.Epics, converted)
+	}
+
+	return export, nil
+}
+
+// readJSONL decodes one value per non-empty line of a file. A missing file
+// yields no values.
+func readJSONL[T any](filename string) (values []*T, err error) {
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var value T
+		if err := json.Unmarshal(scanner.Bytes(), &value); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(filename), err)
+		}
+		values = append(values, &value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// issueFromJSON converts a JSONL issue back to protobuf
+func issueFromJSON(jsonIssue *BeadsIssue) (*pb.Issue, error) {
+	status, err := ParseStatus(jsonIssue.Status)
+	if err != nil {
+		return nil, err
+	}
+	priority, err := ParsePriority(jsonIssue.Priority)
+	if err != nil {
+		return nil, err
+	}
+	created, err := parseTimestamp(jsonIssue.Created)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := parseTimestamp(jsonIssue.Updated)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.Issue{
+		Id:               jsonIssue.ID,
+		Title:            jsonIssue.Title,
+		Description:      jsonIssue.Description,
+		Status:           status,
+		Priority:         priority,
+		Epic:             jsonIssue.Epic,
+		Assignee:         jsonIssue.Assignee,
+		Labels:           jsonIssue.Labels,
+		DependsOn:        jsonIssue.DependsOn,
+		Created:          created,
+		Updated:          updated,
+		Metadata:         metadataFromJSON(jsonIssue.Metadata),
+		EstimatedMinutes: jsonIssue.EstimatedMinutes,
+	}, nil
+}
+
+// epicFromJSON converts a JSONL epic back to protobuf
+func epicFromJSON(jsonEpic *BeadsEpic) (*pb.Epic, error) {
+	status, err := ParseStatus(jsonEpic.Status)
+	if err != nil {
+		return nil, err
+	}
+	created, err := parseTimestamp(jsonEpic.Created)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := parseTimestamp(jsonEpic.Updated)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.Epic{
+		Id:          jsonEpic.ID,
+		Name:        jsonEpic.Name,
+		Description: jsonEpic.Description,
+		Status:      status,
+		Parent:      jsonEpic.Parent,
+		Created:     created,
+		Updated:     updated,
+		Metadata:    metadataFromJSON(jsonEpic.Metadata),
+	}, nil
+}
+
+// metadataFromJSON splits the flattened JSONL metadata back into the Jira
+// fields and custom entries
+func metadataFromJSON(metadata map[string]string) *pb.Metadata {
+	if metadata == nil {
+		return nil
+	}
+
+	result := &pb.Metadata{}
+	for key, value := range metadata {
+		switch key {
+		case "jiraKey":
+			result.JiraKey = value
+		case "jiraId":
+			result.JiraId = value
+		case "jiraIssueType":
+			result.JiraIssueType = value
+		default:
+			if result.Custom == nil {
+				result.Custom = make(map[string]string)
+			}
+			result.Custom[key] = value
+		}
+	}
+	return result
+}
+
+// parseTimestamp parses an RFC3339 timestamp; empty yields nil
+func parseTimestamp(s string) (*timestamppb.Timestamp, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q: %w", s, err)
+	}
+	return timestamppb.New(t), nil
+}
+
+// ParseStatus parses a JSONL status such as "in_progress"; empty means open
+func ParseStatus(s string) (pb.Status, error) {
+	switch strings.ToLower(s) {
+	case "", "open":
+		return pb.Status_STATUS_OPEN, nil
+	case "in_progress":
+		return pb.Status_STATUS_IN_PROGRESS, nil
+	case "blocked":
+		return pb.Status_STATUS_BLOCKED, nil
+	case "closed":
+		return pb.Status_STATUS_CLOSED, nil
+	case "tombstone":
+		return pb.Status_STATUS_TOMBSTONE, nil
+	default:
+		return pb.Status_STATUS_UNSPECIFIED, fmt.Errorf("invalid status %q (use open, in_progress, blocked, closed or tombstone)", s)
+	}
+}
+
+// ParsePriority parses a JSONL priority from "p0" to "p4"; empty means
+// unspecified
+func ParsePriority(s string) (pb.Priority, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return pb.Priority_PRIORITY_UNSPECIFIED, nil
+	case "p0":
+		return pb.Priority_PRIORITY_P0, nil
+	case "p1":
+		return pb.Priority_PRIORITY_P1, nil
+	case "p2":
+		return pb.Priority_PRIORITY_P2, nil
+	case "p3":
+		return pb.Priority_PRIORITY_P3, nil
+	case "p4":
+		return pb.Priority_PRIORITY_P4, nil
+	default:
+		return pb.Priority_PRIORITY_UNSPECIFIED, fmt.Errorf("invalid priority %q (use p0 to p4)", s)
+	}
+}
+
+// Merge combines a freshly converted export into an existing one. Issues and
+// epics in update replace those with the same ID in base, keeping local
+// annotations such as repositories; everything else in base is kept, so
+// several fetches can share one .beads directory.
+func Merge(base, update *pb.Export) *pb.Export {
+	merged := &pb.Export{}
+
+	updatedIssues := make(map[string]*pb.Issue)
+	for _, issue := range update.GetIssues() {
+		updatedIssues[issue.Id] = issue
+	}
+	for _, issue := range base.GetIssues() {
+		if fresh, ok := updatedIssues[issue.Id]; ok {
+			fresh.Metadata = mergeMetadata(issue.Metadata, fresh.Metadata)
+			merged.Issues = append(merged.Issues, fresh)
+			delete(updatedIssues, issue.Id)
+			continue
+		}
+		merged.Issues = append(merged.Issues, issue)
+	}
+	for _, issue := range update.GetIssues() {
+		if _, ok := updatedIssues[issue.Id]; ok {
+			merged.Issues = append(merged.Issues, issue)
+		}
+	}
+
+	updatedEpics := make(map[string]*pb.Epic)
+	for _, epic := range update.GetEpics() {
+		updatedEpics[epic.Id] = epic
+	}
+	for _, epic := range base.GetEpics() {
+		if fresh, ok := updatedEpics[epic.Id]; ok {
+			fresh.Metadata = mergeMetadata(epic.Metadata, fresh.Metadata)
+			merged.Epics = append(merged.Epics, fresh)
+			delete(updatedEpics, epic.Id)
+			continue
+		}
+		merged.Epics = append(merged.Epics, epic)
+	}
+	for _, epic := range update.GetEpics() {
+		if _, ok := updatedEpics[epic.Id]; ok {
+			merged.Epics = append(merged.Epics, epic)
+		}
+	}
+
+	return merged
+}
+
+// mergeMetadata carries local annotations from existing metadata over to
+// freshly converted metadata
+func mergeMetadata(existing, fresh *pb.Metadata) *pb.Metadata {
+	if existing == nil {
+		return fresh
+	}
+	if fresh == nil {
+		fresh = &pb.Metadata{}
+	}
+	if len(fresh.Repositories) == 0 {
+		fresh.Repositories = existing.Repositories
+	}
+	for _, key := range localMetadataKeys {
+		value, ok := existing.Custom[key]
+		if !ok {
+			continue
+		}
+		if _, exists := fresh.Custom[key]; exists {
+			continue
+		}
+		if fresh.Custom == nil {
+			fresh.Custom = make(map[string]string)
+		}
+		fresh.Custom[key] = value
+	}
+	return fresh
+}
+
+// IssueToJSON returns an issue as written to issues.jsonl
+func IssueToJSON(issue *pb.Issue) *BeadsIssue {
+	return (&JSONLRenderer{}).issueToJSON(issue)
+}
+
+// EpicToJSON returns an epic as written to epics.jsonl
+func EpicToJSON(epic *pb.Epic) *BeadsEpic {
+	return (&JSONLRenderer{}).epicToJSON(epic)
+}
+
+// IssueHash returns a hash of an issue as written to issues.jsonl, so local
+// edits can be detected
+func IssueHash(issue *pb.Issue) string {
+	return hashJSON(IssueToJSON(issue))
+}
+
+// EpicHash returns a hash of an epic as written to epics.jsonl
+func EpicHash(epic *pb.Epic) string {
+	return hashJSON(EpicToJSON(epic))
+}
+
+// IssueJiraHash returns a hash of an issue without the metadata added
+// locally, so changes made in Jira can be told apart from local annotations
+func IssueJiraHash(issue *pb.Issue) string {
+	jsonIssue := IssueToJSON(issue)
+	jsonIssue.Metadata = withoutLocalMetadata(jsonIssue.Metadata)
+	return hashJSON(jsonIssue)
+}
+
+// EpicJiraHash returns a hash of an epic without the metadata added locally
+func EpicJiraHash(epic *pb.Epic) string {
+	jsonEpic := EpicToJSON(epic)
+	jsonEpic.Metadata = withoutLocalMetadata(jsonEpic.Metadata)
+	return hashJSON(jsonEpic)
+}
+
+// withoutLocalMetadata returns a copy of metadata without localMetadataKeys
+func withoutLocalMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	stripped := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		stripped[key] = value
+	}
+	for _, key := range localMetadataKeys {
+		delete(stripped, key)
+	}
+	return stripped
+}
+
+// hashJSON hashes the JSON encoding of v, in which map keys are sorted
+func hashJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,132 @@
+package beads
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/conallob/jira-beads-sync/gen/beads"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestReadExportRoundTrip(t *testing.T) {
+	renderer := NewJSONLRenderer(t.TempDir())
+	created := timestamppb.New(time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC))
+
+	export := &pb.Export{
+		Issues: []*pb.Issue{
+			{
+				Id:        "proj-1",
+				Title:     "Story",
+				Status:    pb.Status_STATUS_IN_PROGRESS,
+				Priority:  pb.Priority_PRIORITY_P1,
+				Epic:      "proj-10",
+				Labels:    []string{"backend"},
+				DependsOn: []string{"proj-2"},
+				Created:   created,
+				Metadata: &pb.Metadata{
+					JiraKey:       "PROJ-1",
+					JiraIssueType: "Story",
+					Custom:        map[string]string{"sprintName": "Sprint 1"},
+				},
+				EstimatedMinutes: 90,
+			},
+			{Id: "proj-2", Title: "Task", Status: pb.Status_STATUS_CLOSED},
+		},
+		Epics: []*pb.Epic{
+			{
+				Id:       "proj-10",
+				Name:     "Epic",
+				Status:   pb.Status_STATUS_OPEN,
+				Parent:   "proj-100",
+				Updated:  created,
+				Metadata: &pb.Metadata{JiraKey: "PROJ-10", Custom: map[string]string{"jiraRemoved": "deleted"}},
+			},
+		},
+	}
+	if err := renderer.RenderExport(export); err != nil {
+		t.Fatalf("RenderExport failed: %v", err)
+	}
+
+	got, err := renderer.ReadExport()
+	if err != nil {
+		t.Fatalf("ReadExport failed: %v", err)
+	}
+	if !proto.Equal(got, export) {
+		t.Errorf("Round trip mismatch:\ngot  %v\nwant %v", got, export)
+	}
+}
+
+func TestReadExportMissing(t *testing.T) {
+	export, err := NewJSONLRenderer(t.TempDir()).ReadExport()
+	if err != nil {
+		t.Fatalf("ReadExport failed: %v", err)
+	}
+	if len(export.Issues) != 0 || len(export.Epics) != 0 {
+		t.Errorf("Expected an empty export, got %v", export)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := &pb.Export{
+		Issues: []*pb.Issue{
+			{Id: "proj-1", Title: "Old title", Metadata: &pb.Metadata{
+				JiraKey: "PROJ-1",
+				Custom:  map[string]string{"repositories": "api", "sprintName": "Sprint 1"},
+			}},
+			{Id: "other-1", Title: "From another fetch"},
+		},
+		Epics: []*pb.Epic{{Id: "proj-10", Name: "Old epic"}},
+	}
+	update := &pb.Export{
+		Issues: []*pb.Issue{
+			{Id: "proj-1", Title: "New title", Metadata: &pb.Metadata{JiraKey: "PROJ-1"}},
+			{Id: "proj-2", Title: "New issue"},
+		},
+		Epics: []*pb.Epic{{Id: "proj-10", Name: "New epic"}},
+	}
+
+	merged := Merge(base, update)
+
+	var titles []string
+	for _, issue := range merged.Issues {
+		titles = append(titles, issue.Title)
+	}
+	want := []string{"New title", "From another fetch", "New issue"}
+	if len(titles) != len(want) {
+		t.Fatalf("Expected issues %v, got %v", want, titles)
+	}
+	for i := range want {
+		if titles[i] != want[i] {
+			t.Errorf("Expected issues %v, got %v", want, titles)
+			break
+		}
+	}
+
+	custom := merged.Issues[0].Metadata.Custom
+	if custom["repositories"] != "api" {
+		t.Errorf("Expected the repositories annotation to be kept, got %v", custom)
+	}
+	if _, ok := custom["sprintName"]; ok {
+		t.Errorf("Expected metadata converted from Jira to be replaced, got %v", custom)
+	}
+
+	if len(merged.Epics) != 1 || merged.Epics[0].Name != "New epic" {
+		t.Errorf("Expected the epic to be replaced, got %v", merged.Epics)
+	}
+}
+
+func TestParseStatusAndPriority(t *testing.T) {
+	if status, err := ParseStatus("in_progress"); err != nil || status != pb.Status_STATUS_IN_PROGRESS {
+		t.Errorf("ParseStatus(in_progress) = %v, %v", status, err)
+	}
+	if _, err := ParseStatus("done"); err == nil {
+		t.Error("Expected an error for an unknown status")
+	}
+	if priority, err := ParsePriority("P3"); err != nil || priority != pb.Priority_PRIORITY_P3 {
+		t.Errorf("ParsePriority(P3) = %v, %v", priority, err)
+	}
+	if _, err := ParsePriority("urgent"); err == nil {
+		t.Error("Expected an error for an unknown priority")
+	}
+}
@@ -0,0 +1,161 @@
+package beads
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WorklogEntry is time logged locally against a beads issue. Entries are
+// kept in .beads/worklogs.jsonl until they are pushed to Jira.
+type WorklogEntry struct {
+	IssueID          string `json:"issueId"`
+	JiraKey          string `json:"jiraKey"`
+	TimeSpentSeconds int64  `json:"timeSpentSeconds"`
+	Started          string `json:"started"`
+	Comment          string `json:"comment,omitempty"`
+	JiraWorklogID    string `json:"jiraWorklogId,omitempty"` // Set once pushed to Jira
+}
+
+// Pushed reports whether the entry has already been recorded in Jira
+func (e *WorklogEntry) Pushed() bool {
+	return e.JiraWorklogID != ""
+}
+
+// worklogsFile returns the path of the local worklog file
+func (r *JSONLRenderer) worklogsFile() string {
+	return filepath.Join(r.outputDir, ".beads", "worklogs.jsonl")
+}
+
+// LookupJiraKey returns the Jira key recorded in the metadata of a beads issue
+func (r *JSONLRenderer) LookupJiraKey(issueID string) (key string, err error) {
+	issuesFile := filepath.Join(r.outputDir, ".beads", "issues.jsonl")
+
+	file, err := os.Open(issuesFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to open issues file: %w", err)
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var issue BeadsIssue
+		if err := json.Unmarshal(scanner.Bytes(), &issue); err != nil {
+			return "", fmt.Errorf("failed to parse issue: %w", err)
+		}
+		if issue.ID == issueID {
+			if issue.Metadata["jiraKey"] == "" {
+				return "", fmt.Errorf("issue %s has no Jira key", issueID)
+			}
+			return issue.Metadata["jiraKey"], nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("error reading issues file: %w", err)
+	}
+
+	return "", fmt.Errorf("issue %s not found in issues file", issueID)
+}
+
+// AppendWorklog records a local time entry in the worklog file
+func (r *JSONLRenderer) AppendWorklog(entry *WorklogEntry) (err error) {
+	if err := r.ensureDirectory(); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.OpenFile(r.worklogsFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open worklogs file: %w", err)
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	if err := json.NewEncoder(file).Encode(entry); err != nil {
+		return fmt.Errorf("failed to write worklog: %w", err)
+	}
+
+	return nil
+}
+
+// ReadWorklogs returns all local time entries. A missing file yields no entries.
+func (r *JSONLRenderer) ReadWorklogs() (entries []*WorklogEntry, err error) {
+	file, err := os.Open(r.worklogsFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open worklogs file: %w", err)
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry WorklogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse worklog: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading worklogs file: %w", err)
+	}
+
+	return entries, nil
+}
+
+// WriteWorklogs replaces the worklog file with the given entries. The file
+// is written beside it and renamed into place, so an interrupted write
+// leaves the previous file intact.
+func (r *JSONLRenderer) WriteWorklogs(entries []*WorklogEntry) (err error) {
+	if err := r.ensureDirectory(); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(r.worklogsFile()), "worklogs-*.jsonl")
+	if err != nil {
+		return fmt.Errorf("failed to create worklogs file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	encoder := json.NewEncoder(file)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("failed to write worklog: %w", err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write worklogs file: %w", err)
+	}
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write worklogs file: %w", err)
+	}
+	if err := os.Rename(file.Name(), r.worklogsFile()); err != nil {
+		return fmt.Errorf("failed to replace worklogs file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package beads
+
+import (
+	"testing"
+
+	pb "github.com/conallob/jira-beads-sync/gen/beads"
+)
+
+func TestLookupJiraKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	renderer := NewJSONLRenderer(tmpDir)
+
+	export := &pb.Export{
+		Issues: []*pb.Issue{
+			{
+				Id:       "proj-1",
+				Title:    "Imported",
+				Metadata: &pb.Metadata{JiraKey: "PROJ-1"},
+			},
+			{
+				Id:    "local-1",
+				Title: "Local only",
+			},
+		},
+	}
+	if err := renderer.RenderExport(export); err != nil {
+		t.Fatalf("RenderExport failed: %v", err)
+	}
+
+	key, err := renderer.LookupJiraKey("proj-1")
+	if err != nil {
+		t.Fatalf("LookupJiraKey failed: %v", err)
+	}
+	if key != "PROJ-1" {
+		t.Errorf("Expected PROJ-1, got %s", key)
+	}
+
+	if _, err := renderer.LookupJiraKey("local-1"); err == nil {
+		t.Error("Expected error for issue without a Jira key")
+	}
+
+	if _, err := renderer.LookupJiraKey("missing"); err == nil {
+		t.Error("Expected error for unknown issue")
+	}
+}
+
+func TestWorklogRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	renderer := NewJSONLRenderer(tmpDir)
+
+	entries, err := renderer.ReadWorklogs()
+	if err != nil {
+		t.Fatalf("ReadWorklogs on missing file failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("Expected no entries, got %d", len(entries))
+	}
+
+	first := &WorklogEntry{
+		IssueID:          "proj-1",
+		JiraKey:          "PROJ-1",
+		TimeSpentSeconds: 3600,
+		Started:          "2024-01-01T10:00:00Z",
+		Comment:          "Pairing session",
+	}
+	second := &WorklogEntry{
+		IssueID:          "proj-2",
+		JiraKey:          "PROJ-2",
+		TimeSpentSeconds: 1800,
+		Started:          "2024-01-02T10:00:00Z",
+	}
+
+	for _, entry := range []*WorklogEntry{first, second} {
+		if err := renderer.AppendWorklog(entry); err != nil {
+			t.Fatalf("AppendWorklog failed: %v", err)
+		}
+	}
+
+	entries, err = renderer.ReadWorklogs()
+	if err != nil {
+		t.Fatalf("ReadWorklogs failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Comment != "Pairing session" || entries[1].JiraKey != "PROJ-2" {
+		t.Errorf("Entries not read back in order: %+v, %+v", entries[0], entries[1])
+	}
+	if entries[0].Pushed() {
+		t.Error("New entry should not be marked as pushed")
+	}
+
+	entries[0].JiraWorklogID = "10100"
+	if err := renderer.WriteWorklogs(entries); err != nil {
+		t.Fatalf("WriteWorklogs failed: %v", err)
+	}
+
+	entries, err = renderer.ReadWorklogs()
+	if err != nil {
+		t.Fatalf("ReadWorklogs failed: %v", err)
+	}
+	if !entries[0].Pushed() {
+		t.Error("Expected first entry to be marked as pushed")
+	}
+	if entries[1].Pushed() {
+		t.Error("Expected second entry to remain pending")
+	}
+}
@@ -1,16 +1,120 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
 
+	"github.com/conallob/jira-beads-sync/internal/keyring"
 	"gopkg.in/yaml.v3"
 )
 
+// ErrNoProfile is returned when no profile is configured for the host of a
+// Jira URL
+var ErrNoProfile = errors.New("no profile for host")
+
 // Config holds the configuration for jira-beads-sync
 type Config struct {
 	Jira JiraConfig `yaml:"jira"`
+
+	// Profiles holds further named Jira connections, selected with --profile
+	Profiles map[string]JiraConfig `yaml:"profiles,omitempty"`
+	// DefaultProfile names the profile used when none is selected; when
+	// empty the jira section is used
+	DefaultProfile string `yaml:"default_profile,omitempty"`
+
+	// Queries maps names to saved JQL queries for fetch-jql
+	Queries map[string]string `yaml:"queries,omitempty"`
+
+	// Traversal limits how far dependency crawling reaches
+	Traversal TraversalConfig `yaml:"traversal,omitempty"`
+
+	// Hierarchy configures which issue types become beads epics
+	Hierarchy HierarchyConfig `yaml:"hierarchy,omitempty"`
+
+	// Sync configures re-syncs into an existing .beads directory
+	Sync SyncConfig `yaml:"sync,omitempty"`
+
+	// Validation configures the checks run on converted issues
+	Validation ValidationConfig `yaml:"validation,omitempty"`
+
+	// Fields maps Jira custom fields, whose IDs vary by site
+	Fields FieldsConfig `yaml:"fields,omitempty"`
+
+	// Mapping overrides how Jira statuses and priorities become beads ones
+	Mapping MappingConfig `yaml:"mapping,omitempty"`
+
+	// Output sets how command results are printed by default
+	Output OutputConfig `yaml:"output,omitempty"`
+
+	// Profile is the name of the active profile, whose settings are in Jira
+	Profile string `yaml:"-"`
+	// ProjectFile is the project config file merged into this config, if any
+	ProjectFile string `yaml:"-"`
+
+	// topLevel keeps the jira section while a profile is active
+	topLevel JiraConfig
+	// sources records where each setting came from, for Settings
+	sources map[string]string
+}
+
+// TraversalConfig limits dependency crawling. Zero values mean no limit.
+type TraversalConfig struct {
+	MaxDepth  int      `yaml:"max_depth,omitempty"`
+	LinkTypes []string `yaml:"link_types,omitempty"`
+	Direction string   `yaml:"direction,omitempty"` // inward, outward or both
+	Projects  []string `yaml:"projects,omitempty"`
+}
+
+// HierarchyConfig describes multi-level hierarchies such as
+// Initiative → Epic → Story. Unset values keep the defaults.
+type HierarchyConfig struct {
+	EpicTypes       []string `yaml:"epic_types,omitempty"`        // Issue types treated as epics (default: Epic)
+	EpicLevel       *int32   `yaml:"epic_level,omitempty"`        // Lowest Jira Cloud hierarchy level treated as an epic (default: 1, 0 disables)
+	ParentLinkField string   `yaml:"parent_link_field,omitempty"` // Advanced Roadmaps Parent Link field on Data Center
+}
+
+// SyncConfig configures re-syncs into an existing .beads directory
+type SyncConfig struct {
+	// Removed is what happens to issues deleted in Jira or moved to a new
+	// key: close (the default), tombstone or delete
+	Removed string `yaml:"removed,omitempty"`
+}
+
+// ValidationConfig configures the checks for dangling references, cycles,
+// self-dependencies and orphaned subtasks run before writing .beads
+type ValidationConfig struct {
+	// Mode is what happens to problems found: keep (the default), drop or fail
+	Mode string `yaml:"mode,omitempty"`
+}
+
+// FieldsConfig names the custom fields read from Jira
+type FieldsConfig struct {
+	// StoryPoints is the story points field (e.g., customfield_10016),
+	// which analysis can weigh issues by
+	StoryPoints string `yaml:"story_points,omitempty"`
+}
+
+// MappingConfig overrides the built-in status and priority mapping. Names
+// are Jira's, compared case-insensitively; names without an entry keep the
+// built-in mapping.
+type MappingConfig struct {
+	// Statuses maps status names to open, in_progress, blocked or closed
+	Statuses map[string]string `yaml:"statuses,omitempty"`
+	// Priorities maps priority names to p0 through p4
+	Priorities map[string]string `yaml:"priorities,omitempty"`
+}
+
+// OutputConfig sets defaults for command output
+type OutputConfig struct {
+	// Format is text (the default) or json, unless --output is given
+	Format string `yaml:"format,omitempty"`
 }
 
 // JiraConfig holds Jira-specific configuration
@@ -18,16 +122,65 @@ type JiraConfig struct {
 	BaseURL  string `yaml:"base_url"`
 	Username string `yaml:"username"`
 	APIToken string `yaml:"api_token"`
+
+	// AuthType selects how requests are authenticated: basic (username and
+	// API token, the default), bearer (api_token holds a Data Center personal
+	// access token), cookie (api_token holds the password for a session) or
+	// oauth (tokens obtained by 'jira-beads-sync login')
+	AuthType string `yaml:"auth_type,omitempty"`
+
+	OAuth OAuthConfig `yaml:"oauth,omitempty"`
+
+	// UseKeyring keeps the API token in the OS keyring instead of this file
+	UseKeyring bool `yaml:"use_keyring,omitempty"`
+	// CredentialCommand is a shell command whose output is the API token,
+	// e.g. "pass show jira" or "op read op://work/jira/token"
+	CredentialCommand string `yaml:"credential_command,omitempty"`
+}
+
+// OAuthConfig identifies the OAuth 2.0 (3LO) app used with auth_type: oauth
+type OAuthConfig struct {
+	ClientID     string   `yaml:"client_id,omitempty"`
+	ClientSecret string   `yaml:"client_secret,omitempty"`
+	CallbackURL  string   `yaml:"callback_url,omitempty"`
+	Scopes       []string `yaml:"scopes,omitempty"`
 }
 
 // configPathFunc is a variable that can be overridden in tests
 var configPathFunc = getConfigPath
 
-// Load loads configuration from a file or environment variables
+// Keyring access, overridden in tests
+var (
+	keyringGet = keyring.Get
+	keyringSet = keyring.Set
+)
+
+// Load loads configuration from a file or environment variables, using the
+// default profile and the project file for the working directory
 func Load() (*Config, error) {
+	return LoadProfile("", "")
+}
+
+// LoadProfile loads configuration with the named profile active. An empty
+// name selects $JIRA_PROFILE or the default profile. The project file is
+// looked for from dir, or the working directory if dir is empty.
+func LoadProfile(dir, name string) (*Config, error) {
+	return load(dir, name, "")
+}
+
+// LoadForURL loads configuration for working with a Jira URL. Unless a
+// profile is named, the profile whose base URL has the same host is used.
+// It fails with ErrNoProfile when the settings are for another host.
+func LoadForURL(dir, name, rawURL string) (*Config, error) {
+	return load(dir, name, rawURL)
+}
+
+// ReadFile reads the user config file as written, without the project file,
+// selecting a profile or applying environment variables. Use it for configs
+// that will be saved. A missing file gives an empty config.
+func ReadFile() (*Config, error) {
 	config := &Config{}
 
-	// Try to load from config file first
 	configPath := configPathFunc()
 	if _, err := os.Stat(configPath); err == nil {
 		if err := loadFromFile(configPath, config); err != nil {
@@ -35,35 +188,192 @@ func Load() (*Config, error) {
 		}
 	}
 
+	return config, nil
+}
+
+// load reads the user config file, layers the project file found from dir
+// over it, activates a profile and applies overrides
+func load(dir, name, rawURL string) (*Config, error) {
+	config, err := ReadFile()
+	if err != nil {
+		return nil, err
+	}
+	config.setSources(config.settings(), SourceUser)
+
+	project, projectPath, err := ReadProjectFile(dir)
+	if err != nil {
+		return nil, err
+	}
+	if project != nil {
+		config.mergeProject(project)
+		config.ProjectFile = projectPath
+	}
+
+	if name == "" {
+		name = os.Getenv("JIRA_PROFILE")
+	}
+	if name == "" && rawURL != "" {
+		name = config.profileForURL(rawURL)
+	}
+	if err := config.activate(name); err != nil {
+		return nil, err
+	}
+
 	// Override with environment variables if present
-	if baseURL := os.Getenv("JIRA_BASE_URL"); baseURL != "" {
-		config.Jira.BaseURL = baseURL
+	overrides := []struct {
+		env   string
+		key   string
+		field *string
+	}{
+		{"JIRA_BASE_URL", "jira.base_url", &config.Jira.BaseURL},
+		{"JIRA_USERNAME", "jira.username", &config.Jira.Username},
+		{"JIRA_API_TOKEN", "jira.api_token", &config.Jira.APIToken},
+		{"JIRA_AUTH_TYPE", "jira.auth_type", &config.Jira.AuthType},
+		{"JIRA_OAUTH_CLIENT_ID", "jira.oauth.client_id", &config.Jira.OAuth.ClientID},
+		{"JIRA_OAUTH_CLIENT_SECRET", "jira.oauth.client_secret", &config.Jira.OAuth.ClientSecret},
 	}
-	if username := os.Getenv("JIRA_USERNAME"); username != "" {
-		config.Jira.Username = username
+	for _, override := range overrides {
+		if value := os.Getenv(override.env); value != "" {
+			*override.field = value
+			config.setSource(override.key, "$"+override.env)
+		}
 	}
-	if apiToken := os.Getenv("JIRA_API_TOKEN"); apiToken != "" {
-		config.Jira.APIToken = apiToken
+
+	// Never send one instance's credentials to another instance
+	if host := hostOf(rawURL); host != "" && config.Jira.BaseURL != "" && hostOf(config.Jira.BaseURL) != host {
+		return nil, fmt.Errorf("%w %s (the selected settings are for %s)", ErrNoProfile, host, hostOf(config.Jira.BaseURL))
 	}
 
 	return config, nil
 }
 
+// ResolveToken fetches the API token from the credential command or the
+// keyring when it isn't set. Loading leaves this until a Jira client needs
+// the token, so other commands never run the helper or unlock the keyring.
+func (c *Config) ResolveToken() error {
+	if c.Jira.APIToken != "" {
+		return nil
+	}
+
+	token, err := c.Jira.lookupToken()
+	if err != nil {
+		return err
+	}
+	c.Jira.APIToken = token
+
+	if c.Jira.CredentialCommand != "" {
+		c.setSource("jira.api_token", SourceCredentialCommand)
+	} else if c.Jira.UseKeyring {
+		c.setSource("jira.api_token", SourceKeyring)
+	}
+	return nil
+}
+
+// lookupToken returns the API token from the credential command or keyring,
+// or an empty string when neither is configured
+func (j *JiraConfig) lookupToken() (string, error) {
+	switch {
+	case j.CredentialCommand != "":
+		token, err := runCredentialCommand(j.CredentialCommand)
+		if err != nil {
+			return "", fmt.Errorf("failed to run credential command: %w", err)
+		}
+		return token, nil
+	case j.UseKeyring:
+		token, err := keyringGet(j.keyringKey())
+		if err != nil {
+			return "", fmt.Errorf("failed to read API token from keyring: %w", err)
+		}
+		return token, nil
+	default:
+		return "", nil
+	}
+}
+
+// keyringKey returns the host and account the API token is stored under
+func (j *JiraConfig) keyringKey() (string, string) {
+	host := j.BaseURL
+	if u, err := url.Parse(j.BaseURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	account := j.Username
+	if account == "" {
+		account = "api-token"
+	}
+	return host, account
+}
+
+// runCredentialCommand runs a credential helper through the shell and
+// returns its trimmed output. The helper shares the terminal so it can
+// prompt, e.g. for a GPG passphrase.
+func runCredentialCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", fmt.Errorf("%q printed no token", command)
+	}
+
+	return token, nil
+}
+
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
 	if c.Jira.BaseURL == "" {
 		return fmt.Errorf("jira base URL is required")
 	}
-	if c.Jira.Username == "" {
-		return fmt.Errorf("jira username is required")
+	switch strings.ToLower(c.Jira.AuthType) {
+	case "", "basic", "cookie":
+		if c.Jira.Username == "" {
+			return fmt.Errorf("jira username is required")
+		}
+	case "bearer":
+		// Personal access tokens identify the user on their own
+	case "oauth":
+		return c.Jira.OAuth.Validate()
+	default:
+		return fmt.Errorf("unsupported jira auth type %q (use basic, bearer, cookie or oauth)", c.Jira.AuthType)
 	}
-	if c.Jira.APIToken == "" {
+	if c.Jira.APIToken == "" && c.Jira.CredentialCommand == "" && !c.Jira.UseKeyring {
 		return fmt.Errorf("jira API token is required")
 	}
 	return nil
 }
 
-// Save saves the configuration to a file
+// Validate checks that an OAuth app is configured
+func (o *OAuthConfig) Validate() error {
+	if o.ClientID == "" {
+		return fmt.Errorf("jira OAuth client ID is required")
+	}
+	if o.ClientSecret == "" {
+		return fmt.Errorf("jira OAuth client secret is required")
+	}
+	return nil
+}
+
+// ResolveQuery returns the JQL of a saved query with the given name, or the
+// argument itself when no saved query matches
+func (c *Config) ResolveQuery(nameOrJQL string) string {
+	if jql, ok := c.Queries[nameOrJQL]; ok {
+		return jql
+	}
+	return nameOrJQL
+}
+
+// Save saves the configuration to the user config file. Settings merged
+// from a project file are saved too, so edit configs read with ReadFile.
 func (c *Config) Save() error {
 	configPath := configPathFunc()
 
@@ -73,7 +383,28 @@ func (c *Config) Save() error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	data, err := yaml.Marshal(c)
+	// Put the active profile back in its place
+	saved := *c
+	if c.Profile != "" {
+		saved.Profiles = make(map[string]JiraConfig, len(c.Profiles))
+		for name, profile := range c.Profiles {
+			saved.Profiles[name] = profile
+		}
+		saved.Profiles[c.Profile] = c.Jira
+		saved.Jira = c.topLevel
+	}
+
+	if err := saved.Jira.stashToken(); err != nil {
+		return err
+	}
+	for name, profile := range saved.Profiles {
+		if err := profile.stashToken(); err != nil {
+			return err
+		}
+		saved.Profiles[name] = profile
+	}
+
+	data, err := yaml.Marshal(&saved)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -85,6 +416,30 @@ func (c *Config) Save() error {
 	return nil
 }
 
+// stashToken keeps the API token off disk when it comes from elsewhere,
+// storing it in the keyring if that's where it belongs
+func (j *JiraConfig) stashToken() error {
+	switch {
+	case j.CredentialCommand != "":
+		j.APIToken = ""
+	case j.UseKeyring:
+		if j.APIToken != "" {
+			host, account := j.keyringKey()
+			if err := keyringSet(host, account, j.APIToken); err != nil {
+				return err
+			}
+		}
+		j.APIToken = ""
+	}
+	return nil
+}
+
+// Dir returns the directory holding the config file, where other state such
+// as OAuth tokens is kept
+func Dir() string {
+	return filepath.Dir(configPathFunc())
+}
+
 // getConfigPath returns the path to the config file
 func getConfigPath() string {
 	// Try XDG_CONFIG_HOME first
@@ -115,29 +470,37 @@ func loadFromFile(path string, config *Config) error {
 	return nil
 }
 
-// PromptForConfig interactively prompts the user for configuration
-func PromptForConfig() (*Config, error) {
-	fmt.Println("Jira Configuration")
-	fmt.Println("==================")
-	fmt.Println()
+// PromptForConfig interactively prompts the user for configuration, writing
+// the prompts to out
+func PromptForConfig(out io.Writer) (*Config, error) {
+	fmt.Fprintln(out, "Jira Configuration")
+	fmt.Fprintln(out, "==================")
+	fmt.Fprintln(out)
 
 	config := &Config{}
 
-	fmt.Print("Jira Base URL (e.g., https://jira.example.com): ")
+	fmt.Fprint(out, "Jira Base URL (e.g., https://jira.example.com): ")
 	if _, err := fmt.Scanln(&config.Jira.BaseURL); err != nil {
 		return nil, fmt.Errorf("failed to read base URL: %w", err)
 	}
 
-	fmt.Print("Jira Username/Email: ")
+	fmt.Fprint(out, "Jira Username/Email: ")
 	if _, err := fmt.Scanln(&config.Jira.Username); err != nil {
 		return nil, fmt.Errorf("failed to read username: %w", err)
 	}
 
-	fmt.Print("Jira API Token: ")
+	fmt.Fprint(out, "Jira API Token: ")
 	if _, err := fmt.Scanln(&config.Jira.APIToken); err != nil {
 		return nil, fmt.Errorf("failed to read API token: %w", err)
 	}
 
+	if keyring.Available() {
+		fmt.Fprint(out, "Store the API token in the system keyring instead of the config file? [Y/n]: ")
+		var answer string
+		_, _ = fmt.Scanln(&answer) // An empty answer accepts the default
+		config.Jira.UseKeyring = !strings.HasPrefix(strings.ToLower(answer), "n")
+	}
+
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -60,6 +62,76 @@ func TestConfigValidate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "jira API token is required",
 		},
+		{
+			name: "bearer token without username",
+			config: &Config{
+				Jira: JiraConfig{
+					BaseURL:  "https://jira.example.com",
+					APIToken: "pat123",
+					AuthType: "bearer",
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "cookie session without username",
+			config: &Config{
+				Jira: JiraConfig{
+					BaseURL:  "https://jira.example.com",
+					APIToken: "secret",
+					AuthType: "cookie",
+				},
+			},
+			expectError: true,
+			errorMsg:    "jira username is required",
+		},
+		{
+			name: "oauth without API token",
+			config: &Config{
+				Jira: JiraConfig{
+					BaseURL:  "https://acme.atlassian.net",
+					AuthType: "oauth",
+					OAuth:    OAuthConfig{ClientID: "client", ClientSecret: "secret"},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "oauth without client secret",
+			config: &Config{
+				Jira: JiraConfig{
+					BaseURL:  "https://acme.atlassian.net",
+					AuthType: "oauth",
+					OAuth:    OAuthConfig{ClientID: "client"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "jira OAuth client secret is required",
+		},
+		{
+			name: "unsupported auth type",
+			config: &Config{
+				Jira: JiraConfig{
+					BaseURL:  "https://jira.example.com",
+					Username: "user@example.com",
+					APIToken: "token123",
+					AuthType: "kerberos",
+				},
+			},
+			expectError: true,
+			errorMsg:    `unsupported jira auth type "kerberos" (use basic, bearer, cookie or oauth)`,
+		},
+		{
+			name: "token from keyring",
+			config: &Config{
+				Jira: JiraConfig{
+					BaseURL:    "https://jira.example.com",
+					Username:   "user@example.com",
+					UseKeyring: true,
+				},
+			},
+			expectError: false,
+		},
 		{
 			name: "all fields missing",
 			config: &Config{
@@ -497,3 +569,261 @@ func TestLoadFromFileNonExistent(t *testing.T) {
 		t.Error("Expected error for non-existent file, got nil")
 	}
 }
+
+func TestLoadConfigSavedQueries(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yml")
+
+	configContent := `jira:
+  base_url: https://jira.example.com
+  username: user@example.com
+  api_token: token123
+queries:
+  release: project = PROJ AND fixVersion = "2.0"
+  mine: assignee = currentUser() AND resolution = Unresolved
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	originalConfigPathFunc := configPathFunc
+	defer func() { configPathFunc = originalConfigPathFunc }()
+
+	configPathFunc = func() string {
+		return configPath
+	}
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(config.Queries) != 2 {
+		t.Fatalf("Expected 2 saved queries, got %d", len(config.Queries))
+	}
+
+	if got := config.ResolveQuery("release"); got != `project = PROJ AND fixVersion = "2.0"` {
+		t.Errorf("Expected saved release query, got %q", got)
+	}
+
+	adHoc := "labels = urgent"
+	if got := config.ResolveQuery(adHoc); got != adHoc {
+		t.Errorf("Expected unknown names to be used as JQL, got %q", got)
+	}
+}
+
+func TestLoadConfigTraversal(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yml")
+
+	configContent := `jira:
+  base_url: https://jira.example.com
+  username: user@example.com
+  api_token: token123
+traversal:
+  max_depth: 2
+  link_types: [Blocks]
+  direction: inward
+  projects: [PROJ, CORE]
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	originalConfigPathFunc := configPathFunc
+	defer func() { configPathFunc = originalConfigPathFunc }()
+
+	configPathFunc = func() string {
+		return configPath
+	}
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	traversal := config.Traversal
+	if traversal.MaxDepth != 2 {
+		t.Errorf("Expected max depth 2, got %d", traversal.MaxDepth)
+	}
+	if len(traversal.LinkTypes) != 1 || traversal.LinkTypes[0] != "Blocks" {
+		t.Errorf("Expected link types [Blocks], got %v", traversal.LinkTypes)
+	}
+	if traversal.Direction != "inward" {
+		t.Errorf("Expected direction inward, got %q", traversal.Direction)
+	}
+	if len(traversal.Projects) != 2 {
+		t.Errorf("Expected 2 projects, got %v", traversal.Projects)
+	}
+}
+
+func TestLoadConfigHierarchy(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yml")
+
+	configContent := `jira:
+  base_url: https://jira.example.com
+  username: user@example.com
+  api_token: token123
+hierarchy:
+  epic_types: [Initiative, Epic]
+  epic_level: 2
+  parent_link_field: customfield_10500
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	originalConfigPathFunc := configPathFunc
+	defer func() { configPathFunc = originalConfigPathFunc }()
+
+	configPathFunc = func() string {
+		return configPath
+	}
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	hierarchy := config.Hierarchy
+	if len(hierarchy.EpicTypes) != 2 || hierarchy.EpicTypes[0] != "Initiative" {
+		t.Errorf("Expected epic types [Initiative Epic], got %v", hierarchy.EpicTypes)
+	}
+	if hierarchy.EpicLevel == nil || *hierarchy.EpicLevel != 2 {
+		t.Errorf("Expected epic level 2, got %v", hierarchy.EpicLevel)
+	}
+	if hierarchy.ParentLinkField != "customfield_10500" {
+		t.Errorf("Expected parent link field customfield_10500, got %q", hierarchy.ParentLinkField)
+	}
+}
+
+func TestLoadConfigCredentialCommand(t *testing.T) {
+	t.Setenv("JIRA_API_TOKEN", "")
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yml")
+
+	configContent := `jira:
+  base_url: https://jira.example.com
+  username: user@example.com
+  credential_command: echo "  helper-token  "
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	originalConfigPathFunc := configPathFunc
+	defer func() { configPathFunc = originalConfigPathFunc }()
+
+	configPathFunc = func() string {
+		return configPath
+	}
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if config.Jira.APIToken != "" {
+		t.Errorf("Expected loading to leave the token until it's needed, got %q", config.Jira.APIToken)
+	}
+	if err := config.ResolveToken(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if config.Jira.APIToken != "helper-token" {
+		t.Errorf("Expected token from credential command, got %q", config.Jira.APIToken)
+	}
+
+	// Saving keeps the token off disk
+	if err := config.Save(); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read saved config: %v", err)
+	}
+	if !strings.Contains(string(data), `api_token: ""`) {
+		t.Errorf("Expected saved config to omit the token, got:\n%s", data)
+	}
+
+	// A failing helper is an error rather than a missing token
+	config.Jira.CredentialCommand = "exit 1"
+	if err := config.Save(); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	failing, err := Load()
+	if err != nil {
+		t.Fatalf("Expected loading not to run the credential command, got: %v", err)
+	}
+	if err := failing.ResolveToken(); err == nil {
+		t.Error("Expected error when the credential command fails")
+	}
+}
+
+func TestConfigKeyring(t *testing.T) {
+	t.Setenv("JIRA_API_TOKEN", "")
+
+	secrets := make(map[string]string)
+	originalGet, originalSet := keyringGet, keyringSet
+	defer func() { keyringGet, keyringSet = originalGet, originalSet }()
+	keyringSet = func(host, account, secret string) error {
+		secrets[host+"/"+account] = secret
+		return nil
+	}
+	keyringGet = func(host, account string) (string, error) {
+		secret, ok := secrets[host+"/"+account]
+		if !ok {
+			return "", fmt.Errorf("not found")
+		}
+		return secret, nil
+	}
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yml")
+
+	originalConfigPathFunc := configPathFunc
+	defer func() { configPathFunc = originalConfigPathFunc }()
+
+	configPathFunc = func() string {
+		return configPath
+	}
+
+	config := &Config{
+		Jira: JiraConfig{
+			BaseURL:    "https://acme.atlassian.net",
+			Username:   "user@example.com",
+			APIToken:   "keyring-token",
+			UseKeyring: true,
+		},
+	}
+	if err := config.Save(); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	if secrets["acme.atlassian.net/user@example.com"] != "keyring-token" {
+		t.Errorf("Expected token stored in keyring, got %v", secrets)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read saved config: %v", err)
+	}
+	if strings.Contains(string(data), "keyring-token") {
+		t.Errorf("Expected saved config to omit the token, got:\n%s", data)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := loaded.ResolveToken(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if loaded.Jira.APIToken != "keyring-token" {
+		t.Errorf("Expected token from keyring, got %q", loaded.Jira.APIToken)
+	}
+}
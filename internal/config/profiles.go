@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ProfileNames returns the names of the configured profiles, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetProfile replaces the Jira settings of the named profile, creating it if
+// needed. An empty name updates the default profile, or the jira section
+// when there is none.
+func (c *Config) SetProfile(name string, jira JiraConfig) {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		c.Jira = jira
+		return
+	}
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]JiraConfig)
+	}
+	c.Profiles[name] = jira
+}
+
+// activate makes the named profile's settings the active Jira settings. An
+// empty name selects the default profile, if any.
+func (c *Config) activate(name string) error {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		return nil
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		if len(c.Profiles) == 0 {
+			return fmt.Errorf("unknown profile %q: no profiles are configured", name)
+		}
+		return fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(c.ProfileNames(), ", "))
+	}
+
+	c.topLevel = c.Jira
+	c.Jira = profile
+	c.Profile = name
+
+	for _, setting := range jiraSettings(c.topLevel) {
+		delete(c.sources, setting.Key)
+	}
+	c.setSources(jiraSettings(profile), "profile "+name)
+	return nil
+}
+
+// profileForURL returns the profile whose base URL is on the same host as
+// rawURL, preferring the default. An empty result keeps the default.
+func (c *Config) profileForURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	defaultJira := c.Jira
+	if profile, ok := c.Profiles[c.DefaultProfile]; ok {
+		defaultJira = profile
+	}
+	if hostOf(defaultJira.BaseURL) == strings.ToLower(u.Host) {
+		return ""
+	}
+
+	for _, name := range c.ProfileNames() {
+		if hostOf(c.Profiles[name].BaseURL) == strings.ToLower(u.Host) {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// hostOf returns the lower-cased host of a URL, or "" if it has none
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const profilesConfig = `jira:
+  base_url: https://jira.example.com
+  username: top@example.com
+  api_token: top-token
+profiles:
+  work-cloud:
+    base_url: https://acme.atlassian.net
+    username: me@acme.com
+    api_token: cloud-token
+  onprem:
+    base_url: https://jira.internal.example.com
+    api_token: pat-token
+    auth_type: bearer
+`
+
+// useConfigFile points the config path at a temporary file with content
+func useConfigFile(t *testing.T, content string) string {
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	originalConfigPathFunc := configPathFunc
+	t.Cleanup(func() { configPathFunc = originalConfigPathFunc })
+	configPathFunc = func() string { return configPath }
+
+	// Keep any project file around the test run out of the way
+	originalWorkingDirFunc := workingDirFunc
+	t.Cleanup(func() { workingDirFunc = originalWorkingDirFunc })
+	workDir := t.TempDir()
+	workingDirFunc = func() (string, error) { return workDir, nil }
+
+	return configPath
+}
+
+func TestLoadProfile(t *testing.T) {
+	t.Setenv("JIRA_BASE_URL", "")
+	t.Setenv("JIRA_USERNAME", "")
+	t.Setenv("JIRA_API_TOKEN", "")
+	t.Setenv("JIRA_AUTH_TYPE", "")
+	t.Setenv("JIRA_PROFILE", "")
+
+	tests := []struct {
+		name        string
+		content     string
+		profile     string
+		env         string
+		wantProfile string
+		wantBaseURL string
+		wantErr     string
+	}{
+		{
+			name:        "no profile uses jira section",
+			content:     profilesConfig,
+			wantBaseURL: "https://jira.example.com",
+		},
+		{
+			name:        "named profile",
+			content:     profilesConfig,
+			profile:     "onprem",
+			wantProfile: "onprem",
+			wantBaseURL: "https://jira.internal.example.com",
+		},
+		{
+			name:        "profile from environment",
+			content:     profilesConfig,
+			env:         "work-cloud",
+			wantProfile: "work-cloud",
+			wantBaseURL: "https://acme.atlassian.net",
+		},
+		{
+			name:        "flag wins over environment",
+			content:     profilesConfig,
+			profile:     "onprem",
+			env:         "work-cloud",
+			wantProfile: "onprem",
+			wantBaseURL: "https://jira.internal.example.com",
+		},
+		{
+			name:        "default profile",
+			content:     profilesConfig + "default_profile: work-cloud\n",
+			wantProfile: "work-cloud",
+			wantBaseURL: "https://acme.atlassian.net",
+		},
+		{
+			name:    "unknown profile",
+			content: profilesConfig,
+			profile: "missing",
+			wantErr: "available: onprem, work-cloud",
+		},
+		{
+			name:    "unknown default profile",
+			content: profilesConfig + "default_profile: missing\n",
+			wantErr: `unknown profile "missing"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfigFile(t, tt.content)
+			t.Setenv("JIRA_PROFILE", tt.env)
+
+			config, err := LoadProfile("", tt.profile)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if config.Profile != tt.wantProfile {
+				t.Errorf("Expected profile %q, got %q", tt.wantProfile, config.Profile)
+			}
+			if config.Jira.BaseURL != tt.wantBaseURL {
+				t.Errorf("Expected base URL %s, got %s", tt.wantBaseURL, config.Jira.BaseURL)
+			}
+		})
+	}
+}
+
+func TestLoadForURL(t *testing.T) {
+	t.Setenv("JIRA_BASE_URL", "")
+	t.Setenv("JIRA_PROFILE", "")
+
+	tests := []struct {
+		name        string
+		content     string
+		profile     string
+		url         string
+		wantProfile string
+		wantErr     bool
+	}{
+		{
+			name:        "host matches a profile",
+			content:     profilesConfig,
+			url:         "https://acme.atlassian.net",
+			wantProfile: "work-cloud",
+		},
+		{
+			name:        "host matching is case insensitive",
+			content:     profilesConfig,
+			url:         "https://Jira.Internal.Example.com",
+			wantProfile: "onprem",
+		},
+		{
+			name:        "host matches jira section",
+			content:     profilesConfig,
+			url:         "https://jira.example.com",
+			wantProfile: "",
+		},
+		{
+			name:        "default profile preferred when it matches",
+			content:     profilesConfig + "default_profile: work-cloud\n",
+			url:         "https://acme.atlassian.net",
+			wantProfile: "work-cloud",
+		},
+		{
+			name:    "unknown host is refused",
+			content: profilesConfig + "default_profile: onprem\n",
+			url:     "https://other.example.com",
+			wantErr: true,
+		},
+		{
+			name:    "explicit profile for another host is refused",
+			content: profilesConfig,
+			profile: "onprem",
+			url:     "https://acme.atlassian.net",
+			wantErr: true,
+		},
+		{
+			name:        "explicit profile for the host",
+			content:     profilesConfig,
+			profile:     "onprem",
+			url:         "https://jira.internal.example.com",
+			wantProfile: "onprem",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfigFile(t, tt.content)
+
+			config, err := LoadForURL("", tt.profile, tt.url)
+			if tt.wantErr {
+				// Credentials must never be sent to another host
+				if !errors.Is(err, ErrNoProfile) {
+					t.Errorf("Expected ErrNoProfile, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if config.Profile != tt.wantProfile {
+				t.Errorf("Expected profile %q, got %q", tt.wantProfile, config.Profile)
+			}
+		})
+	}
+}
+
+func TestSaveProfile(t *testing.T) {
+	t.Setenv("JIRA_BASE_URL", "")
+	t.Setenv("JIRA_USERNAME", "")
+	t.Setenv("JIRA_API_TOKEN", "")
+	t.Setenv("JIRA_PROFILE", "")
+
+	useConfigFile(t, profilesConfig)
+
+	// Changes to the active profile are saved back to that profile only
+	config, err := LoadProfile("", "work-cloud")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	config.Jira.Username = "someone@acme.com"
+	if err := config.Save(); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	saved, err := ReadFile()
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if saved.Jira.Username != "top@example.com" {
+		t.Errorf("Expected jira section to be unchanged, got %q", saved.Jira.Username)
+	}
+	if got := saved.Profiles["work-cloud"].Username; got != "someone@acme.com" {
+		t.Errorf("Expected profile username to be saved, got %q", got)
+	}
+	if got := saved.Profiles["onprem"].APIToken; got != "pat-token" {
+		t.Errorf("Expected other profiles to be kept, got token %q", got)
+	}
+
+	// SetProfile creates new profiles
+	saved.SetProfile("staging", JiraConfig{BaseURL: "https://staging.example.com"})
+	if err := saved.Save(); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	if names := saved.ProfileNames(); strings.Join(names, ",") != "onprem,staging,work-cloud" {
+		t.Errorf("Unexpected profiles %v", names)
+	}
+
+	config, err = LoadProfile("", "staging")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if config.Jira.BaseURL != "https://staging.example.com" {
+		t.Errorf("Expected staging profile, got %s", config.Jira.BaseURL)
+	}
+}
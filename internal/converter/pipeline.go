@@ -5,6 +5,8 @@ import (
 
 	"github.com/conallob/jira-beads-sync/internal/beads"
 	"github.com/conallob/jira-beads-sync/internal/jira"
+	"github.com/conallob/jira-beads-sync/internal/progress"
+	"github.com/conallob/jira-beads-sync/internal/validate"
 )
 
 // Pipeline orchestrates the full conversion from Jira JSON to beads JSONL
@@ -12,6 +14,9 @@ type Pipeline struct {
 	jiraAdapter   *jira.Adapter
 	converter     *ProtoConverter
 	jsonlRenderer *beads.JSONLRenderer
+	reporter      progress.Reporter
+	validation    validate.Mode
+	problems      []validate.Problem
 }
 
 // NewPipeline creates a new conversion pipeline
@@ -20,9 +25,42 @@ func NewPipeline(outputDir string) *Pipeline {
 		jiraAdapter:   jira.NewAdapter(),
 		converter:     NewProtoConverter(),
 		jsonlRenderer: beads.NewJSONLRenderer(outputDir),
+		reporter:      progress.Nop{},
+		validation:    validate.ModeKeep,
 	}
 }
 
+// SetReporter sets where the pipeline and its renderer log their steps
+func (p *Pipeline) SetReporter(reporter progress.Reporter) {
+	p.reporter = progress.OrNop(reporter)
+	p.jsonlRenderer.SetReporter(reporter)
+}
+
+// SetHierarchy sets which issue types become epics, and where Advanced
+// Roadmaps parents are read from, as when fetching
+func (p *Pipeline) SetHierarchy(hierarchy jira.Hierarchy) {
+	p.jiraAdapter.ParentLinkField = hierarchy.ParentLinkField
+	mapping := p.converter.mapping
+	p.converter = NewProtoConverterWithHierarchy(hierarchy)
+	p.converter.SetMapping(mapping)
+}
+
+// SetMapping sets the status and priority overrides
+func (p *Pipeline) SetMapping(mapping Mapping) {
+	p.converter.SetMapping(mapping)
+}
+
+// SetValidation sets what happens to dangling references, cycles and other
+// problems found in the converted issues
+func (p *Pipeline) SetValidation(mode validate.Mode) {
+	p.validation = mode
+}
+
+// Problems returns the problems found by the last ConvertFile
+func (p *Pipeline) Problems() []validate.Problem {
+	return p.problems
+}
+
 // ConvertFile converts a Jira JSON export file to beads JSONL files
 func (p *Pipeline) ConvertFile(jiraFile string) error {
 	// Step 1: Parse Jira JSON to protobuf
@@ -30,14 +68,22 @@ func (p *Pipeline) ConvertFile(jiraFile string) error {
 	if err != nil {
 		return fmt.Errorf("failed to parse Jira file: %w", err)
 	}
+	p.reporter.Debug("Parsed Jira export", "file", jiraFile, "issues", len(jiraExport.Issues))
 
 	// Step 2: Convert Jira protobuf to beads protobuf
 	beadsExport, err := p.converter.Convert(jiraExport)
 	if err != nil {
 		return fmt.Errorf("failed to convert to beads format: %w", err)
 	}
+	p.reporter.Debug("Converted to beads format", "issues", len(beadsExport.Issues), "epics", len(beadsExport.Epics))
+
+	// Step 3: Check references, dropping or failing on problems as configured
+	p.problems, err = validate.Validate(beadsExport, p.validation)
+	if err != nil {
+		return err
+	}
 
-	// Step 3: Render beads protobuf to JSONL files
+	// Step 4: Render beads protobuf to JSONL files
 	if err := p.jsonlRenderer.RenderExport(beadsExport); err != nil {
 		return fmt.Errorf("failed to render JSONL files: %w", err)
 	}
@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/conallob/jira-beads-sync/internal/jira"
 )
 
 func TestNewPipeline(t *testing.T) {
@@ -110,6 +112,9 @@ func TestPipelineEndToEnd(t *testing.T) {
 	if err != nil {
 		t.Fatalf("ConvertFile failed: %v", err)
 	}
+	if problems := pipeline.Problems(); len(problems) != 0 {
+		t.Errorf("Expected no validation problems, got %v", problems)
+	}
 
 	// Verify the conversion produced correct structure:
 	// - 1 epic (PROJ-1)
@@ -176,3 +181,28 @@ func containsSubstring(s, substr string) bool {
 func splitLines(s string) []string {
 	return strings.Split(strings.TrimSpace(s), "\n")
 }
+
+func TestPipelineSetHierarchy(t *testing.T) {
+	tmpDir := t.TempDir()
+	jiraFile := filepath.Join(tmpDir, "export.json")
+	data := `{"issues": [{"key": "PROJ-1", "id": "1", "fields": {
+		"summary": "Platform", "issuetype": {"name": "Theme"},
+		"status": {"name": "Open", "statusCategory": {"key": "new"}}}}]}`
+	if err := os.WriteFile(jiraFile, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write export: %v", err)
+	}
+
+	pipeline := NewPipeline(tmpDir)
+	pipeline.SetHierarchy(jira.Hierarchy{EpicTypes: []string{"Theme"}})
+	if err := pipeline.ConvertFile(jiraFile); err != nil {
+		t.Fatalf("ConvertFile failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, ".beads", "epics.jsonl"))
+	if err != nil {
+		t.Fatalf("Failed to read epics.jsonl: %v", err)
+	}
+	if !strings.Contains(string(content), "Platform") {
+		t.Errorf("Expected the Theme to become an epic, got %q", content)
+	}
+}
@@ -2,26 +2,88 @@ package converter
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
 	jirapb "github.com/conallob/jira-beads-sync/gen/jira"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+	"github.com/conallob/jira-beads-sync/internal/jira"
 )
 
 // ProtoConverter handles converting Jira protobuf to beads protobuf
 type ProtoConverter struct {
-	issueMap map[string]*jirapb.Issue // Map of Jira keys to issues
-	epicMap  map[string]string        // Map of Jira epic keys to beads epic IDs
+	issueMap  map[string]*jirapb.Issue // Map of Jira keys to issues
+	epicMap   map[string]string        // Map of Jira epic keys to beads epic IDs
+	hierarchy jira.Hierarchy           // Which issue types become beads epics
+	mapping   Mapping                  // Status and priority overrides
+}
+
+// Mapping overrides how Jira statuses and priorities, keyed by name and
+// compared case-insensitively, map to beads. Names without an entry use
+// the built-in mapping.
+type Mapping struct {
+	Statuses   map[string]beadspb.Status
+	Priorities map[string]beadspb.Priority
+}
+
+// ParseMapping parses status overrides ("open", "in_progress", "blocked" or
+// "closed") and priority overrides ("p0" through "p4"), keyed by Jira name
+func ParseMapping(statuses, priorities map[string]string) (Mapping, error) {
+	var mapping Mapping
+
+	if len(statuses) > 0 {
+		mapping.Statuses = make(map[string]beadspb.Status, len(statuses))
+		for name, value := range statuses {
+			status, err := beads.ParseStatus(value)
+			if err != nil {
+				return mapping, fmt.Errorf("invalid mapping for status %q: %w", name, err)
+			}
+			mapping.Statuses[name] = status
+		}
+	}
+
+	if len(priorities) > 0 {
+		mapping.Priorities = make(map[string]beadspb.Priority, len(priorities))
+		for name, value := range priorities {
+			priority, err := beads.ParsePriority(value)
+			if err != nil {
+				return mapping, fmt.Errorf("invalid mapping for priority %q: %w", name, err)
+			}
+			mapping.Priorities[name] = priority
+		}
+	}
+
+	return mapping, nil
 }
 
 // NewProtoConverter creates a new protobuf-based converter
 func NewProtoConverter() *ProtoConverter {
+	return NewProtoConverterWithHierarchy(jira.DefaultHierarchy())
+}
+
+// NewProtoConverterWithHierarchy creates a converter that turns the given
+// hierarchy's epic-level issue types (e.g., Initiative and Epic) into epics
+func NewProtoConverterWithHierarchy(hierarchy jira.Hierarchy) *ProtoConverter {
 	return &ProtoConverter{
-		issueMap: make(map[string]*jirapb.Issue),
-		epicMap:  make(map[string]string),
+		issueMap:  make(map[string]*jirapb.Issue),
+		epicMap:   make(map[string]string),
+		hierarchy: hierarchy,
 	}
 }
 
+// SetMapping sets the status and priority overrides
+func (c *ProtoConverter) SetMapping(mapping Mapping) {
+	c.mapping = mapping
+}
+
+// RegisterEpic records an epic converted earlier, so issues converted
+// without it, such as from a webhook, are still linked to it
+func (c *ProtoConverter) RegisterEpic(jiraKey string) {
+	c.epicMap[jiraKey] = c.generateBeadsID(jiraKey)
+}
+
 // Convert converts a Jira export to beads format
 func (c *ProtoConverter) Convert(jiraExport *jirapb.Export) (*beadspb.Export, error) {
 	if jiraExport == nil {
@@ -36,21 +98,24 @@ func (c *ProtoConverter) Convert(jiraExport *jirapb.Export) (*beadspb.Export, er
 		Epics:  []*beadspb.Epic{},
 	}
 
-	// Convert epics first so we can reference them in issues
+	// Register epics first so issues and nested epics can reference them
 	epics := c.getEpics(jiraExport)
+	for _, jiraIssue := range epics {
+		c.epicMap[jiraIssue.Key] = c.generateBeadsID(jiraIssue.Key)
+	}
+
 	for _, jiraIssue := range epics {
 		beadsEpic, err := c.convertEpic(jiraIssue)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert epic %s: %w", jiraIssue.Key, err)
 		}
 		beadsExport.Epics = append(beadsExport.Epics, beadsEpic)
-		c.epicMap[jiraIssue.Key] = beadsEpic.Id
 	}
 
 	// Convert all issues (stories, tasks, subtasks)
 	for _, jiraIssue := range jiraExport.Issues {
 		// Skip epics as they've already been converted
-		if jiraIssue.Fields.IssueType.Name == "Epic" {
+		if _, isEpic := c.epicMap[jiraIssue.Key]; isEpic {
 			continue
 		}
 
@@ -85,6 +150,13 @@ func (c *ProtoConverter) convertEpic(jiraIssue *jirapb.Issue) (*beadspb.Epic, er
 		},
 	}
 
+	// Nest the epic under a higher-level one, such as an initiative
+	if jiraIssue.Fields.Parent != nil {
+		if parentID, exists := c.epicMap[jiraIssue.Fields.Parent.Key]; exists {
+			epic.Parent = parentID
+		}
+	}
+
 	return epic, nil
 }
 
@@ -107,6 +179,33 @@ func (c *ProtoConverter) convertIssue(jiraIssue *jirapb.Issue) (*beadspb.Issue,
 		},
 	}
 
+	custom := make(map[string]string)
+
+	// Carry over estimates and logged time
+	if tt := jiraIssue.Fields.TimeTracking; tt != nil {
+		issue.EstimatedMinutes = int32(tt.OriginalEstimateSeconds / 60)
+		c.addTimeTrackingMetadata(custom, tt)
+	}
+
+	// Carry over story points, which analysis can weigh issues by
+	if points := jiraIssue.Fields.StoryPoints; points > 0 {
+		custom[beads.PointsKey] = strconv.FormatFloat(points, 'f', -1, 64)
+	}
+
+	// Record the sprint the issue is planned in
+	if sprint := jiraIssue.Fields.Sprint; sprint != nil {
+		c.addSprintMetadata(custom, sprint)
+	}
+
+	// Record a subtask's parent, so validation can tell when it's missing
+	if parent := jiraIssue.Fields.Parent; parent != nil && jiraIssue.Fields.IssueType.Subtask && !c.isEpicParent(parent) {
+		custom[beads.ParentKey] = parent.Key
+	}
+
+	if len(custom) > 0 {
+		issue.Metadata.Custom = custom
+	}
+
 	// Set assignee if present
 	if jiraIssue.Fields.Assignee != nil {
 		issue.Assignee = jiraIssue.Fields.Assignee.EmailAddress
@@ -117,18 +216,20 @@ func (c *ProtoConverter) convertIssue(jiraIssue *jirapb.Issue) (*beadspb.Issue,
 
 	// Link to epic if this issue belongs to one
 	if jiraIssue.Fields.Parent != nil {
-		// Check if parent is an epic
-		if jiraIssue.Fields.Parent.Fields.IssueType.Name == "Epic" {
-			if epicID, exists := c.epicMap[jiraIssue.Fields.Parent.Key]; exists {
-				issue.Epic = epicID
-			}
+		if epicID, exists := c.epicMap[jiraIssue.Fields.Parent.Key]; exists {
+			issue.Epic = epicID
+		}
+	} else if jiraIssue.Fields.Epic != nil {
+		// Older Jira versions link issues to epics via the Epic Link field
+		if epicID, exists := c.epicMap[jiraIssue.Fields.Epic.Key]; exists {
+			issue.Epic = epicID
 		}
 	}
 
 	// Handle dependencies from parent-child relationships
 	if jiraIssue.Fields.Parent != nil && jiraIssue.Fields.IssueType.Subtask {
 		// Subtasks depend on their parent (unless parent is an epic)
-		if jiraIssue.Fields.Parent.Fields.IssueType.Name != "Epic" {
+		if !c.isEpicParent(jiraIssue.Fields.Parent) {
 			parentBeadsID := c.generateBeadsID(jiraIssue.Fields.Parent.Key)
 			issue.DependsOn = append(issue.DependsOn, parentBeadsID)
 		}
@@ -170,8 +271,44 @@ func (c *ProtoConverter) addDependencies(jiraExport *jirapb.Export, beadsExport
 	return nil
 }
 
+// addTimeTrackingMetadata records Jira time tracking values, in seconds, as
+// custom metadata so they survive the round trip through beads
+func (c *ProtoConverter) addTimeTrackingMetadata(custom map[string]string, tt *jirapb.TimeTracking) {
+	if tt.OriginalEstimateSeconds > 0 {
+		custom["jiraOriginalEstimateSeconds"] = strconv.FormatInt(tt.OriginalEstimateSeconds, 10)
+	}
+	if tt.RemainingEstimateSeconds > 0 {
+		custom["jiraRemainingEstimateSeconds"] = strconv.FormatInt(tt.RemainingEstimateSeconds, 10)
+	}
+	if tt.TimeSpentSeconds > 0 {
+		custom["jiraTimeSpentSeconds"] = strconv.FormatInt(tt.TimeSpentSeconds, 10)
+	}
+}
+
+// addSprintMetadata records the sprint name, state and dates as custom metadata
+func (c *ProtoConverter) addSprintMetadata(custom map[string]string, sprint *jirapb.Sprint) {
+	custom["jiraSprint"] = sprint.Name
+	custom["jiraSprintId"] = strconv.FormatInt(sprint.Id, 10)
+	if sprint.State != "" {
+		custom["jiraSprintState"] = sprint.State
+	}
+	if sprint.StartDate != nil {
+		custom["jiraSprintStart"] = sprint.StartDate.AsTime().Format(time.RFC3339)
+	}
+	if sprint.EndDate != nil {
+		custom["jiraSprintEnd"] = sprint.EndDate.AsTime().Format(time.RFC3339)
+	}
+}
+
 // mapStatus maps Jira status to beads status
 func (c *ProtoConverter) mapStatus(jiraStatus *jirapb.Status) beadspb.Status {
+	if jiraStatus != nil {
+		for name, status := range c.mapping.Statuses {
+			if strings.EqualFold(name, jiraStatus.Name) {
+				return status
+			}
+		}
+	}
 	if jiraStatus == nil || jiraStatus.StatusCategory == nil {
 		return beadspb.Status_STATUS_OPEN
 	}
@@ -204,6 +341,11 @@ func (c *ProtoConverter) mapPriority(jiraPriority *jirapb.Priority) beadspb.Prio
 	if jiraPriority == nil {
 		return beadspb.Priority_PRIORITY_P2
 	}
+	for name, priority := range c.mapping.Priorities {
+		if strings.EqualFold(name, jiraPriority.Name) {
+			return priority
+		}
+	}
 
 	priorityName := strings.ToLower(jiraPriority.Name)
 
@@ -239,11 +381,20 @@ func (c *ProtoConverter) buildIssueMap(export *jirapb.Export) map[string]*jirapb
 	return issueMap
 }
 
-// getEpics returns all issues that are epics
+// isEpicParent reports whether a parent is at the epic level, whether or not
+// it was fetched
+func (c *ProtoConverter) isEpicParent(parent *jirapb.Parent) bool {
+	if _, exists := c.epicMap[parent.Key]; exists {
+		return true
+	}
+	return c.hierarchy.IsEpic(parent.GetFields().GetIssueType())
+}
+
+// getEpics returns all issues at the epic level or above
 func (c *ProtoConverter) getEpics(export *jirapb.Export) []*jirapb.Issue {
 	var epics []*jirapb.Issue
 	for _, issue := range export.Issues {
-		if issue.Fields.IssueType.Name == "Epic" {
+		if c.hierarchy.IsEpic(issue.Fields.IssueType) {
 			epics = append(epics, issue)
 		}
 	}
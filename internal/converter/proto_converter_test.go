@@ -2,9 +2,12 @@ package converter
 
 import (
 	"testing"
+	"time"
 
 	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
 	jirapb "github.com/conallob/jira-beads-sync/gen/jira"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+	"github.com/conallob/jira-beads-sync/internal/jira"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -150,6 +153,30 @@ func TestProtoMapPriority(t *testing.T) {
 	}
 }
 
+func TestProtoMapping(t *testing.T) {
+	conv := NewProtoConverter()
+	conv.SetMapping(Mapping{
+		Statuses:   map[string]beadspb.Status{"awaiting review": beadspb.Status_STATUS_BLOCKED},
+		Priorities: map[string]beadspb.Priority{"Blocker": beadspb.Priority_PRIORITY_P0},
+	})
+
+	review := &jirapb.Status{Name: "Awaiting Review", StatusCategory: &jirapb.StatusCategory{Key: "indeterminate"}}
+	if got := conv.mapStatus(review); got != beadspb.Status_STATUS_BLOCKED {
+		t.Errorf("mapStatus() = %v, want STATUS_BLOCKED", got)
+	}
+	doing := &jirapb.Status{Name: "Doing", StatusCategory: &jirapb.StatusCategory{Key: "indeterminate"}}
+	if got := conv.mapStatus(doing); got != beadspb.Status_STATUS_IN_PROGRESS {
+		t.Errorf("mapStatus() = %v, want the built-in STATUS_IN_PROGRESS", got)
+	}
+
+	if got := conv.mapPriority(&jirapb.Priority{Name: "blocker"}); got != beadspb.Priority_PRIORITY_P0 {
+		t.Errorf("mapPriority() = %v, want PRIORITY_P0", got)
+	}
+	if got := conv.mapPriority(&jirapb.Priority{Name: "Low"}); got != beadspb.Priority_PRIORITY_P3 {
+		t.Errorf("mapPriority() = %v, want the built-in PRIORITY_P3", got)
+	}
+}
+
 func TestProtoGenerateBeadsID(t *testing.T) {
 	conv := NewProtoConverter()
 
@@ -375,3 +402,170 @@ func TestProtoGetDependencies(t *testing.T) {
 		t.Errorf("Expected PROJ-1 to depend on PROJ-2, got %v", proj1Deps)
 	}
 }
+
+func TestProtoConvertIssueTimeTracking(t *testing.T) {
+	conv := NewProtoConverter()
+
+	jiraIssue := &jirapb.Issue{
+		Id:  "10002",
+		Key: "PROJ-2",
+		Fields: &jirapb.Fields{
+			Summary:   "Estimated Issue",
+			IssueType: &jirapb.IssueType{Name: "Story"},
+			TimeTracking: &jirapb.TimeTracking{
+				OriginalEstimateSeconds:  28800,
+				RemainingEstimateSeconds: 7200,
+				TimeSpentSeconds:         21600,
+			},
+			StoryPoints: 3,
+		},
+	}
+
+	issue, err := conv.convertIssue(jiraIssue)
+	if err != nil {
+		t.Fatalf("convertIssue failed: %v", err)
+	}
+
+	if issue.EstimatedMinutes != 480 {
+		t.Errorf("Expected 480 estimated minutes, got %d", issue.EstimatedMinutes)
+	}
+
+	expected := map[string]string{
+		"jiraOriginalEstimateSeconds":  "28800",
+		"jiraRemainingEstimateSeconds": "7200",
+		"jiraTimeSpentSeconds":         "21600",
+		"jiraStoryPoints":              "3",
+	}
+	for key, want := range expected {
+		if got := issue.Metadata.Custom[key]; got != want {
+			t.Errorf("Expected metadata %s=%s, got %q", key, want, got)
+		}
+	}
+}
+
+func TestProtoConvertIssueSprint(t *testing.T) {
+	conv := NewProtoConverter()
+
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 15, 17, 0, 0, 0, time.UTC)
+	jiraIssue := &jirapb.Issue{
+		Id:  "10002",
+		Key: "PROJ-2",
+		Fields: &jirapb.Fields{
+			Summary:   "Sprint Issue",
+			IssueType: &jirapb.IssueType{Name: "Story"},
+			Sprint: &jirapb.Sprint{
+				Id:        7,
+				Name:      "Sprint 7",
+				State:     "active",
+				StartDate: timestamppb.New(start),
+				EndDate:   timestamppb.New(end),
+			},
+		},
+	}
+
+	issue, err := conv.convertIssue(jiraIssue)
+	if err != nil {
+		t.Fatalf("convertIssue failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"jiraSprint":      "Sprint 7",
+		"jiraSprintId":    "7",
+		"jiraSprintState": "active",
+		"jiraSprintStart": "2024-03-01T09:00:00Z",
+		"jiraSprintEnd":   "2024-03-15T17:00:00Z",
+	}
+	for key, want := range expected {
+		if got := issue.Metadata.Custom[key]; got != want {
+			t.Errorf("Expected metadata %s=%s, got %q", key, want, got)
+		}
+	}
+}
+
+func TestProtoConvertIssueEpicLinkField(t *testing.T) {
+	conv := NewProtoConverter()
+	conv.epicMap["EPIC-1"] = "epic-1"
+
+	jiraIssue := &jirapb.Issue{
+		Id:  "10002",
+		Key: "PROJ-2",
+		Fields: &jirapb.Fields{
+			Summary:   "Story in a legacy epic",
+			IssueType: &jirapb.IssueType{Name: "Story"},
+			Epic:      &jirapb.Epic{Key: "EPIC-1", Name: "Legacy epic"},
+		},
+	}
+
+	issue, err := conv.convertIssue(jiraIssue)
+	if err != nil {
+		t.Fatalf("convertIssue failed: %v", err)
+	}
+
+	if issue.Epic != "epic-1" {
+		t.Errorf("Expected epic epic-1 from the Epic Link field, got %q", issue.Epic)
+	}
+}
+
+func TestProtoConvertMultiLevelHierarchy(t *testing.T) {
+	conv := NewProtoConverterWithHierarchy(jira.Hierarchy{EpicTypes: []string{"Initiative", "Epic"}})
+
+	issue := func(key, issueType string, subtask bool, parent *jirapb.Parent) *jirapb.Issue {
+		return &jirapb.Issue{
+			Key: key,
+			Fields: &jirapb.Fields{
+				Summary:   key,
+				IssueType: &jirapb.IssueType{Name: issueType, Subtask: subtask},
+				Parent:    parent,
+			},
+		}
+	}
+	parent := func(key, issueType string) *jirapb.Parent {
+		return &jirapb.Parent{Key: key, Fields: &jirapb.LinkedFields{IssueType: &jirapb.IssueType{Name: issueType}}}
+	}
+
+	jiraExport := &jirapb.Export{
+		Issues: []*jirapb.Issue{
+			issue("PROJ-4", "Sub-task", true, parent("PROJ-3", "Story")),
+			issue("PROJ-3", "Story", false, parent("PROJ-2", "Epic")),
+			issue("PROJ-2", "Epic", false, parent("PROJ-1", "Initiative")),
+			issue("PROJ-1", "Initiative", false, nil),
+		},
+	}
+
+	beadsExport, err := conv.Convert(jiraExport)
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	if len(beadsExport.Epics) != 2 || len(beadsExport.Issues) != 2 {
+		t.Fatalf("Expected 2 epics and 2 issues, got %d and %d", len(beadsExport.Epics), len(beadsExport.Issues))
+	}
+
+	epics := make(map[string]*beadspb.Epic)
+	for _, epic := range beadsExport.Epics {
+		epics[epic.Id] = epic
+	}
+	if epics["proj-2"].Parent != "proj-1" {
+		t.Errorf("Expected epic proj-2 nested under initiative proj-1, got %q", epics["proj-2"].Parent)
+	}
+	if epics["proj-1"].Parent != "" {
+		t.Errorf("Expected initiative to have no parent, got %q", epics["proj-1"].Parent)
+	}
+
+	for _, beadsIssue := range beadsExport.Issues {
+		switch beadsIssue.Id {
+		case "proj-3":
+			if beadsIssue.Epic != "proj-2" {
+				t.Errorf("Expected story in epic proj-2, got %q", beadsIssue.Epic)
+			}
+		case "proj-4":
+			if !contains(beadsIssue.DependsOn, "proj-3") {
+				t.Errorf("Expected subtask to depend on its story, got %v", beadsIssue.DependsOn)
+			}
+			if got := beadsIssue.Metadata.Custom[beads.ParentKey]; got != "PROJ-3" {
+				t.Errorf("Expected subtask's parent key recorded, got %q", got)
+			}
+		}
+	}
+}
@@ -0,0 +1,259 @@
+// Package diff compares beads exports field by field, to show what an
+// import would change in .beads
+package diff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+)
+
+// Kind is how an issue or epic changed
+type Kind string
+
+// Change kinds
+const (
+	Added    Kind = "added"
+	Removed  Kind = "removed"
+	Modified Kind = "modified"
+)
+
+// Types of item compared
+const (
+	TypeIssue = "issue"
+	TypeEpic  = "epic"
+)
+
+// Change is an issue or epic that differs between the exports
+type Change struct {
+	Kind   Kind          `json:"kind"`
+	Type   string        `json:"type"` // issue or epic
+	ID     string        `json:"id"`
+	Title  string        `json:"title"`
+	Fields []FieldChange `json:"fields"`
+}
+
+// FieldChange is a field that differs, with values as written to JSONL;
+// lists are comma-separated and metadata fields are named metadata.<key>
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// Result is the difference between two exports
+type Result struct {
+	Changes   []Change `json:"changes"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// Count returns the number of changes of a kind
+func (r *Result) Count(kind Kind) int {
+	n := 0
+	for _, change := range r.Changes {
+		if change.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// field is a named value of an issue or epic
+type field struct {
+	name, value string
+}
+
+// item is an issue or epic flattened to its fields
+type item struct {
+	typ, id, title string
+	fields         []field
+}
+
+// Compare compares the issues and epics in before with those in after.
+// Epics come first, then issues, each sorted by ID.
+func Compare(before, after *beadspb.Export) *Result {
+	result := &Result{Changes: []Change{}}
+	compare := func(oldItems, newItems []item) {
+		oldByID := make(map[string]item)
+		for _, it := range oldItems {
+			oldByID[it.id] = it
+		}
+		newIDs := make(map[string]bool)
+
+		var changes []Change
+		for _, it := range newItems {
+			newIDs[it.id] = true
+			previous, exists := oldByID[it.id]
+			if !exists {
+				changes = append(changes, Change{Kind: Added, Type: it.typ, ID: it.id, Title: it.title, Fields: fieldChanges(nil, it.fields)})
+				continue
+			}
+			if fields := fieldChanges(previous.fields, it.fields); len(fields) > 0 {
+				changes = append(changes, Change{Kind: Modified, Type: it.typ, ID: it.id, Title: it.title, Fields: fields})
+			} else {
+				result.Unchanged++
+			}
+		}
+		for _, it := range oldItems {
+			if !newIDs[it.id] {
+				changes = append(changes, Change{Kind: Removed, Type: it.typ, ID: it.id, Title: it.title, Fields: fieldChanges(it.fields, nil)})
+			}
+		}
+
+		sort.SliceStable(changes, func(i, j int) bool { return changes[i].ID < changes[j].ID })
+		result.Changes = append(result.Changes, changes...)
+	}
+
+	compare(epicItems(before), epicItems(after))
+	compare(issueItems(before), issueItems(after))
+	return result
+}
+
+// fieldChanges lists the fields whose values differ, in the order they're
+// written, with metadata fields last
+func fieldChanges(before, after []field) []FieldChange {
+	oldValues := make(map[string]string)
+	for _, f := range before {
+		oldValues[f.name] = f.value
+	}
+	newValues := make(map[string]string)
+	for _, f := range after {
+		newValues[f.name] = f.value
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, f := range append(append([]field{}, after...), before...) {
+		if !seen[f.name] {
+			seen[f.name] = true
+			names = append(names, f.name)
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return !isMetadata(names[i]) && isMetadata(names[j]) ||
+			isMetadata(names[i]) && isMetadata(names[j]) && names[i] < names[j]
+	})
+
+	changes := []FieldChange{}
+	for _, name := range names {
+		if oldValues[name] != newValues[name] {
+			changes = append(changes, FieldChange{Field: name, Old: oldValues[name], New: newValues[name]})
+		}
+	}
+	return changes
+}
+
+// isMetadata reports whether a field is a metadata entry
+func isMetadata(name string) bool {
+	return strings.HasPrefix(name, "metadata.")
+}
+
+// issueItems flattens issues to their fields as written to issues.jsonl
+func issueItems(export *beadspb.Export) []item {
+	var items []item
+	for _, issue := range export.GetIssues() {
+		j := beads.IssueToJSON(issue)
+		fields := []field{
+			{"title", j.Title},
+			{"description", j.Description},
+			{"status", j.Status},
+			{"priority", j.Priority},
+			{"epic", j.Epic},
+			{"assignee", j.Assignee},
+			{"labels", strings.Join(j.Labels, ", ")},
+			{"dependsOn", strings.Join(j.DependsOn, ", ")},
+			{"estimatedMinutes", formatMinutes(j.EstimatedMinutes)},
+			{"created", j.Created},
+			{"updated", j.Updated},
+		}
+		items = append(items, item{typ: TypeIssue, id: j.ID, title: j.Title, fields: withMetadata(fields, j.Metadata)})
+	}
+	return items
+}
+
+// epicItems flattens epics to their fields as written to epics.jsonl
+func epicItems(export *beadspb.Export) []item {
+	var items []item
+	for _, epic := range export.GetEpics() {
+		j := beads.EpicToJSON(epic)
+		fields := []field{
+			{"name", j.Name},
+			{"description", j.Description},
+			{"status", j.Status},
+			{"parent", j.Parent},
+			{"created", j.Created},
+			{"updated", j.Updated},
+		}
+		items = append(items, item{typ: TypeEpic, id: j.ID, title: j.Name, fields: withMetadata(fields, j.Metadata)})
+	}
+	return items
+}
+
+// withMetadata drops empty fields and appends the metadata entries
+func withMetadata(fields []field, metadata map[string]string) []field {
+	result := fields[:0]
+	for _, f := range fields {
+		if f.value != "" {
+			result = append(result, f)
+		}
+	}
+	for key, value := range metadata {
+		result = append(result, field{"metadata." + key, value})
+	}
+	return result
+}
+
+// formatMinutes formats an estimate, leaving zero empty as JSONL omits it
+func formatMinutes(minutes int32) string {
+	if minutes == 0 {
+		return ""
+	}
+	return strconv.Itoa(int(minutes))
+}
+
+// WriteUnified writes the changes as a unified diff of fields, one hunk per
+// issue or epic under a header for the file it's in
+func WriteUnified(w io.Writer, result *Result) error {
+	var b strings.Builder
+	file := ""
+	for _, change := range result.Changes {
+		name := ".beads/issues.jsonl"
+		if change.Type == TypeEpic {
+			name = ".beads/epics.jsonl"
+		}
+		if name != file {
+			file = name
+			fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", name, name)
+		}
+
+		fmt.Fprintf(&b, "@@ %s %s: %s @@\n", change.Kind, change.ID, change.Title)
+		for _, f := range change.Fields {
+			if change.Kind != Added {
+				writeLines(&b, "-", f.Field, f.Old)
+			}
+			if change.Kind != Removed {
+				writeLines(&b, "+", f.Field, f.New)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeLines writes a field's value with a diff prefix, continuing
+// multi-line values such as descriptions on lines of their own
+func writeLines(b *strings.Builder, prefix, name, value string) {
+	if value == "" {
+		return
+	}
+	lines := strings.Split(value, "\n")
+	fmt.Fprintf(b, "%s%s: %s\n", prefix, name, lines[0])
+	for _, line := range lines[1:] {
+		fmt.Fprintf(b, "%s  %s\n", prefix, line)
+	}
+}
@@ -0,0 +1,83 @@
+package diff
+
+import (
+	"bytes"
+	"testing"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+)
+
+func TestCompare(t *testing.T) {
+	before := &beadspb.Export{
+		Issues: []*beadspb.Issue{
+			{Id: "proj-2", Title: "Login", Status: beadspb.Status_STATUS_OPEN, DependsOn: []string{"proj-3"},
+				Metadata: &beadspb.Metadata{JiraKey: "PROJ-2", Custom: map[string]string{"repositories": "org/app"}}},
+			{Id: "proj-3", Title: "Schema", Status: beadspb.Status_STATUS_CLOSED},
+			{Id: "proj-4", Title: "Gone", Status: beadspb.Status_STATUS_OPEN},
+		},
+		Epics: []*beadspb.Epic{
+			{Id: "proj-1", Name: "Auth", Status: beadspb.Status_STATUS_OPEN},
+		},
+	}
+	after := &beadspb.Export{
+		Issues: []*beadspb.Issue{
+			{Id: "proj-5", Title: "Logout", Status: beadspb.Status_STATUS_OPEN, Description: "Clear the session\nand redirect"},
+			{Id: "proj-3", Title: "Schema", Status: beadspb.Status_STATUS_CLOSED},
+			{Id: "proj-2", Title: "Login", Status: beadspb.Status_STATUS_IN_PROGRESS, Epic: "proj-1",
+				Metadata: &beadspb.Metadata{JiraKey: "PROJ-2"}},
+		},
+		Epics: []*beadspb.Epic{
+			{Id: "proj-1", Name: "Auth", Status: beadspb.Status_STATUS_OPEN},
+		},
+	}
+
+	result := Compare(before, after)
+	if result.Unchanged != 2 {
+		t.Errorf("Expected 2 unchanged, got %d", result.Unchanged)
+	}
+	if added, removed, modified := result.Count(Added), result.Count(Removed), result.Count(Modified); added != 1 || removed != 1 || modified != 1 {
+		t.Errorf("Expected 1 added, removed and modified, got %d, %d, %d", added, removed, modified)
+	}
+
+	var out bytes.Buffer
+	if err := WriteUnified(&out, result); err != nil {
+		t.Fatalf("WriteUnified failed: %v", err)
+	}
+	want := `--- a/.beads/issues.jsonl
++++ b/.beads/issues.jsonl
+@@ modified proj-2: Login @@
+-status: open
++status: in_progress
++epic: proj-1
+-dependsOn: proj-3
+-metadata.repositories: org/app
+@@ removed proj-4: Gone @@
+-title: Gone
+-status: open
+@@ added proj-5: Logout @@
++title: Logout
++description: Clear the session
++  and redirect
++status: open
+`
+	if out.String() != want {
+		t.Errorf("Expected diff:\n%s\ngot:\n%s", want, out.String())
+	}
+}
+
+func TestCompareIdentical(t *testing.T) {
+	export := &beadspb.Export{
+		Issues: []*beadspb.Issue{{Id: "proj-1", Title: "Task", Labels: []string{"a", "b"}}},
+		Epics:  []*beadspb.Epic{{Id: "proj-10", Name: "Epic"}},
+	}
+
+	result := Compare(export, export)
+	if len(result.Changes) != 0 || result.Unchanged != 2 {
+		t.Errorf("Expected no changes, got %+v", result)
+	}
+
+	var out bytes.Buffer
+	if err := WriteUnified(&out, result); err != nil || out.Len() != 0 {
+		t.Errorf("Expected no output, got %q, %v", out.String(), err)
+	}
+}
@@ -0,0 +1,292 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+)
+
+// Weight is how much an issue adds to the length of a chain
+type Weight func(node *Node) float64
+
+// Count weighs every issue the same, so the longest chain has the most issues
+func Count(*Node) float64 { return 1 }
+
+// Points weighs issues by their story points; unestimated issues add nothing
+func Points(node *Node) float64 { return node.Points }
+
+// ParseWeight parses a weight name: count or points
+func ParseWeight(s string) (Weight, error) {
+	switch strings.ToLower(s) {
+	case "", "count":
+		return Count, nil
+	case "points":
+		return Points, nil
+	default:
+		return nil, fmt.Errorf("invalid weight %q (use count or points)", s)
+	}
+}
+
+// Analysis is what can be worked on now, and what determines when the work
+// is done
+type Analysis struct {
+	// Ready are unfinished issues whose dependencies are all closed
+	Ready []string `json:"ready"`
+	// Blocked maps the other unfinished issues to the longest chain of
+	// unfinished issues ahead of them, in work order. It's empty for issues
+	// waiting only on External dependencies.
+	Blocked map[string][]string `json:"blocked"`
+	// External maps blocked issues to their unfinished dependencies that
+	// aren't issues in the export, such as issues that weren't fetched
+	External map[string][]string `json:"external,omitempty"`
+	// Order lists unfinished issues so each follows its dependencies
+	Order []string `json:"order"`
+	// Cyclic are unfinished issues left out of the order because they're
+	// in, or wait on, a dependency cycle
+	Cyclic []string `json:"cyclic,omitempty"`
+	// CriticalPath is the heaviest chain of unfinished issues, and Length
+	// its total weight
+	CriticalPath []string `json:"criticalPath"`
+	Length       float64  `json:"length"`
+}
+
+// Analyze finds the ready and blocked issues of an export, a work order and
+// the critical path by weight. Dependencies on issues not in the export
+// count as unfinished, since nothing says they're done.
+func Analyze(export *beadspb.Export, weight Weight) *Analysis {
+	g := New(export)
+	analysis := &Analysis{
+		Ready:   g.Ready(),
+		Blocked: make(map[string][]string),
+		Order:   []string{},
+	}
+
+	ready := make(map[string]bool)
+	for _, id := range analysis.Ready {
+		ready[id] = true
+	}
+	c := newChains(g, weight)
+	for _, node := range g.Nodes {
+		if done(node.Status) || ready[node.ID] {
+			continue
+		}
+		analysis.Blocked[node.ID] = c.blocked(node.ID)
+		if len(node.External) > 0 {
+			if analysis.External == nil {
+				analysis.External = make(map[string][]string)
+			}
+			analysis.External[node.ID] = node.External
+		}
+	}
+
+	order, cyclic := g.TopologicalOrder()
+	for _, id := range order {
+		if !done(g.byID[id].Status) {
+			analysis.Order = append(analysis.Order, id)
+		}
+	}
+	for _, id := range cyclic {
+		if !done(g.byID[id].Status) {
+			analysis.Cyclic = append(analysis.Cyclic, id)
+		}
+	}
+
+	analysis.CriticalPath, analysis.Length = c.longestPath()
+	if analysis.CriticalPath == nil {
+		analysis.CriticalPath = []string{}
+	}
+	return analysis
+}
+
+// dependencies maps each issue to the issues it depends on
+func (g *Graph) dependencies() map[string][]string {
+	deps := make(map[string][]string)
+	for _, edge := range g.Edges {
+		deps[edge.To] = append(deps[edge.To], edge.From)
+	}
+	return deps
+}
+
+// Ready returns the unfinished issues whose dependencies are all closed, in
+// export order. Issues with External dependencies aren't ready.
+func (g *Graph) Ready() []string {
+	deps := g.dependencies()
+	ready := []string{}
+	for _, node := range g.Nodes {
+		if done(node.Status) || len(node.External) > 0 {
+			continue
+		}
+		waiting := false
+		for _, dep := range deps[node.ID] {
+			if !done(g.byID[dep].Status) {
+				waiting = true
+				break
+			}
+		}
+		if !waiting {
+			ready = append(ready, node.ID)
+		}
+	}
+	return ready
+}
+
+// BlockedChain returns the heaviest chain of unfinished issues an issue
+// waits on, in work order, or nothing if it's ready or closed
+func (g *Graph) BlockedChain(id string, weight Weight) []string {
+	return newChains(g, weight).blocked(id)
+}
+
+// LongestPath returns the heaviest chain of unfinished issues, each
+// depending on the one before, in work order, and its total weight. Ties go
+// to the chain with more issues, then the one ending earliest in the
+// export. Cycles are broken where they're found.
+func (g *Graph) LongestPath(weight Weight) ([]string, float64) {
+	return newChains(g, weight).longestPath()
+}
+
+// TopologicalOrder returns the issues so that each follows its
+// dependencies, keeping export order where there's a choice. Issues in, or
+// waiting on, a dependency cycle can't be ordered and are returned apart.
+func (g *Graph) TopologicalOrder() (order, cyclic []string) {
+	index := make(map[string]int)
+	for i, node := range g.Nodes {
+		index[node.ID] = i
+	}
+	waiting := make(map[string]int)
+	dependents := make(map[string][]string)
+	for _, edge := range g.Edges {
+		waiting[edge.To]++
+		dependents[edge.From] = append(dependents[edge.From], edge.To)
+	}
+
+	// queue holds the indexes of issues with nothing left to wait on, sorted
+	var queue []int
+	for i, node := range g.Nodes {
+		if waiting[node.ID] == 0 {
+			queue = append(queue, i)
+		}
+	}
+	order = []string{}
+	for len(queue) > 0 {
+		id := g.Nodes[queue[0]].ID
+		queue = queue[1:]
+		order = append(order, id)
+		for _, dependent := range dependents[id] {
+			if waiting[dependent]--; waiting[dependent] == 0 {
+				i := index[dependent]
+				at := sort.SearchInts(queue, i)
+				queue = append(queue[:at], append([]int{i}, queue[at:]...)...)
+			}
+		}
+	}
+
+	for _, node := range g.Nodes {
+		if waiting[node.ID] > 0 {
+			cyclic = append(cyclic, node.ID)
+		}
+	}
+	return order, cyclic
+}
+
+// score is the length of a chain: its weight, then its number of issues
+type score struct {
+	weight float64
+	issues int
+}
+
+// less reports whether s is shorter than o
+func (s score) less(o score) bool {
+	if s.weight != o.weight {
+		return s.weight < o.weight
+	}
+	return s.issues < o.issues
+}
+
+// chains finds the heaviest chain of unfinished dependencies ending at each
+// issue
+type chains struct {
+	g        *Graph
+	weight   Weight
+	deps     map[string][]string
+	scores   map[string]score
+	next     map[string]string // The dependency the heaviest chain continues to
+	visiting map[string]bool
+}
+
+func newChains(g *Graph, weight Weight) *chains {
+	return &chains{
+		g:        g,
+		weight:   weight,
+		deps:     g.dependencies(),
+		scores:   make(map[string]score),
+		next:     make(map[string]string),
+		visiting: make(map[string]bool),
+	}
+}
+
+// longest returns the score of the heaviest chain ending at an issue
+func (c *chains) longest(id string) score {
+	if s, ok := c.scores[id]; ok {
+		return s
+	}
+	c.visiting[id] = true
+	var best score
+	for _, dep := range c.deps[id] {
+		if c.visiting[dep] || done(c.g.byID[dep].Status) {
+			continue
+		}
+		if s := c.longest(dep); best.less(s) {
+			best = s
+			c.next[id] = dep
+		}
+	}
+	c.visiting[id] = false
+	s := score{weight: best.weight + c.weight(c.g.byID[id]), issues: best.issues + 1}
+	c.scores[id] = s
+	return s
+}
+
+// blocked returns the heaviest chain of unfinished issues an issue waits
+// on, in work order, or nothing if it's closed
+func (c *chains) blocked(id string) []string {
+	node := c.g.byID[id]
+	if node == nil || done(node.Status) {
+		return nil
+	}
+	path := c.path(id)
+	return path[:len(path)-1]
+}
+
+// longestPath returns the heaviest chain of unfinished issues and its weight
+func (c *chains) longestPath() ([]string, float64) {
+	start, best := "", score{}
+	for _, node := range c.g.Nodes {
+		if done(node.Status) {
+			continue
+		}
+		if s := c.longest(node.ID); best.less(s) {
+			start, best = node.ID, s
+		}
+	}
+	if start == "" {
+		return nil, 0
+	}
+	return c.path(start), best.weight
+}
+
+// path returns the heaviest chain ending at an issue, in work order
+func (c *chains) path(id string) []string {
+	c.longest(id)
+
+	// Walk from the last issue back to the first, then reverse
+	path := []string{id}
+	for dep := c.next[id]; dep != ""; dep = c.next[dep] {
+		path = append(path, dep)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
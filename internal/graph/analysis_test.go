@@ -0,0 +1,116 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+)
+
+// plannedExport has two chains into proj-5: a long one of small issues and a
+// short one of large issues. proj-7 waits only on an issue that wasn't
+// fetched, and proj-6 only on a closed epic.
+func plannedExport() *beadspb.Export {
+	points := func(p string) *beadspb.Metadata {
+		return &beadspb.Metadata{Custom: map[string]string{beads.PointsKey: p}}
+	}
+	return &beadspb.Export{
+		Issues: []*beadspb.Issue{
+			{Id: "proj-1", Status: beadspb.Status_STATUS_CLOSED, Metadata: points("8")},
+			{Id: "proj-2", Status: beadspb.Status_STATUS_OPEN, DependsOn: []string{"proj-1"}, Metadata: points("1")},
+			{Id: "proj-3", Status: beadspb.Status_STATUS_OPEN, DependsOn: []string{"proj-2"}, Metadata: points("1")},
+			{Id: "proj-4", Status: beadspb.Status_STATUS_IN_PROGRESS, Metadata: points("13")},
+			{Id: "proj-5", Status: beadspb.Status_STATUS_OPEN, DependsOn: []string{"proj-3", "proj-4", "other-1"}, Metadata: points("2")},
+			{Id: "proj-6", Status: beadspb.Status_STATUS_OPEN, DependsOn: []string{"proj-10"}},
+			{Id: "proj-7", Status: beadspb.Status_STATUS_OPEN, DependsOn: []string{"other-2"}},
+		},
+		Epics: []*beadspb.Epic{
+			{Id: "proj-10", Status: beadspb.Status_STATUS_CLOSED},
+		},
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name       string
+		weight     Weight
+		wantPath   []string
+		wantLength float64
+		wantChain  []string // Ahead of proj-5
+	}{
+		{
+			name:       "count",
+			weight:     Count,
+			wantPath:   []string{"proj-2", "proj-3", "proj-5"},
+			wantLength: 3,
+			wantChain:  []string{"proj-2", "proj-3"},
+		},
+		{
+			name:       "points",
+			weight:     Points,
+			wantPath:   []string{"proj-4", "proj-5"},
+			wantLength: 15,
+			wantChain:  []string{"proj-4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := Analyze(plannedExport(), tt.weight)
+
+			if want := []string{"proj-2", "proj-4", "proj-6"}; !reflect.DeepEqual(analysis.Ready, want) {
+				t.Errorf("Expected ready %v, got %v", want, analysis.Ready)
+			}
+			if len(analysis.Blocked) != 3 {
+				t.Errorf("Expected proj-3, proj-5 and proj-7 blocked, got %v", analysis.Blocked)
+			}
+			if chain, ok := analysis.Blocked["proj-7"]; !ok || len(chain) != 0 {
+				t.Errorf("Expected proj-7 blocked by nothing in the export, got %v", chain)
+			}
+			want := map[string][]string{"proj-5": {"other-1"}, "proj-7": {"other-2"}}
+			if !reflect.DeepEqual(analysis.External, want) {
+				t.Errorf("Expected external dependencies %v, got %v", want, analysis.External)
+			}
+			if !reflect.DeepEqual(analysis.Blocked["proj-5"], tt.wantChain) {
+				t.Errorf("Expected proj-5 blocked by %v, got %v", tt.wantChain, analysis.Blocked["proj-5"])
+			}
+			if !reflect.DeepEqual(analysis.CriticalPath, tt.wantPath) || analysis.Length != tt.wantLength {
+				t.Errorf("Expected critical path %v of %v, got %v of %v", tt.wantPath, tt.wantLength, analysis.CriticalPath, analysis.Length)
+			}
+		})
+	}
+}
+
+func TestTopologicalOrder(t *testing.T) {
+	g := New(plannedExport())
+	order, cyclic := g.TopologicalOrder()
+	want := []string{"proj-1", "proj-2", "proj-3", "proj-4", "proj-5", "proj-6", "proj-7"}
+	if !reflect.DeepEqual(order, want) || cyclic != nil {
+		t.Errorf("Expected order %v, got %v (cyclic %v)", want, order, cyclic)
+	}
+
+	// b and c wait on each other, and d waits on the cycle
+	g = New(&beadspb.Export{Issues: []*beadspb.Issue{
+		{Id: "d", DependsOn: []string{"c"}},
+		{Id: "c", DependsOn: []string{"b"}},
+		{Id: "b", DependsOn: []string{"c", "a"}},
+		{Id: "a"},
+	}})
+	order, cyclic = g.TopologicalOrder()
+	if !reflect.DeepEqual(order, []string{"a"}) || !reflect.DeepEqual(cyclic, []string{"d", "c", "b"}) {
+		t.Errorf("Expected a ordered and the rest cyclic, got %v and %v", order, cyclic)
+	}
+	if chain := g.BlockedChain("c", Count); !reflect.DeepEqual(chain, []string{"a", "b"}) {
+		t.Errorf("Expected c blocked by a and b, got %v", chain)
+	}
+}
+
+func TestParseWeight(t *testing.T) {
+	if _, err := ParseWeight("points"); err != nil {
+		t.Errorf("Expected points to parse, got %v", err)
+	}
+	if _, err := ParseWeight("hours"); err == nil {
+		t.Error("Expected an error for hours")
+	}
+}
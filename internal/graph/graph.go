@@ -0,0 +1,183 @@
+// Package graph renders beads issues, epics and their dependencies as
+// Graphviz DOT or Mermaid flowcharts, and analyzes which issues are ready
+// and which chain of them determines when the work is done
+package graph
+
+import (
+	"strconv"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+)
+
+// Node is an issue in the graph
+type Node struct {
+	ID     string
+	Title  string
+	Status string  // As written to JSONL, e.g. in_progress
+	Epic   string  // Epic the issue belongs to, if it's in the graph
+	Points float64 // Story points, or 0 if not estimated
+	// External are unfinished dependencies that aren't issues in the graph,
+	// such as issues that weren't fetched, or open epics
+	External []string
+}
+
+// Cluster is an epic, grouping its issues and any epics nested under it
+type Cluster struct {
+	ID       string
+	Name     string
+	Status   string
+	Nodes    []*Node
+	Children []*Cluster
+}
+
+// Edge is a dependency: To depends on From, so From comes first
+type Edge struct {
+	From, To string
+}
+
+// Graph is the dependency graph of an export
+type Graph struct {
+	Nodes    []*Node    // Every issue, in export order
+	Clusters []*Cluster // Top-level epics
+	Loose    []*Node    // Issues in no epic
+	Edges    []Edge     // Dependencies between issues in the graph
+
+	byID map[string]*Node
+}
+
+// New builds the graph of an export's issues and epics. Dependencies on
+// anything but another issue in the export are recorded as External unless
+// they're closed epics, and links to missing epics are left out.
+func New(export *beadspb.Export) *Graph {
+	g := &Graph{byID: make(map[string]*Node)}
+
+	clusters := make(map[string]*Cluster)
+	for _, epic := range export.GetEpics() {
+		clusters[epic.Id] = &Cluster{ID: epic.Id, Name: epic.Name, Status: beads.EpicToJSON(epic).Status}
+	}
+	parents := make(map[string]string)
+	for _, epic := range export.GetEpics() {
+		parents[epic.Id] = epic.Parent
+	}
+	for _, epic := range export.GetEpics() {
+		cluster := clusters[epic.Id]
+		if parent, ok := clusters[epic.Parent]; ok && !inCycle(parents, epic.Id) {
+			parent.Children = append(parent.Children, cluster)
+		} else {
+			g.Clusters = append(g.Clusters, cluster)
+		}
+	}
+
+	for _, issue := range export.GetIssues() {
+		node := &Node{ID: issue.Id, Title: issue.Title, Status: beads.IssueToJSON(issue).Status}
+		if points, err := strconv.ParseFloat(issue.GetMetadata().GetCustom()[beads.PointsKey], 64); err == nil {
+			node.Points = points
+		}
+		g.Nodes = append(g.Nodes, node)
+		g.byID[node.ID] = node
+		if cluster, ok := clusters[issue.Epic]; ok {
+			node.Epic = issue.Epic
+			cluster.Nodes = append(cluster.Nodes, node)
+		} else {
+			g.Loose = append(g.Loose, node)
+		}
+	}
+
+	for _, issue := range export.GetIssues() {
+		node := g.byID[issue.Id]
+		for _, dep := range issue.DependsOn {
+			_, isIssue := g.byID[dep]
+			epic, isEpic := clusters[dep]
+			switch {
+			case dep == issue.Id:
+				// Self-dependencies are left to validation
+			case isIssue:
+				g.Edges = append(g.Edges, Edge{From: dep, To: issue.Id})
+			case isEpic && done(epic.Status):
+			default:
+				node.External = append(node.External, dep)
+			}
+		}
+	}
+	return g
+}
+
+// inCycle reports whether following an epic's parents leads back to it,
+// in which case it can't be nested
+func inCycle(parents map[string]string, id string) bool {
+	seen := make(map[string]bool)
+	for parent := parents[id]; parent != ""; parent = parents[parent] {
+		if parent == id {
+			return true
+		}
+		if seen[parent] {
+			return false
+		}
+		seen[parent] = true
+	}
+	return false
+}
+
+// Node returns an issue by ID, or nil
+func (g *Graph) Node(id string) *Node {
+	return g.byID[id]
+}
+
+// done reports whether a status needs no more work
+func done(status string) bool {
+	return status == "closed" || status == "tombstone"
+}
+
+// CriticalPath returns the longest chain of unfinished issues, each
+// depending on the one before, in the order they can be worked on. Closed
+// issues are left out, and cycles are broken where they're found.
+func (g *Graph) CriticalPath() []string {
+	path, _ := g.LongestPath(Count)
+	return path
+}
+
+// onPath returns the nodes and edges along a path
+func onPath(path []string) (map[string]bool, map[Edge]bool) {
+	nodes := make(map[string]bool)
+	edges := make(map[Edge]bool)
+	for i, id := range path {
+		nodes[id] = true
+		if i > 0 {
+			edges[Edge{From: path[i-1], To: id}] = true
+		}
+	}
+	return nodes, edges
+}
+
+// statusColors are the fill colors for each status
+var statusColors = map[string]string{
+	"open":        "#ffffff",
+	"in_progress": "#fff3b0",
+	"blocked":     "#f8b4b4",
+	"closed":      "#c6f0c2",
+	"tombstone":   "#d9d9d9",
+}
+
+// statusColor returns the fill color for a status
+func statusColor(status string) string {
+	if color, ok := statusColors[status]; ok {
+		return color
+	}
+	return statusColors["open"]
+}
+
+// highlightColor outlines the critical path
+const highlightColor = "#d62728"
+
+// maxTitle is the longest title shown in a node before it's shortened
+const maxTitle = 40
+
+// shorten truncates long titles so nodes stay readable
+func shorten(title string) string {
+	runes := []rune(title)
+	if len(runes) <= maxTitle {
+		return title
+	}
+	return string(runes[:maxTitle-1]) + "…"
+}
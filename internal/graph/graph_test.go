@@ -0,0 +1,153 @@
+package graph
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+)
+
+func testExport() *beadspb.Export {
+	return &beadspb.Export{
+		Issues: []*beadspb.Issue{
+			{Id: "proj-2", Title: "Schema", Status: beadspb.Status_STATUS_CLOSED, Epic: "proj-1"},
+			{Id: "proj-3", Title: "API", Status: beadspb.Status_STATUS_IN_PROGRESS, Epic: "proj-1", DependsOn: []string{"proj-2"}},
+			{Id: "proj-4", Title: `Login "form"`, Status: beadspb.Status_STATUS_OPEN, Epic: "proj-5", DependsOn: []string{"proj-3", "other-1"}},
+			{Id: "proj-6", Title: "Docs", Status: beadspb.Status_STATUS_BLOCKED, DependsOn: []string{"proj-4"}},
+			{Id: "proj-7", Title: "Cleanup", Status: beadspb.Status_STATUS_OPEN},
+		},
+		Epics: []*beadspb.Epic{
+			{Id: "proj-1", Name: "Backend", Status: beadspb.Status_STATUS_OPEN},
+			{Id: "proj-5", Name: "Frontend", Status: beadspb.Status_STATUS_OPEN, Parent: "proj-1"},
+		},
+	}
+}
+
+func TestNew(t *testing.T) {
+	g := New(testExport())
+
+	if len(g.Clusters) != 1 || g.Clusters[0].ID != "proj-1" {
+		t.Fatalf("Expected proj-1 as the only top-level epic, got %+v", g.Clusters)
+	}
+	if children := g.Clusters[0].Children; len(children) != 1 || children[0].ID != "proj-5" {
+		t.Errorf("Expected proj-5 nested under proj-1, got %+v", children)
+	}
+	if len(g.Loose) != 2 {
+		t.Errorf("Expected 2 issues outside epics, got %d", len(g.Loose))
+	}
+	// The dependency on other-1 isn't in the export
+	want := []Edge{{"proj-2", "proj-3"}, {"proj-3", "proj-4"}, {"proj-4", "proj-6"}}
+	if !reflect.DeepEqual(g.Edges, want) {
+		t.Errorf("Expected edges %v, got %v", want, g.Edges)
+	}
+}
+
+func TestNewEpicCycle(t *testing.T) {
+	g := New(&beadspb.Export{Epics: []*beadspb.Epic{
+		{Id: "a", Parent: "b"},
+		{Id: "b", Parent: "a"},
+	}})
+	if len(g.Clusters) != 2 {
+		t.Errorf("Expected epics in a cycle at the top level, got %+v", g.Clusters)
+	}
+}
+
+func TestCriticalPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		export *beadspb.Export
+		want   []string
+	}{
+		{
+			name:   "closed issues are left out",
+			export: testExport(),
+			want:   []string{"proj-3", "proj-4", "proj-6"},
+		},
+		{
+			name: "cycle",
+			export: &beadspb.Export{Issues: []*beadspb.Issue{
+				{Id: "a", DependsOn: []string{"b"}},
+				{Id: "b", DependsOn: []string{"a"}},
+			}},
+			want: []string{"b", "a"},
+		},
+		{
+			name: "all closed",
+			export: &beadspb.Export{Issues: []*beadspb.Issue{
+				{Id: "a", Status: beadspb.Status_STATUS_CLOSED},
+			}},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.export).CriticalPath(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	g := New(testExport())
+	var out bytes.Buffer
+	if err := WriteDOT(&out, g, Options{Highlight: g.CriticalPath()}); err != nil {
+		t.Fatalf("WriteDOT failed: %v", err)
+	}
+	dot := out.String()
+
+	for _, want := range []string{
+		"digraph beads {",
+		`subgraph "cluster_proj-1" {`,
+		`    subgraph "cluster_proj-5" {`,
+		`      "proj-4" [label="proj-4\nLogin \"form\"", fillcolor="#ffffff", color="#d62728", penwidth=3];`,
+		`    "proj-2" [label="proj-2\nSchema", fillcolor="#c6f0c2"];`,
+		`  "proj-7" [label="proj-7\nCleanup", fillcolor="#ffffff"];`,
+		`  "proj-2" -> "proj-3";`,
+		`  "proj-3" -> "proj-4" [color="#d62728", penwidth=3];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("Expected DOT to contain %q, got:\n%s", want, dot)
+		}
+	}
+}
+
+func TestWriteMermaid(t *testing.T) {
+	g := New(testExport())
+	var out bytes.Buffer
+	if err := WriteMermaid(&out, g, Options{Highlight: g.CriticalPath()}); err != nil {
+		t.Fatalf("WriteMermaid failed: %v", err)
+	}
+	mermaid := out.String()
+
+	for _, want := range []string{
+		"flowchart LR\n",
+		`  subgraph epic_proj_1["proj-1: Backend"]`,
+		`    subgraph epic_proj_5["proj-5: Frontend"]`,
+		`      proj_4["proj-4<br/>Login #quot;form#quot;"]:::status_open`,
+		`  proj_6["proj-6<br/>Docs"]:::status_blocked`,
+		"  proj_2 --> proj_3\n",
+		"  classDef status_in_progress fill:#fff3b0",
+		"  style proj_3 stroke:#d62728,stroke-width:3px",
+		"  linkStyle 1,2 stroke:#d62728,stroke-width:3px",
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Expected Mermaid to contain %q, got:\n%s", want, mermaid)
+		}
+	}
+	if strings.Contains(mermaid, "style proj_2 ") {
+		t.Errorf("Expected closed proj-2 off the critical path, got:\n%s", mermaid)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("Mermaid"); err != nil || f != FormatMermaid {
+		t.Errorf("Expected mermaid, got %q, %v", f, err)
+	}
+	if _, err := ParseFormat("svg"); err == nil {
+		t.Error("Expected an error for svg")
+	}
+}
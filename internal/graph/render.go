@@ -0,0 +1,195 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Format is an output format for a graph
+type Format string
+
+// Formats
+const (
+	FormatDOT     Format = "dot"
+	FormatMermaid Format = "mermaid"
+)
+
+// ParseFormat parses a format name
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatDOT, FormatMermaid:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid graph format %q (use dot or mermaid)", s)
+	}
+}
+
+// Options controls how a graph is drawn
+type Options struct {
+	// Highlight is a path of issue IDs, such as the critical path, drawn
+	// with a red outline
+	Highlight []string
+}
+
+// Write writes the graph in a format
+func Write(w io.Writer, g *Graph, format Format, opts Options) error {
+	switch format {
+	case FormatMermaid:
+		return WriteMermaid(w, g, opts)
+	default:
+		return WriteDOT(w, g, opts)
+	}
+}
+
+// WriteDOT writes the graph as a Graphviz digraph, each epic a cluster and
+// each issue filled with the color of its status
+func WriteDOT(w io.Writer, g *Graph, opts Options) error {
+	highlightNodes, highlightEdges := onPath(opts.Highlight)
+
+	var b strings.Builder
+	b.WriteString("digraph beads {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [color=\"#555555\"];\n")
+
+	writeNode := func(node *Node, indent string) {
+		attrs := fmt.Sprintf("label=%s, fillcolor=%q", dotQuote(node.ID+"\n"+shorten(node.Title)), statusColor(node.Status))
+		if highlightNodes[node.ID] {
+			attrs += fmt.Sprintf(", color=%q, penwidth=3", highlightColor)
+		}
+		fmt.Fprintf(&b, "%s%s [%s];\n", indent, dotQuote(node.ID), attrs)
+	}
+
+	var writeCluster func(c *Cluster, indent string)
+	writeCluster = func(c *Cluster, indent string) {
+		fmt.Fprintf(&b, "%ssubgraph %s {\n", indent, dotQuote("cluster_"+c.ID))
+		fmt.Fprintf(&b, "%s  label=%s;\n", indent, dotQuote(c.ID+": "+shorten(c.Name)))
+		fmt.Fprintf(&b, "%s  style=\"rounded,filled\";\n", indent)
+		fmt.Fprintf(&b, "%s  fillcolor=%q;\n", indent, clusterColor(c.Status))
+		for _, child := range c.Children {
+			writeCluster(child, indent+"  ")
+		}
+		for _, node := range c.Nodes {
+			writeNode(node, indent+"  ")
+		}
+		fmt.Fprintf(&b, "%s}\n", indent)
+	}
+
+	for _, c := range g.Clusters {
+		writeCluster(c, "  ")
+	}
+	for _, node := range g.Loose {
+		writeNode(node, "  ")
+	}
+	for _, edge := range g.Edges {
+		attrs := ""
+		if highlightEdges[edge] {
+			attrs = fmt.Sprintf(" [color=%q, penwidth=3]", highlightColor)
+		}
+		fmt.Fprintf(&b, "  %s -> %s%s;\n", dotQuote(edge.From), dotQuote(edge.To), attrs)
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote quotes a DOT ID or label
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// clusterColor is a lighter shade for epics, so their issues stand out
+func clusterColor(status string) string {
+	if status == "closed" || status == "tombstone" {
+		return "#f0f0f0"
+	}
+	return "#f5f8ff"
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart, each epic a
+// subgraph and each issue styled with a class for its status
+func WriteMermaid(w io.Writer, g *Graph, opts Options) error {
+	highlightNodes, highlightEdges := onPath(opts.Highlight)
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	writeNode := func(node *Node, indent string) {
+		fmt.Fprintf(&b, "%s%s[\"%s<br/>%s\"]:::%s\n", indent, mermaidID(node.ID),
+			mermaidEscape(node.ID), mermaidEscape(shorten(node.Title)), mermaidClass(node.Status))
+	}
+
+	var writeCluster func(c *Cluster, indent string)
+	writeCluster = func(c *Cluster, indent string) {
+		fmt.Fprintf(&b, "%ssubgraph %s[\"%s: %s\"]\n", indent, mermaidID("epic_"+c.ID),
+			mermaidEscape(c.ID), mermaidEscape(shorten(c.Name)))
+		for _, child := range c.Children {
+			writeCluster(child, indent+"  ")
+		}
+		for _, node := range c.Nodes {
+			writeNode(node, indent+"  ")
+		}
+		fmt.Fprintf(&b, "%send\n", indent)
+	}
+
+	for _, c := range g.Clusters {
+		writeCluster(c, "  ")
+	}
+	for _, node := range g.Loose {
+		writeNode(node, "  ")
+	}
+
+	var highlighted []string
+	for i, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s --> %s\n", mermaidID(edge.From), mermaidID(edge.To))
+		if highlightEdges[edge] {
+			highlighted = append(highlighted, fmt.Sprint(i))
+		}
+	}
+
+	for _, status := range []string{"open", "in_progress", "blocked", "closed", "tombstone"} {
+		fmt.Fprintf(&b, "  classDef %s fill:%s,stroke:#555555\n", mermaidClass(status), statusColor(status))
+	}
+	for _, node := range g.Nodes {
+		if highlightNodes[node.ID] {
+			fmt.Fprintf(&b, "  style %s stroke:%s,stroke-width:3px\n", mermaidID(node.ID), highlightColor)
+		}
+	}
+	if len(highlighted) > 0 {
+		fmt.Fprintf(&b, "  linkStyle %s stroke:%s,stroke-width:3px\n", strings.Join(highlighted, ","), highlightColor)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidUnsafe matches characters not allowed in Mermaid node IDs
+var mermaidUnsafe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// mermaidID turns a beads ID into a Mermaid node ID
+func mermaidID(id string) string {
+	return mermaidUnsafe.ReplaceAllString(id, "_")
+}
+
+// mermaidClass names the class for a status; "end" and other keywords are
+// avoided by the prefix
+func mermaidClass(status string) string {
+	if _, ok := statusColors[status]; !ok {
+		status = "open"
+	}
+	return "status_" + status
+}
+
+// mermaidEscape escapes text for a quoted Mermaid label
+func mermaidEscape(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	s = strings.ReplaceAll(s, "<", "#lt;")
+	s = strings.ReplaceAll(s, ">", "#gt;")
+	return s
+}
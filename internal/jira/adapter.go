@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	pb "github.com/conallob/jira-beads-sync/gen/jira"
@@ -11,7 +12,14 @@ import (
 )
 
 // Adapter handles converting JSON Jira exports to protobuf format
-type Adapter struct{}
+type Adapter struct {
+	// ParentLinkField is the custom field holding the Advanced Roadmaps
+	// Parent Link, used as the parent when the parent field is unset
+	ParentLinkField string
+	// StoryPointsField is the custom field holding story points, which
+	// varies by site (e.g., "customfield_10016")
+	StoryPointsField string
+}
 
 // NewAdapter creates a new Jira JSON to protobuf adapter
 func NewAdapter() *Adapter {
@@ -54,6 +62,27 @@ func (a *Adapter) Parse(data []byte) (*pb.Export, error) {
 	return export, nil
 }
 
+// ParseIssue parses a single issue in REST API format, such as the issue in
+// a webhook payload
+func (a *Adapter) ParseIssue(data []byte) (*pb.Issue, error) {
+	var jsonIssue jsonIssue
+	if err := json.Unmarshal(data, &jsonIssue); err != nil {
+		return nil, fmt.Errorf("failed to parse Jira issue: %w", err)
+	}
+
+	issue, err := a.convertIssue(&jsonIssue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert issue %s: %w", jsonIssue.Key, err)
+	}
+
+	export := &pb.Export{Issues: []*pb.Issue{issue}}
+	if err := a.validate(export); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	return issue, nil
+}
+
 // validate checks if the parsed export is valid
 func (a *Adapter) validate(export *pb.Export) error {
 	if export == nil {
@@ -89,9 +118,10 @@ func (a *Adapter) convertIssue(jsonIssue *jsonIssue) (*pb.Issue, error) {
 			Summary:     jsonIssue.Fields.Summary,
 			Description: jsonIssue.Fields.Description,
 			IssueType: &pb.IssueType{
-				Name:        jsonIssue.Fields.IssueType.Name,
-				Description: jsonIssue.Fields.IssueType.Description,
-				Subtask:     jsonIssue.Fields.IssueType.Subtask,
+				Name:           jsonIssue.Fields.IssueType.Name,
+				Description:    jsonIssue.Fields.IssueType.Description,
+				Subtask:        jsonIssue.Fields.IssueType.Subtask,
+				HierarchyLevel: jsonIssue.Fields.IssueType.HierarchyLevel,
 			},
 			Status: &pb.Status{
 				Name: jsonIssue.Fields.Status.Name,
@@ -144,6 +174,14 @@ func (a *Adapter) convertIssue(jsonIssue *jsonIssue) (*pb.Issue, error) {
 	// Convert parent
 	if jsonIssue.Fields.Parent != nil {
 		issue.Fields.Parent = a.convertParent(jsonIssue.Fields.Parent)
+	} else if a.ParentLinkField != "" {
+		if key := parentLinkKey(jsonIssue.Fields.Custom[a.ParentLinkField]); key != "" {
+			// The Parent Link only identifies the parent, not its type
+			issue.Fields.Parent = &pb.Parent{
+				Key:    key,
+				Fields: &pb.LinkedFields{IssueType: &pb.IssueType{}},
+			}
+		}
 	}
 
 	// Convert epic
@@ -158,6 +196,39 @@ func (a *Adapter) convertIssue(jsonIssue *jsonIssue) (*pb.Issue, error) {
 		}
 	}
 
+	// Convert story points
+	if a.StoryPointsField != "" {
+		issue.Fields.StoryPoints = storyPoints(jsonIssue.Fields.Custom[a.StoryPointsField])
+	}
+
+	// Convert time tracking and worklogs
+	issue.Fields.TimeTracking = a.convertTimeTracking(&jsonIssue.Fields)
+	if jsonIssue.Fields.Worklog != nil {
+		for _, worklog := range jsonIssue.Fields.Worklog.Worklogs {
+			pbWorklog, err := a.convertWorklog(&worklog)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert worklog %s: %w", worklog.ID, err)
+			}
+			issue.Fields.Worklogs = append(issue.Fields.Worklogs, pbWorklog)
+		}
+	}
+
+	// Convert sprints
+	if jsonIssue.Fields.Sprint != nil {
+		sprint, err := a.convertSprint(jsonIssue.Fields.Sprint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert sprint %d: %w", jsonIssue.Fields.Sprint.ID, err)
+		}
+		issue.Fields.Sprint = sprint
+	}
+	for _, closed := range jsonIssue.Fields.ClosedSprints {
+		sprint, err := a.convertSprint(&closed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert sprint %d: %w", closed.ID, err)
+		}
+		issue.Fields.ClosedSprints = append(issue.Fields.ClosedSprints, sprint)
+	}
+
 	// Convert subtasks
 	for i, subtask := range jsonIssue.Fields.Subtasks {
 		issue.Fields.Subtasks[i] = &pb.Subtask{
@@ -174,9 +245,10 @@ func (a *Adapter) convertIssue(jsonIssue *jsonIssue) (*pb.Issue, error) {
 					},
 				},
 				IssueType: &pb.IssueType{
-					Name:        subtask.Fields.IssueType.Name,
-					Description: subtask.Fields.IssueType.Description,
-					Subtask:     subtask.Fields.IssueType.Subtask,
+					Name:           subtask.Fields.IssueType.Name,
+					Description:    subtask.Fields.IssueType.Description,
+					Subtask:        subtask.Fields.IssueType.Subtask,
+					HierarchyLevel: subtask.Fields.IssueType.HierarchyLevel,
 				},
 			},
 		}
@@ -245,6 +317,100 @@ func (a *Adapter) convertIssueLink(link *jsonIssueLink) *pb.IssueLink {
 	return pbLink
 }
 
+// convertTimeTracking converts Jira time tracking fields to protobuf.
+// The "timetracking" object is preferred; the flat timeoriginalestimate,
+// timeestimate and timespent fields are used when it is absent (as in
+// search results that don't request it). Returns nil if no time is tracked.
+func (a *Adapter) convertTimeTracking(fields *jsonFields) *pb.TimeTracking {
+	tt := &pb.TimeTracking{}
+
+	if fields.TimeTracking != nil {
+		tt.OriginalEstimate = fields.TimeTracking.OriginalEstimate
+		tt.RemainingEstimate = fields.TimeTracking.RemainingEstimate
+		tt.TimeSpent = fields.TimeTracking.TimeSpent
+		tt.OriginalEstimateSeconds = fields.TimeTracking.OriginalEstimateSeconds
+		tt.RemainingEstimateSeconds = fields.TimeTracking.RemainingEstimateSeconds
+		tt.TimeSpentSeconds = fields.TimeTracking.TimeSpentSeconds
+	}
+
+	if tt.OriginalEstimateSeconds == 0 && fields.TimeOriginalEstimate != nil {
+		tt.OriginalEstimateSeconds = *fields.TimeOriginalEstimate
+	}
+	if tt.RemainingEstimateSeconds == 0 && fields.TimeEstimate != nil {
+		tt.RemainingEstimateSeconds = *fields.TimeEstimate
+	}
+	if tt.TimeSpentSeconds == 0 && fields.TimeSpent != nil {
+		tt.TimeSpentSeconds = *fields.TimeSpent
+	}
+
+	if tt.OriginalEstimateSeconds == 0 && tt.RemainingEstimateSeconds == 0 && tt.TimeSpentSeconds == 0 {
+		return nil
+	}
+
+	return tt
+}
+
+// convertWorklog converts a JSON worklog entry to protobuf
+func (a *Adapter) convertWorklog(worklog *jsonWorklog) (*pb.Worklog, error) {
+	pbWorklog := &pb.Worklog{
+		Id:               worklog.ID,
+		Comment:          worklog.Comment,
+		TimeSpent:        worklog.TimeSpent,
+		TimeSpentSeconds: worklog.TimeSpentSeconds,
+	}
+
+	if worklog.Author != nil {
+		pbWorklog.Author = &pb.User{
+			AccountId:    worklog.Author.AccountID,
+			DisplayName:  worklog.Author.DisplayName,
+			EmailAddress: worklog.Author.EmailAddress,
+		}
+	}
+
+	if worklog.Started != "" {
+		t, err := time.Parse("2006-01-02T15:04:05.000-0700", worklog.Started)
+		if err != nil {
+			return nil, err
+		}
+		pbWorklog.Started = timestamppb.New(t)
+	}
+
+	return pbWorklog, nil
+}
+
+// convertSprint converts a Jira Agile sprint to protobuf. Agile API dates
+// are RFC 3339, unlike the platform API's timestamps.
+func (a *Adapter) convertSprint(sprint *jsonSprint) (*pb.Sprint, error) {
+	pbSprint := &pb.Sprint{
+		Id:            sprint.ID,
+		Name:          sprint.Name,
+		State:         sprint.State,
+		OriginBoardId: sprint.OriginBoardID,
+		Goal:          sprint.Goal,
+	}
+
+	dates := []struct {
+		value  string
+		target **timestamppb.Timestamp
+	}{
+		{sprint.StartDate, &pbSprint.StartDate},
+		{sprint.EndDate, &pbSprint.EndDate},
+		{sprint.CompleteDate, &pbSprint.CompleteDate},
+	}
+	for _, d := range dates {
+		if d.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, d.value)
+		if err != nil {
+			return nil, err
+		}
+		*d.target = timestamppb.New(t)
+	}
+
+	return pbSprint, nil
+}
+
 // convertParent converts a JSON parent to protobuf
 func (a *Adapter) convertParent(parent *jsonParent) *pb.Parent {
 	return &pb.Parent{
@@ -261,14 +427,52 @@ func (a *Adapter) convertParent(parent *jsonParent) *pb.Parent {
 				},
 			},
 			IssueType: &pb.IssueType{
-				Name:        parent.Fields.IssueType.Name,
-				Description: parent.Fields.IssueType.Description,
-				Subtask:     parent.Fields.IssueType.Subtask,
+				Name:           parent.Fields.IssueType.Name,
+				Description:    parent.Fields.IssueType.Description,
+				Subtask:        parent.Fields.IssueType.Subtask,
+				HierarchyLevel: parent.Fields.IssueType.HierarchyLevel,
 			},
 		},
 	}
 }
 
+// storyPoints reads a story points value, which is a number or null when
+// the issue isn't estimated
+func storyPoints(raw json.RawMessage) float64 {
+	var points float64
+	if err := json.Unmarshal(raw, &points); err != nil {
+		return 0
+	}
+	return points
+}
+
+// parentLinkKey extracts the parent key from an Advanced Roadmaps Parent Link
+// value, which is a plain key or an object holding one, depending on version
+func parentLinkKey(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var key string
+	if err := json.Unmarshal(raw, &key); err == nil {
+		return key
+	}
+
+	var link struct {
+		Key  string `json:"key"`
+		Data *struct {
+			Key string `json:"key"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &link); err != nil {
+		return ""
+	}
+	if link.Key == "" && link.Data != nil {
+		return link.Data.Key
+	}
+	return link.Key
+}
+
 // JSON types for unmarshaling (kept internal)
 type jsonExport struct {
 	Issues []jsonIssue `json:"issues"`
@@ -296,12 +500,26 @@ type jsonFields struct {
 	Parent      *jsonParent     `json:"parent,omitempty"`
 	Epic        *jsonEpic       `json:"epic,omitempty"`
 	Subtasks    []jsonSubtask   `json:"subtasks"`
+
+	TimeTracking         *jsonTimeTracking `json:"timetracking,omitempty"`
+	TimeOriginalEstimate *int64            `json:"timeoriginalestimate,omitempty"`
+	TimeEstimate         *int64            `json:"timeestimate,omitempty"`
+	TimeSpent            *int64            `json:"timespent,omitempty"`
+	Worklog              *jsonWorklogPage  `json:"worklog,omitempty"`
+
+	// Only present in Agile API responses
+	Sprint        *jsonSprint  `json:"sprint,omitempty"`
+	ClosedSprints []jsonSprint `json:"closedSprints,omitempty"`
+
+	// Custom holds the raw customfield_* values
+	Custom map[string]json.RawMessage `json:"-"`
 }
 
 type jsonIssueType struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Subtask     bool   `json:"subtask"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	Subtask        bool   `json:"subtask"`
+	HierarchyLevel int32  `json:"hierarchyLevel"`
 }
 
 type jsonStatus struct {
@@ -374,6 +592,42 @@ type jsonSubtask struct {
 	Fields jsonLinkedFields `json:"fields"`
 }
 
+type jsonTimeTracking struct {
+	OriginalEstimate         string `json:"originalEstimate"`
+	RemainingEstimate        string `json:"remainingEstimate"`
+	TimeSpent                string `json:"timeSpent"`
+	OriginalEstimateSeconds  int64  `json:"originalEstimateSeconds"`
+	RemainingEstimateSeconds int64  `json:"remainingEstimateSeconds"`
+	TimeSpentSeconds         int64  `json:"timeSpentSeconds"`
+}
+
+type jsonWorklogPage struct {
+	StartAt    int           `json:"startAt"`
+	MaxResults int           `json:"maxResults"`
+	Total      int           `json:"total"`
+	Worklogs   []jsonWorklog `json:"worklogs"`
+}
+
+type jsonWorklog struct {
+	ID               string    `json:"id"`
+	Author           *jsonUser `json:"author,omitempty"`
+	Comment          string    `json:"comment"`
+	Started          string    `json:"started"`
+	TimeSpent        string    `json:"timeSpent"`
+	TimeSpentSeconds int64     `json:"timeSpentSeconds"`
+}
+
+type jsonSprint struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	State         string `json:"state"`
+	StartDate     string `json:"startDate,omitempty"`
+	EndDate       string `json:"endDate,omitempty"`
+	CompleteDate  string `json:"completeDate,omitempty"`
+	OriginBoardID int64  `json:"originBoardId,omitempty"`
+	Goal          string `json:"goal,omitempty"`
+}
+
 // UnmarshalJSON implements custom JSON unmarshaling for timestamps
 func (jf *jsonFields) UnmarshalJSON(b []byte) error {
 	type Alias jsonFields
@@ -406,5 +660,19 @@ func (jf *jsonFields) UnmarshalJSON(b []byte) error {
 		jf.Updated = t
 	}
 
+	// Keep custom fields raw, since their IDs vary by site
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return err
+	}
+	for name, value := range all {
+		if strings.HasPrefix(name, "customfield_") {
+			if jf.Custom == nil {
+				jf.Custom = make(map[string]json.RawMessage)
+			}
+			jf.Custom[name] = value
+		}
+	}
+
 	return nil
 }
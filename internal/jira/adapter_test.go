@@ -207,3 +207,242 @@ func TestAdapterConvertParent(t *testing.T) {
 		}
 	}
 }
+
+func TestAdapterConvertTimeTracking(t *testing.T) {
+	data := []byte(`{
+		"issues": [
+			{
+				"key": "PROJ-1",
+				"id": "10001",
+				"fields": {
+					"summary": "Tracked issue",
+					"issuetype": {"name": "Story"},
+					"timetracking": {
+						"originalEstimate": "1d",
+						"remainingEstimate": "4h",
+						"timeSpent": "4h",
+						"originalEstimateSeconds": 28800,
+						"remainingEstimateSeconds": 14400,
+						"timeSpentSeconds": 14400
+					},
+					"worklog": {
+						"startAt": 0,
+						"maxResults": 20,
+						"total": 1,
+						"worklogs": [
+							{
+								"id": "100",
+								"author": {"accountId": "abc", "displayName": "Jane"},
+								"comment": "Initial spike",
+								"started": "2024-01-02T09:00:00.000+0000",
+								"timeSpent": "4h",
+								"timeSpentSeconds": 14400
+							}
+						]
+					}
+				}
+			},
+			{
+				"key": "PROJ-2",
+				"id": "10002",
+				"fields": {
+					"summary": "Flat fields only",
+					"issuetype": {"name": "Task"},
+					"timeoriginalestimate": 7200,
+					"timeestimate": 3600,
+					"timespent": 3600
+				}
+			},
+			{
+				"key": "PROJ-3",
+				"id": "10003",
+				"fields": {
+					"summary": "Untracked",
+					"issuetype": {"name": "Task"},
+					"timeoriginalestimate": null
+				}
+			}
+		]
+	}`)
+
+	adapter := NewAdapter()
+	export, err := adapter.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tracked := export.Issues[0].Fields
+	if tracked.TimeTracking == nil {
+		t.Fatal("Expected time tracking on PROJ-1")
+	}
+	if tracked.TimeTracking.OriginalEstimate != "1d" {
+		t.Errorf("Expected original estimate '1d', got %q", tracked.TimeTracking.OriginalEstimate)
+	}
+	if tracked.TimeTracking.OriginalEstimateSeconds != 28800 {
+		t.Errorf("Expected 28800 estimate seconds, got %d", tracked.TimeTracking.OriginalEstimateSeconds)
+	}
+	if tracked.TimeTracking.TimeSpentSeconds != 14400 {
+		t.Errorf("Expected 14400 spent seconds, got %d", tracked.TimeTracking.TimeSpentSeconds)
+	}
+	if len(tracked.Worklogs) != 1 {
+		t.Fatalf("Expected 1 worklog, got %d", len(tracked.Worklogs))
+	}
+	worklog := tracked.Worklogs[0]
+	if worklog.Id != "100" || worklog.Comment != "Initial spike" || worklog.TimeSpentSeconds != 14400 {
+		t.Errorf("Unexpected worklog: %+v", worklog)
+	}
+	if worklog.Author == nil || worklog.Author.DisplayName != "Jane" {
+		t.Error("Expected worklog author Jane")
+	}
+	if worklog.Started == nil || worklog.Started.AsTime().Hour() != 9 {
+		t.Error("Expected worklog start time to be parsed")
+	}
+
+	flat := export.Issues[1].Fields.TimeTracking
+	if flat == nil {
+		t.Fatal("Expected time tracking on PROJ-2 from flat fields")
+	}
+	if flat.OriginalEstimateSeconds != 7200 || flat.RemainingEstimateSeconds != 3600 || flat.TimeSpentSeconds != 3600 {
+		t.Errorf("Unexpected flat time tracking: %+v", flat)
+	}
+
+	if export.Issues[2].Fields.TimeTracking != nil {
+		t.Error("Expected no time tracking on PROJ-3")
+	}
+}
+
+func TestAdapterConvertSprints(t *testing.T) {
+	data := []byte(`{
+		"issues": [
+			{
+				"key": "PROJ-1",
+				"id": "10001",
+				"fields": {
+					"summary": "Carried over",
+					"issuetype": {"name": "Story"},
+					"sprint": {
+						"id": 8,
+						"name": "Sprint 8",
+						"state": "active",
+						"startDate": "2024-03-15T09:00:00.000Z",
+						"endDate": "2024-03-29T17:00:00.000Z",
+						"originBoardId": 42
+					},
+					"closedSprints": [
+						{
+							"id": 7,
+							"name": "Sprint 7",
+							"state": "closed",
+							"completeDate": "2024-03-15T08:00:00.000Z"
+						}
+					]
+				}
+			}
+		]
+	}`)
+
+	adapter := NewAdapter()
+	export, err := adapter.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	fields := export.Issues[0].Fields
+	if fields.Sprint == nil {
+		t.Fatal("Expected sprint to be converted")
+	}
+	if fields.Sprint.Name != "Sprint 8" || fields.Sprint.State != "active" || fields.Sprint.OriginBoardId != 42 {
+		t.Errorf("Unexpected sprint: %+v", fields.Sprint)
+	}
+	if fields.Sprint.StartDate == nil || fields.Sprint.EndDate == nil {
+		t.Error("Expected sprint dates to be converted")
+	}
+	if len(fields.ClosedSprints) != 1 || fields.ClosedSprints[0].CompleteDate == nil {
+		t.Errorf("Expected one closed sprint with a complete date, got %v", fields.ClosedSprints)
+	}
+}
+
+func TestAdapterConvertStoryPoints(t *testing.T) {
+	data := []byte(`{
+		"issues": [
+			{"key": "PROJ-1", "id": "10001", "fields": {"summary": "Estimated", "issuetype": {"name": "Story"}, "customfield_10016": 5.5}},
+			{"key": "PROJ-2", "id": "10002", "fields": {"summary": "Not estimated", "issuetype": {"name": "Story"}, "customfield_10016": null}}
+		]
+	}`)
+
+	adapter := NewAdapter()
+	adapter.StoryPointsField = "customfield_10016"
+	export, err := adapter.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if points := export.Issues[0].Fields.StoryPoints; points != 5.5 {
+		t.Errorf("Expected 5.5 story points, got %v", points)
+	}
+	if points := export.Issues[1].Fields.StoryPoints; points != 0 {
+		t.Errorf("Expected no story points, got %v", points)
+	}
+}
+
+func TestAdapterConvertParentLink(t *testing.T) {
+	data := []byte(`{
+		"issues": [
+			{
+				"key": "PROJ-1",
+				"id": "10001",
+				"fields": {
+					"summary": "Epic under an initiative",
+					"issuetype": {"name": "Epic", "hierarchyLevel": 1},
+					"customfield_10500": "INIT-1"
+				}
+			},
+			{
+				"key": "PROJ-2",
+				"id": "10002",
+				"fields": {
+					"summary": "Epic with a Parent Link object",
+					"issuetype": {"name": "Epic", "hierarchyLevel": 1},
+					"customfield_10500": {"data": {"id": 5, "key": "INIT-2"}}
+				}
+			},
+			{
+				"key": "PROJ-3",
+				"id": "10003",
+				"fields": {
+					"summary": "Parent field wins",
+					"issuetype": {"name": "Story"},
+					"parent": {"key": "PROJ-1", "fields": {"issuetype": {"name": "Epic"}}},
+					"customfield_10500": "INIT-1"
+				}
+			}
+		]
+	}`)
+
+	adapter := NewAdapter()
+	adapter.ParentLinkField = "customfield_10500"
+	export, err := adapter.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if level := export.Issues[0].Fields.IssueType.HierarchyLevel; level != 1 {
+		t.Errorf("Expected hierarchy level 1, got %d", level)
+	}
+
+	expected := []string{"INIT-1", "INIT-2", "PROJ-1"}
+	for i, want := range expected {
+		parent := export.Issues[i].Fields.Parent
+		if parent == nil || parent.Key != want {
+			t.Errorf("Expected %s to have parent %s, got %v", export.Issues[i].Key, want, parent)
+		}
+	}
+
+	// Without the field configured, the Parent Link is ignored
+	export, err = NewAdapter().Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if export.Issues[0].Fields.Parent != nil {
+		t.Errorf("Expected no parent without a Parent Link field, got %v", export.Issues[0].Fields.Parent)
+	}
+}
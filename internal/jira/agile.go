@@ -0,0 +1,217 @@
+package jira
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	pb "github.com/conallob/jira-beads-sync/gen/jira"
+)
+
+// Sprint states accepted by the Jira Agile API
+const (
+	SprintStateFuture = "future"
+	SprintStateActive = "active"
+	SprintStateClosed = "closed"
+)
+
+// GetBoardSprints returns the sprints of a board, optionally filtered by a
+// comma-separated list of states (e.g., "active" or "active,future")
+func (c *Client) GetBoardSprints(boardID int, state string) ([]*pb.Sprint, error) {
+	var sprints []*pb.Sprint
+
+	for startAt := 0; ; {
+		query := url.Values{}
+		query.Set("startAt", fmt.Sprintf("%d", startAt))
+		if state != "" {
+			query.Set("state", state)
+		}
+		apiURL := fmt.Sprintf("%s/rest/agile/1.0/board/%d/sprint?%s", c.baseURL, boardID, query.Encode())
+
+		req, err := http.NewRequest("GET", apiURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		var page struct {
+			MaxResults int          `json:"maxResults"`
+			StartAt    int          `json:"startAt"`
+			IsLast     bool         `json:"isLast"`
+			Values     []jsonSprint `json:"values"`
+		}
+		if err := c.doJSON(req, &page); err != nil {
+			return nil, fmt.Errorf("failed to list sprints for board %d: %w", boardID, err)
+		}
+
+		for i := range page.Values {
+			sprint, err := c.adapter.convertSprint(&page.Values[i])
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert sprint %d: %w", page.Values[i].ID, err)
+			}
+			sprints = append(sprints, sprint)
+		}
+
+		startAt += len(page.Values)
+		if page.IsLast || len(page.Values) == 0 {
+			return sprints, nil
+		}
+	}
+}
+
+// GetSprint fetches a single sprint by ID
+func (c *Client) GetSprint(sprintID int64) (*pb.Sprint, error) {
+	apiURL := fmt.Sprintf("%s/rest/agile/1.0/sprint/%d", c.baseURL, sprintID)
+
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var sprint jsonSprint
+	if err := c.doJSON(req, &sprint); err != nil {
+		return nil, fmt.Errorf("failed to fetch sprint %d: %w", sprintID, err)
+	}
+
+	return c.adapter.convertSprint(&sprint)
+}
+
+// GetActiveSprints returns the active sprints of a board. Boards with
+// parallel sprints enabled may have more than one.
+func (c *Client) GetActiveSprints(boardID int) ([]*pb.Sprint, error) {
+	sprints, err := c.GetBoardSprints(boardID, SprintStateActive)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sprints) == 0 {
+		return nil, fmt.Errorf("board %d has no active sprint", boardID)
+	}
+
+	return sprints, nil
+}
+
+// FindSprintByName returns the sprint of a board with the given name,
+// compared case-insensitively
+func (c *Client) FindSprintByName(boardID int, name string) (*pb.Sprint, error) {
+	sprints, err := c.GetBoardSprints(boardID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sprint := range sprints {
+		if strings.EqualFold(sprint.Name, name) {
+			return sprint, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no sprint named %q on board %d", name, boardID)
+}
+
+// GetSprintIssueKeys returns the keys of all issues in a sprint
+func (c *Client) GetSprintIssueKeys(sprintID int64) ([]string, error) {
+	apiURL := fmt.Sprintf("%s/rest/agile/1.0/sprint/%d/issue", c.baseURL, sprintID)
+	keys, err := c.agileIssueKeys(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issues in sprint %d: %w", sprintID, err)
+	}
+	return keys, nil
+}
+
+// GetBacklogIssueKeys returns the keys of all issues in a board's backlog
+func (c *Client) GetBacklogIssueKeys(boardID int) ([]string, error) {
+	apiURL := fmt.Sprintf("%s/rest/agile/1.0/board/%d/backlog", c.baseURL, boardID)
+	keys, err := c.agileIssueKeys(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backlog of board %d: %w", boardID, err)
+	}
+	return keys, nil
+}
+
+// agileIssueKeys pages through an Agile API issue listing and returns the keys
+func (c *Client) agileIssueKeys(apiURL string) ([]string, error) {
+	var keys []string
+
+	for startAt := 0; ; {
+		pageURL := fmt.Sprintf("%s?fields=key&startAt=%d", apiURL, startAt)
+
+		req, err := http.NewRequest("GET", pageURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		var page struct {
+			StartAt    int `json:"startAt"`
+			MaxResults int `json:"maxResults"`
+			Total      int `json:"total"`
+			Issues     []struct {
+				Key string `json:"key"`
+			} `json:"issues"`
+		}
+		if err := c.doJSON(req, &page); err != nil {
+			return nil, err
+		}
+
+		for _, issue := range page.Issues {
+			keys = append(keys, issue.Key)
+		}
+
+		startAt += len(page.Issues)
+		if len(page.Issues) == 0 || startAt >= page.Total {
+			return keys, nil
+		}
+	}
+}
+
+// FetchSprintIssues fetches every issue in a sprint and their dependencies.
+// Issues in the sprint are tagged with it, since the platform API used to
+// fetch them doesn't report sprints in a consistent field.
+func (c *Client) FetchSprintIssues(sprint *pb.Sprint) (*pb.Export, error) {
+	c.reporter.Info("Listing issues in sprint", "sprint", sprint.Name)
+
+	issueKeys, err := c.GetSprintIssueKeys(sprint.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(issueKeys) == 0 {
+		return nil, fmt.Errorf("sprint %q has no issues", sprint.Name)
+	}
+
+	c.reporter.Info("Found issues", "count", len(issueKeys), "sprint", sprint.Name)
+
+	export, err := c.fetchAll(issueKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	inSprint := make(map[string]bool, len(issueKeys))
+	for _, key := range issueKeys {
+		inSprint[key] = true
+	}
+	for _, issue := range export.Issues {
+		if inSprint[issue.Key] && issue.Fields.Sprint == nil {
+			issue.Fields.Sprint = sprint
+		}
+	}
+
+	return export, nil
+}
+
+// FetchBoardBacklog fetches every issue in a board's backlog and their dependencies
+func (c *Client) FetchBoardBacklog(boardID int) (*pb.Export, error) {
+	c.reporter.Info("Listing backlog", "board", boardID)
+
+	issueKeys, err := c.GetBacklogIssueKeys(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(issueKeys) == 0 {
+		return nil, fmt.Errorf("backlog of board %d is empty", boardID)
+	}
+
+	c.reporter.Info("Found issues", "count", len(issueKeys), "board", boardID)
+
+	return c.fetchAll(issueKeys)
+}
@@ -0,0 +1,267 @@
+package jira
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pb "github.com/conallob/jira-beads-sync/gen/jira"
+)
+
+func TestGetBoardSprints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/agile/1.0/board/42/sprint" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if state := r.URL.Query().Get("state"); state != "active" {
+			t.Errorf("Expected state=active, got %q", state)
+		}
+
+		var response map[string]interface{}
+		switch r.URL.Query().Get("startAt") {
+		case "0":
+			response = map[string]interface{}{
+				"isLast": false,
+				"values": []map[string]interface{}{
+					{
+						"id":            7,
+						"name":          "Sprint 7",
+						"state":         "active",
+						"startDate":     "2024-03-01T09:00:00.000+10:00",
+						"endDate":       "2024-03-15T17:00:00.000+10:00",
+						"originBoardId": 42,
+						"goal":          "Ship login",
+					},
+				},
+			}
+		case "1":
+			response = map[string]interface{}{
+				"isLast": true,
+				"values": []map[string]interface{}{
+					{"id": 8, "name": "Sprint 8", "state": "active"},
+				},
+			}
+		default:
+			t.Errorf("Unexpected startAt %s", r.URL.Query().Get("startAt"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Errorf("Failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	sprints, err := client.GetActiveSprints(42)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(sprints) != 2 {
+		t.Fatalf("Expected 2 sprints, got %d", len(sprints))
+	}
+
+	first := sprints[0]
+	if first.Id != 7 || first.Name != "Sprint 7" || first.State != "active" || first.Goal != "Ship login" {
+		t.Errorf("Unexpected sprint: %+v", first)
+	}
+	if first.OriginBoardId != 42 {
+		t.Errorf("Expected origin board 42, got %d", first.OriginBoardId)
+	}
+	if first.StartDate == nil || first.StartDate.AsTime().UTC().Hour() != 23 {
+		t.Errorf("Expected start date to be parsed with its offset, got %v", first.StartDate)
+	}
+	if first.EndDate == nil {
+		t.Error("Expected end date to be parsed")
+	}
+}
+
+func TestGetActiveSprintsNone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"isLast":true,"values":[]}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	if _, err := client.GetActiveSprints(42); err == nil {
+		t.Error("Expected error when board has no active sprint")
+	}
+}
+
+func TestFindSprintByName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("state") != "" {
+			t.Errorf("Expected no state filter, got %q", r.URL.Query().Get("state"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"isLast":true,"values":[
+			{"id":1,"name":"Sprint 22","state":"closed"},
+			{"id":2,"name":"Sprint 23","state":"active"}
+		]}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	sprint, err := client.FindSprintByName(42, "sprint 22")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if sprint.Id != 1 || sprint.State != "closed" {
+		t.Errorf("Unexpected sprint: %+v", sprint)
+	}
+
+	if _, err := client.FindSprintByName(42, "Sprint 99"); err == nil {
+		t.Error("Expected error for unknown sprint name")
+	}
+}
+
+func TestFetchSprintIssues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+
+		switch r.URL.Path {
+		case "/rest/agile/1.0/sprint/7/issue":
+			switch r.URL.Query().Get("startAt") {
+			case "0":
+				response = map[string]interface{}{
+					"startAt": 0, "maxResults": 1, "total": 2,
+					"issues": []map[string]interface{}{{"key": "PROJ-1"}},
+				}
+			case "1":
+				response = map[string]interface{}{
+					"startAt": 1, "maxResults": 1, "total": 2,
+					"issues": []map[string]interface{}{{"key": "PROJ-2"}},
+				}
+			}
+		case "/rest/api/2/issue/PROJ-1":
+			issue := createMinimalIssue("PROJ-1", "Issue PROJ-1")
+			issue["fields"].(map[string]interface{})["issuelinks"] = []map[string]interface{}{
+				{
+					"type":        map[string]interface{}{"name": "Blocks", "inward": "is blocked by"},
+					"inwardIssue": map[string]interface{}{"key": "PROJ-9"},
+				},
+			}
+			response = issue
+		case "/rest/api/2/issue/PROJ-2", "/rest/api/2/issue/PROJ-9":
+			key := r.URL.Path[len("/rest/api/2/issue/"):]
+			response = createMinimalIssue(key, "Issue "+key)
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Errorf("Failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	export, err := client.FetchSprintIssues(&pb.Sprint{Id: 7, Name: "Sprint 7", State: "active"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(export.Issues) != 3 {
+		t.Fatalf("Expected 3 issues (2 in sprint + 1 dependency), got %d", len(export.Issues))
+	}
+
+	for _, issue := range export.Issues {
+		inSprint := issue.Key != "PROJ-9"
+		if inSprint && (issue.Fields.Sprint == nil || issue.Fields.Sprint.Name != "Sprint 7") {
+			t.Errorf("Expected %s to be tagged with Sprint 7", issue.Key)
+		}
+		if !inSprint && issue.Fields.Sprint != nil {
+			t.Errorf("Dependency %s outside the sprint should not be tagged", issue.Key)
+		}
+	}
+}
+
+func TestFetchBoardBacklog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+
+		switch r.URL.Path {
+		case "/rest/agile/1.0/board/42/backlog":
+			response = map[string]interface{}{
+				"startAt": 0, "maxResults": 50, "total": 1,
+				"issues": []map[string]interface{}{{"key": "PROJ-5"}},
+			}
+		case "/rest/api/2/issue/PROJ-5":
+			response = createMinimalIssue("PROJ-5", "Backlog item")
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Errorf("Failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	export, err := client.FetchBoardBacklog(42)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(export.Issues) != 1 || export.Issues[0].Key != "PROJ-5" {
+		t.Errorf("Expected backlog issue PROJ-5, got %v", export.Issues)
+	}
+}
+
+func TestFetchBoardBacklogEmpty(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"startAt":0,"maxResults":50,"total":0,"issues":[]}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	if _, err := client.FetchBoardBacklog(42); err == nil {
+		t.Error("Expected error for empty backlog")
+	}
+}
+
+func TestGetSprint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/agile/1.0/sprint/17" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"id":17,"name":"Sprint 17","state":"future","originBoardId":42}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	sprint, err := client.GetSprint(17)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if sprint.Name != "Sprint 17" || sprint.State != SprintStateFuture || sprint.OriginBoardId != 42 {
+		t.Errorf("Unexpected sprint: %+v", sprint)
+	}
+}
@@ -0,0 +1,222 @@
+package jira
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Authentication types accepted by NewAuthenticator
+const (
+	AuthTypeBasic  = "basic"
+	AuthTypeBearer = "bearer"
+	AuthTypeCookie = "cookie"
+	AuthTypeOAuth  = "oauth"
+)
+
+// Authenticator adds credentials to requests sent to Jira
+type Authenticator interface {
+	// Authenticate adds credentials to the request
+	Authenticate(req *http.Request) error
+	// Mode describes the authentication mode for display
+	Mode() string
+}
+
+// Refresher is implemented by authenticators whose credentials can go stale
+// before they're used, such as session cookies. Refresh drops the cached
+// credentials, so the next Authenticate gets new ones.
+type Refresher interface {
+	Refresh()
+}
+
+// NewAuthenticator creates an authenticator for the given type. The secret
+// is the API token for basic auth, the personal access token for bearer
+// auth, and the password for cookie sessions.
+func NewAuthenticator(authType, baseURL, username, secret string) (Authenticator, error) {
+	switch strings.ToLower(authType) {
+	case "", AuthTypeBasic:
+		return &BasicAuth{Username: username, APIToken: secret}, nil
+	case AuthTypeBearer:
+		return &BearerAuth{Token: secret}, nil
+	case AuthTypeCookie:
+		return NewCookieAuth(baseURL, username, secret), nil
+	case AuthTypeOAuth:
+		return nil, fmt.Errorf("oauth authentication needs a token source; use OAuthAuth")
+	default:
+		return nil, fmt.Errorf("unsupported auth type %q (use basic, bearer or cookie)", authType)
+	}
+}
+
+// BasicAuth authenticates with a username and API token, as used by Jira Cloud
+type BasicAuth struct {
+	Username string
+	APIToken string
+}
+
+// Authenticate sets the basic auth header
+func (a *BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.APIToken)
+	return nil
+}
+
+// Mode describes basic authentication
+func (a *BasicAuth) Mode() string {
+	return "basic (username and API token)"
+}
+
+// BearerAuth authenticates with a Jira Data Center personal access token
+type BearerAuth struct {
+	Token string
+}
+
+// Authenticate sets the bearer token header
+func (a *BearerAuth) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+// Mode describes bearer authentication
+func (a *BearerAuth) Mode() string {
+	return "bearer (personal access token)"
+}
+
+// CookieAuth logs in to Jira Server or Data Center once with a username and
+// password, then reuses the session cookie for later requests
+type CookieAuth struct {
+	baseURL    string
+	username   string
+	password   string
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	session *http.Cookie
+}
+
+// NewCookieAuth creates a cookie session authenticator for a Jira instance
+func NewCookieAuth(baseURL, username, password string) *CookieAuth {
+	return &CookieAuth{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		username:   username,
+		password:   password,
+		httpClient: &http.Client{},
+	}
+}
+
+// Authenticate adds the session cookie, logging in first if needed
+func (a *CookieAuth) Authenticate(req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.session == nil {
+		session, err := a.login()
+		if err != nil {
+			return err
+		}
+		a.session = session
+	}
+
+	req.AddCookie(a.session)
+	return nil
+}
+
+// Refresh forgets the session, so the next request logs in again
+func (a *CookieAuth) Refresh() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.session = nil
+}
+
+// Mode describes cookie session authentication
+func (a *CookieAuth) Mode() string {
+	return "cookie (session login)"
+}
+
+// login creates a session via the Jira auth API
+func (a *CookieAuth) login() (session *http.Cookie, err error) {
+	payload, err := json.Marshal(map[string]string{
+		"username": a.username,
+		"password": a.password,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode login: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", a.baseURL+"/rest/auth/1/session", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to log in: %w", err)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body), Message: fmt.Sprintf("login failed with status %d: %s", resp.StatusCode, string(body))}
+	}
+
+	var result struct {
+		Session struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"session"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse login response: %w", err)
+	}
+	if result.Session.Name == "" || result.Session.Value == "" {
+		return nil, fmt.Errorf("login response did not include a session")
+	}
+
+	return &http.Cookie{Name: result.Session.Name, Value: result.Session.Value}, nil
+}
+
+// TokenSource supplies OAuth access tokens, refreshing them as needed
+type TokenSource interface {
+	AccessToken() (string, error)
+}
+
+// OAuthAuth authenticates with OAuth 2.0 access tokens, which are sent to
+// the Atlassian API gateway rather than the site itself
+type OAuthAuth struct {
+	Source TokenSource
+}
+
+// Authenticate sets the current access token as a bearer token
+func (a *OAuthAuth) Authenticate(req *http.Request) error {
+	token, err := a.Source.AccessToken()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Refresh forces a new access token on the next request, if the source
+// can refresh early
+func (a *OAuthAuth) Refresh() {
+	if source, ok := a.Source.(interface{ Invalidate() }); ok {
+		source.Invalidate()
+	}
+}
+
+// Mode describes OAuth authentication
+func (a *OAuthAuth) Mode() string {
+	return "oauth (Atlassian 3LO)"
+}
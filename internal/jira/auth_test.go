@@ -0,0 +1,219 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewAuthenticator(t *testing.T) {
+	tests := []struct {
+		authType string
+		want     string
+		wantErr  bool
+	}{
+		{"", "*jira.BasicAuth", false},
+		{"basic", "*jira.BasicAuth", false},
+		{"Bearer", "*jira.BearerAuth", false},
+		{"cookie", "*jira.CookieAuth", false},
+		{"kerberos", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.authType, func(t *testing.T) {
+			auth, err := NewAuthenticator(tt.authType, "https://jira.example.com", "user", "secret")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewAuthenticator(%q) error = %v, wantErr %v", tt.authType, err, tt.wantErr)
+			}
+			if err == nil {
+				if got := fmt.Sprintf("%T", auth); got != tt.want {
+					t.Errorf("NewAuthenticator(%q) = %s, want %s", tt.authType, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer pat123" {
+			t.Errorf("Expected bearer token header, got %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"displayName":"Data Center User","active":true}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClientWithAuth(server.URL, &BearerAuth{Token: "pat123"})
+
+	user, err := client.GetCurrentUser()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if user.DisplayName != "Data Center User" {
+		t.Errorf("Unexpected user: %+v", user)
+	}
+	if client.AuthMode() != "bearer (personal access token)" {
+		t.Errorf("Unexpected auth mode %q", client.AuthMode())
+	}
+}
+
+func TestCookieAuth(t *testing.T) {
+	logins := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/rest/auth/1/session" {
+			logins++
+			var credentials map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+				t.Errorf("Failed to decode login: %v", err)
+			}
+			if credentials["username"] != "user" || credentials["password"] != "hunter2" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if _, err := w.Write([]byte(`{"session":{"name":"JSESSIONID","value":"abc123"}}`)); err != nil {
+				t.Errorf("Failed to write response: %v", err)
+			}
+			return
+		}
+
+		cookie, err := r.Cookie("JSESSIONID")
+		if err != nil || cookie.Value != "abc123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if _, err := w.Write([]byte(`{"displayName":"Session User","active":true}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClientWithAuth(server.URL, NewCookieAuth(server.URL, "user", "hunter2"))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetCurrentUser(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+	if logins != 1 {
+		t.Errorf("Expected the session to be reused after one login, got %d logins", logins)
+	}
+
+	badClient := NewClientWithAuth(server.URL, NewCookieAuth(server.URL, "user", "wrong"))
+	if _, err := badClient.GetCurrentUser(); err == nil {
+		t.Error("Expected error when login is rejected")
+	}
+}
+
+func TestCookieAuthExpiredSession(t *testing.T) {
+	// Logging in hands out the current session; only it is accepted
+	current, logins := "session-1", 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/rest/auth/1/session" {
+			logins++
+			if _, err := fmt.Fprintf(w, `{"session":{"name":"JSESSIONID","value":%q}}`, current); err != nil {
+				t.Errorf("Failed to write response: %v", err)
+			}
+			return
+		}
+
+		cookie, err := r.Cookie("JSESSIONID")
+		if err != nil || cookie.Value != current {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if _, err := w.Write([]byte(`{"displayName":"Session User","active":true}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClientWithAuth(server.URL, NewCookieAuth(server.URL, "user", "hunter2"))
+	if _, err := client.GetCurrentUser(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// The session expires, so the next request logs in again and retries
+	current = "session-2"
+	if _, err := client.GetCurrentUser(); err != nil {
+		t.Fatalf("Expected the expired session to be renewed, got: %v", err)
+	}
+	if logins != 2 {
+		t.Errorf("Expected one more login after the session expired, got %d logins", logins)
+	}
+}
+
+// staticTokenSource hands out a fixed token
+type staticTokenSource string
+
+func (s staticTokenSource) AccessToken() (string, error) {
+	return string(s), nil
+}
+
+func TestOAuthAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer oauth-access" {
+			t.Errorf("Expected OAuth access token, got %q", got)
+		}
+		if r.URL.Path != "/ex/jira/cloud-1/rest/api/2/myself" {
+			t.Errorf("Expected request through the API gateway, got %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"displayName":"Cloud User","active":true}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClientWithAuth(server.URL+"/ex/jira/cloud-1", &OAuthAuth{Source: staticTokenSource("oauth-access")})
+
+	if _, err := client.GetCurrentUser(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+}
+
+// rotatingTokenSource hands out a new token each time it's invalidated
+type rotatingTokenSource struct {
+	generation int
+}
+
+func (s *rotatingTokenSource) AccessToken() (string, error) {
+	return fmt.Sprintf("access-%d", s.generation), nil
+}
+
+func (s *rotatingTokenSource) Invalidate() {
+	s.generation++
+}
+
+func TestOAuthAuthRevokedToken(t *testing.T) {
+	// access-0 was revoked before it expired
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"displayName":"OAuth User","active":true}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	source := &rotatingTokenSource{}
+	client := NewClientWithAuth(server.URL, &OAuthAuth{Source: source})
+	if _, err := client.GetCurrentUser(); err != nil {
+		t.Fatalf("Expected the revoked token to be refreshed, got: %v", err)
+	}
+	if source.generation != 1 {
+		t.Errorf("Expected one refresh, got %d", source.generation)
+	}
+}
@@ -1,34 +1,136 @@
 package jira
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	pb "github.com/conallob/jira-beads-sync/gen/jira"
+	"github.com/conallob/jira-beads-sync/internal/progress"
 )
 
+// searchPageSize is how many results each search request asks for. Jira
+// may return fewer, e.g. at most 100 on Jira Cloud.
+const searchPageSize = 100
+
 // Client handles communication with Jira API
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
-	username   string
-	apiToken   string
+	auth       Authenticator
 	adapter    *Adapter
+	traversal  TraversalOptions
+	hierarchy  Hierarchy
+	reporter   progress.Reporter
+	ctx        context.Context
 }
 
 // NewClient creates a new Jira API client
 func NewClient(baseURL, username, apiToken string) *Client {
+	return NewClientWithAuth(baseURL, &BasicAuth{Username: username, APIToken: apiToken})
+}
+
+// NewClientWithAuth creates a new Jira API client using the given authenticator
+func NewClientWithAuth(baseURL string, auth Authenticator) *Client {
 	return &Client{
 		baseURL:    strings.TrimSuffix(baseURL, "/"),
 		httpClient: &http.Client{},
-		username:   username,
-		apiToken:   apiToken,
+		auth:       auth,
 		adapter:    NewAdapter(),
+		hierarchy:  DefaultHierarchy(),
+		reporter:   progress.Nop{},
+	}
+}
+
+// SetReporter sets where the client logs what it is doing and reports
+// fetch progress. By default nothing is reported.
+func (c *Client) SetReporter(reporter progress.Reporter) {
+	c.reporter = progress.OrNop(reporter)
+}
+
+// WithContext returns a copy of the client whose requests use ctx, so
+// fetches can be cancelled or given a deadline
+func (c *Client) WithContext(ctx context.Context) *Client {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+// context returns the context requests are sent with
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
 	}
+	return c.ctx
+}
+
+// do sends a request with the client's context, tracing it for -vv
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	req = req.WithContext(c.context())
+	c.reporter.Trace("HTTP request", "method", req.Method, "url", req.URL.String())
+	resp, err := c.httpClient.Do(req)
+	if err == nil {
+		c.reporter.Trace("HTTP response", "status", resp.StatusCode, "url", req.URL.String())
+	}
+	return resp, err
+}
+
+// send authenticates and sends a request. If Jira rejects credentials that
+// can go stale, such as an expired session cookie, they're refreshed and the
+// request is sent once more.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if err := c.auth.Authenticate(req); err != nil {
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
+	resp, err := c.do(req)
+	refresher, ok := c.auth.(Refresher)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !ok {
+		return resp, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.Body != nil {
+		if req.GetBody == nil {
+			return resp, nil
+		}
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	_ = resp.Body.Close()
+
+	c.reporter.Debug("Credentials rejected, refreshing", "mode", c.auth.Mode())
+	refresher.Refresh()
+	retry.Header.Del("Authorization")
+	retry.Header.Del("Cookie")
+	if err := c.auth.Authenticate(retry); err != nil {
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
+	return c.do(retry)
+}
+
+// AuthMode describes how the client authenticates
+func (c *Client) AuthMode() string {
+	return c.auth.Mode()
+}
+
+// SetHierarchy sets which issue types are treated as epics when fetching
+// epic children, and where Advanced Roadmaps parents are read from
+func (c *Client) SetHierarchy(hierarchy Hierarchy) {
+	c.hierarchy = hierarchy
+	c.adapter.ParentLinkField = hierarchy.ParentLinkField
+}
+
+// SetStoryPointsField sets the custom field story points are read from
+func (c *Client) SetStoryPointsField(field string) {
+	c.adapter.StoryPointsField = field
 }
 
 // FetchIssue fetches a single issue by key (e.g., "PROJ-123")
@@ -40,10 +142,9 @@ func (c *Client) FetchIssue(issueKey string) (*pb.Issue, error) {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(c.username, c.apiToken)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch issue: %w", err)
 	}
@@ -55,7 +156,7 @@ func (c *Client) FetchIssue(issueKey string) (*pb.Issue, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("jira API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp.StatusCode, body)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -69,6 +170,15 @@ func (c *Client) FetchIssue(issueKey string) (*pb.Issue, error) {
 		return nil, fmt.Errorf("failed to parse issue: %w", err)
 	}
 
+	// The issue endpoint only embeds the first page of worklogs
+	if page := jsonIssue.Fields.Worklog; page != nil && page.Total > len(page.Worklogs) {
+		worklogs, err := c.fetchWorklogs(issueKey)
+		if err != nil {
+			return nil, err
+		}
+		page.Worklogs = worklogs
+	}
+
 	issue, err := c.adapter.convertIssue(&jsonIssue)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert issue: %w", err)
@@ -77,6 +187,102 @@ func (c *Client) FetchIssue(issueKey string) (*pb.Issue, error) {
 	return issue, nil
 }
 
+// fetchWorklogs fetches every worklog entry recorded against an issue
+func (c *Client) fetchWorklogs(issueKey string) ([]jsonWorklog, error) {
+	var worklogs []jsonWorklog
+
+	for startAt := 0; ; {
+		apiURL := fmt.Sprintf("%s/rest/api/2/issue/%s/worklog?startAt=%d", c.baseURL, issueKey, startAt)
+
+		req, err := http.NewRequest("GET", apiURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		var page jsonWorklogPage
+		if err := c.doJSON(req, &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch worklogs for %s: %w", issueKey, err)
+		}
+
+		worklogs = append(worklogs, page.Worklogs...)
+		startAt += len(page.Worklogs)
+		if len(page.Worklogs) == 0 || startAt >= page.Total {
+			return worklogs, nil
+		}
+	}
+}
+
+// AddWorklog records time spent on an issue and returns the new worklog ID
+func (c *Client) AddWorklog(issueKey string, timeSpentSeconds int64, started time.Time, comment string) (string, error) {
+	if timeSpentSeconds <= 0 {
+		return "", fmt.Errorf("time spent must be positive")
+	}
+
+	payload := map[string]interface{}{
+		"timeSpentSeconds": timeSpentSeconds,
+		"started":          started.Format("2006-01-02T15:04:05.000-0700"),
+	}
+	if comment != "" {
+		payload["comment"] = comment
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode worklog: %w", err)
+	}
+
+	apiURL := fmt.Sprintf("%s/rest/api/2/issue/%s/worklog", c.baseURL, issueKey)
+	req, err := http.NewRequest("POST", apiURL, bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := c.doJSON(req, &created); err != nil {
+		return "", fmt.Errorf("failed to add worklog to %s: %w", issueKey, err)
+	}
+
+	return created.ID, nil
+}
+
+// doJSON authenticates and sends a request, decoding a successful JSON
+// response into out
+func (c *Client) doJSON(req *http.Request, out interface{}) (err error) {
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newAPIError(resp.StatusCode, body)
+	}
+
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
 // UserInfo represents basic information about a Jira user
 type UserInfo struct {
 	AccountID    string `json:"accountId"`
@@ -95,10 +301,9 @@ func (c *Client) GetCurrentUser() (*UserInfo, error) {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(c.username, c.apiToken)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Jira: %w", err)
 	}
@@ -111,9 +316,15 @@ func (c *Client) GetCurrentUser() (*UserInfo, error) {
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		if resp.StatusCode == http.StatusUnauthorized {
-			return nil, fmt.Errorf("authentication failed: invalid username or API token")
+			apiErr := &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+			if _, basic := c.auth.(*BasicAuth); basic {
+				apiErr.Message = "authentication failed: invalid username or API token"
+			} else {
+				apiErr.Message = fmt.Sprintf("authentication failed: credentials rejected for %s auth", c.auth.Mode())
+			}
+			return nil, apiErr
 		}
-		return nil, fmt.Errorf("jira API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp.StatusCode, body)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -131,61 +342,13 @@ func (c *Client) GetCurrentUser() (*UserInfo, error) {
 
 // FetchIssueWithDependencies fetches an issue and all its dependencies recursively
 func (c *Client) FetchIssueWithDependencies(issueKey string) (*pb.Export, error) {
-	visited := make(map[string]bool)
-	issues := make([]*pb.Issue, 0)
-
-	if err := c.fetchRecursive(issueKey, visited, &issues); err != nil {
-		return nil, err
-	}
-
-	return &pb.Export{Issues: issues}, nil
+	return c.fetchAll([]string{issueKey})
 }
 
-// fetchRecursive recursively fetches an issue and all its related issues
-func (c *Client) fetchRecursive(issueKey string, visited map[string]bool, issues *[]*pb.Issue) error {
-	if visited[issueKey] {
-		return nil
-	}
-
-	fmt.Printf("Fetching %s...\n", issueKey)
-	visited[issueKey] = true
-
-	issue, err := c.FetchIssue(issueKey)
-	if err != nil {
-		return fmt.Errorf("failed to fetch %s: %w", issueKey, err)
-	}
-
-	*issues = append(*issues, issue)
-
-	// Fetch subtasks
-	for _, subtask := range issue.Fields.Subtasks {
-		if err := c.fetchRecursive(subtask.Key, visited, issues); err != nil {
-			return err
-		}
-	}
-
-	// Fetch linked issues (dependencies)
-	for _, link := range issue.Fields.IssueLinks {
-		if link.InwardIssue != nil {
-			if err := c.fetchRecursive(link.InwardIssue.Key, visited, issues); err != nil {
-				return err
-			}
-		}
-		if link.OutwardIssue != nil {
-			if err := c.fetchRecursive(link.OutwardIssue.Key, visited, issues); err != nil {
-				return err
-			}
-		}
-	}
-
-	// Fetch parent if it exists and isn't an epic
-	if issue.Fields.Parent != nil && issue.Fields.Parent.Fields.IssueType.Name != "Epic" {
-		if err := c.fetchRecursive(issue.Fields.Parent.Key, visited, issues); err != nil {
-			return err
-		}
-	}
-
-	return nil
+// FetchIssues fetches several issues, such as search results, and all their
+// dependencies
+func (c *Client) FetchIssues(issueKeys []string) (*pb.Export, error) {
+	return c.fetchAll(issueKeys)
 }
 
 // ParseIssueKeyFromURL extracts the issue key from a Jira URL
@@ -244,68 +407,57 @@ func (c *Client) SearchIssuesByLabel(label string) ([]string, error) {
 	return c.SearchIssues(jql)
 }
 
-// SearchIssues performs a JQL search and returns issue keys
+// SearchIssues performs a JQL search and returns the keys of every
+// matching issue, paging through the results
 func (c *Client) SearchIssues(jql string) ([]string, error) {
-	// URL encode the JQL query
-	encodedJQL := url.QueryEscape(jql)
-	apiURL := fmt.Sprintf("%s/rest/api/2/search?jql=%s&fields=key&maxResults=1000", c.baseURL, encodedJQL)
-
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.SetBasicAuth(c.username, c.apiToken)
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search issues: %w", err)
-	}
-	defer func() {
-		if cerr := resp.Body.Close(); cerr != nil && err == nil {
-			err = cerr
+	var issueKeys []string
+	seen := make(map[string]bool)
+
+	for startAt := 0; ; {
+		query := url.Values{}
+		query.Set("jql", jql)
+		query.Set("fields", "key")
+		query.Set("startAt", strconv.Itoa(startAt))
+		query.Set("maxResults", strconv.Itoa(searchPageSize))
+		apiURL := fmt.Sprintf("%s/rest/api/2/search?%s", c.baseURL, query.Encode())
+
+		req, err := http.NewRequest("GET", apiURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("jira API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Parse search results
-	var searchResult struct {
-		Issues []struct {
-			Key string `json:"key"`
-		} `json:"issues"`
-		Total int `json:"total"`
-	}
 
-	if err := json.Unmarshal(body, &searchResult); err != nil {
-		return nil, fmt.Errorf("failed to parse search results: %w", err)
-	}
+		var page struct {
+			Issues []struct {
+				Key string `json:"key"`
+			} `json:"issues"`
+			Total int `json:"total"`
+		}
+		if err := c.doJSON(req, &page); err != nil {
+			return nil, fmt.Errorf("failed to search issues: %w", err)
+		}
 
-	// Extract issue keys
-	issueKeys := make([]string, 0, len(searchResult.Issues))
-	for _, issue := range searchResult.Issues {
-		issueKeys = append(issueKeys, issue.Key)
-	}
+		// Keys can repeat across pages if issues change while paging
+		for _, issue := range page.Issues {
+			if !seen[issue.Key] {
+				seen[issue.Key] = true
+				issueKeys = append(issueKeys, issue.Key)
+			}
+		}
 
-	if len(issueKeys) < searchResult.Total {
-		fmt.Printf("⚠ Warning: Retrieved %d of %d total issues (pagination limit)\n", len(issueKeys), searchResult.Total)
+		startAt += len(page.Issues)
+		if startAt >= page.Total {
+			return issueKeys, nil
+		}
+		if len(page.Issues) == 0 {
+			c.reporter.Warn("Search results ended early", "retrieved", len(issueKeys), "total", page.Total)
+			return issueKeys, nil
+		}
 	}
-
-	return issueKeys, nil
 }
 
 // FetchIssuesByLabel fetches all issues with a given label and their dependencies
 func (c *Client) FetchIssuesByLabel(label string) (*pb.Export, error) {
-	fmt.Printf("Searching for issues with label: %s\n", label)
+	c.reporter.Info("Searching for issues", "label", label)
 
 	issueKeys, err := c.SearchIssuesByLabel(label)
 	if err != nil {
@@ -316,18 +468,25 @@ func (c *Client) FetchIssuesByLabel(label string) (*pb.Export, error) {
 		return nil, fmt.Errorf("no issues found with label: %s", label)
 	}
 
-	fmt.Printf("Found %d issue(s) with label %s\n", len(issueKeys), label)
-	fmt.Println()
+	c.reporter.Info("Found issues", "count", len(issueKeys), "label", label)
 
-	// Fetch all issues and their dependencies
-	visited := make(map[string]bool)
-	issues := make([]*pb.Issue, 0)
+	return c.fetchAll(issueKeys)
+}
 
-	for _, key := range issueKeys {
-		if err := c.fetchRecursive(key, visited, &issues); err != nil {
-			return nil, err
-		}
+// FetchIssuesByJQL fetches all issues matching a JQL query and their dependencies
+func (c *Client) FetchIssuesByJQL(jql string) (*pb.Export, error) {
+	c.reporter.Info("Searching for issues", "jql", jql)
+
+	issueKeys, err := c.SearchIssues(jql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
 	}
 
-	return &pb.Export{Issues: issues}, nil
+	if len(issueKeys) == 0 {
+		return nil, fmt.Errorf("no issues found matching: %s", jql)
+	}
+
+	c.reporter.Info("Found issues", "count", len(issueKeys))
+
+	return c.fetchAll(issueKeys)
 }
@@ -2,10 +2,15 @@ package jira
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewClient(t *testing.T) {
@@ -19,12 +24,17 @@ func TestNewClient(t *testing.T) {
 		t.Errorf("Expected baseURL to be 'https://jira.example.com', got '%s'", client.baseURL)
 	}
 
-	if client.username != "user@example.com" {
-		t.Errorf("Expected username to be 'user@example.com', got '%s'", client.username)
+	auth, ok := client.auth.(*BasicAuth)
+	if !ok {
+		t.Fatalf("Expected basic auth, got %T", client.auth)
 	}
 
-	if client.apiToken != "token123" {
-		t.Errorf("Expected apiToken to be 'token123', got '%s'", client.apiToken)
+	if auth.Username != "user@example.com" {
+		t.Errorf("Expected username to be 'user@example.com', got '%s'", auth.Username)
+	}
+
+	if auth.APIToken != "token123" {
+		t.Errorf("Expected apiToken to be 'token123', got '%s'", auth.APIToken)
 	}
 
 	if client.httpClient == nil {
@@ -509,51 +519,141 @@ func TestGetBaseURLFromIssueURL(t *testing.T) {
 	}
 }
 
-func TestFetchRecursiveSkipsEpicParents(t *testing.T) {
-	// Test that parent issues that are epics are not fetched
+func TestFetchFollowsEpicParentsNotSiblings(t *testing.T) {
+	// A story in an epic pulls in the epic, but not the epic's other
+	// children
 	fetchedIssues := make(map[string]bool)
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rest/api/2/search" {
+			t.Errorf("Unexpected epic children query %q", r.URL.Query().Get("jql"))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
 		issueKey := r.URL.Path[len("/rest/api/2/issue/"):]
 		fetchedIssues[issueKey] = true
 
-		var response map[string]interface{}
-
+		issue := createMinimalIssue(issueKey, "Issue "+issueKey)
+		fields := issue["fields"].(map[string]interface{})
 		switch issueKey {
-		case "PROJ-123":
-			response = map[string]interface{}{
-				"key": "PROJ-123",
-				"id":  "123",
+		case "EPIC-100":
+			fields["issuetype"] = map[string]interface{}{"name": "Epic"}
+		case "PROJ-123", "PROJ-124":
+			fields["parent"] = map[string]interface{}{
+				"key": "EPIC-100",
 				"fields": map[string]interface{}{
-					"summary": "Story with Epic parent",
-					"issuetype": map[string]interface{}{
-						"name": "Story",
-					},
-					"status": map[string]interface{}{
-						"name": "Open",
-						"statusCategory": map[string]interface{}{
-							"key": "new",
-						},
-					},
-					"priority": map[string]interface{}{
-						"name": "Medium",
-					},
-					"created": "2024-01-01T10:00:00.000+0000",
-					"updated": "2024-01-01T10:00:00.000+0000",
-					"parent": map[string]interface{}{
-						"key": "EPIC-100",
-						"fields": map[string]interface{}{
-							"issuetype": map[string]interface{}{
-								"name": "Epic", // This should NOT be fetched
-							},
-						},
-					},
+					"issuetype": map[string]interface{}{"name": "Epic"},
 				},
 			}
-		case "EPIC-100":
-			// This should never be requested
-			t.Error("Epic parent should not be fetched")
-			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(issue); err != nil {
+			t.Errorf("Failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	export, err := client.FetchIssueWithDependencies("PROJ-123")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(export.Issues) != 2 || export.Issues[1].Key != "EPIC-100" {
+		t.Errorf("Expected the story and its epic, got %v", export.Issues)
+	}
+	if fetchedIssues["PROJ-124"] {
+		t.Error("Expected the epic's other child not to be fetched")
+	}
+}
+
+func TestFetchEpicExpandsNestedChildren(t *testing.T) {
+	// An initiative's epics are expanded too, since they were found under
+	// the requested issue
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			children := map[string]string{
+				`parent = INIT-1 OR "Epic Link" = INIT-1`: "EPIC-1",
+				`parent = EPIC-1 OR "Epic Link" = EPIC-1`: "PROJ-1",
+			}
+			child, ok := children[r.URL.Query().Get("jql")]
+			if !ok {
+				t.Errorf("Unexpected children query %q", r.URL.Query().Get("jql"))
+			}
+			response = map[string]interface{}{
+				"total":  1,
+				"issues": []map[string]interface{}{{"key": child}},
+			}
+		default:
+			key := r.URL.Path[len("/rest/api/2/issue/"):]
+			issue := createMinimalIssue(key, "Issue "+key)
+			fields := issue["fields"].(map[string]interface{})
+			switch key {
+			case "INIT-1":
+				fields["issuetype"] = map[string]interface{}{"name": "Initiative", "hierarchyLevel": 2}
+			case "EPIC-1":
+				fields["issuetype"] = map[string]interface{}{"name": "Epic", "hierarchyLevel": 1}
+				fields["parent"] = map[string]interface{}{"key": "INIT-1", "fields": map[string]interface{}{
+					"issuetype": map[string]interface{}{"name": "Initiative", "hierarchyLevel": 2},
+				}}
+			case "PROJ-1":
+				fields["parent"] = map[string]interface{}{"key": "EPIC-1", "fields": map[string]interface{}{
+					"issuetype": map[string]interface{}{"name": "Epic", "hierarchyLevel": 1},
+				}}
+			}
+			response = issue
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Errorf("Failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	export, err := client.FetchIssueWithDependencies("INIT-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(export.Issues) != 3 || export.Issues[2].Key != "PROJ-1" {
+		t.Errorf("Expected the initiative, its epic and the epic's story, got %v", export.Issues)
+	}
+}
+
+func TestFetchEpicChildrenWithoutEpicLink(t *testing.T) {
+	// Sites without the Epic Link field reject the combined query, so the
+	// children are found by parent alone
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			if strings.Contains(r.URL.Query().Get("jql"), "Epic Link") {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			response = map[string]interface{}{
+				"total":  1,
+				"issues": []map[string]interface{}{{"key": "PROJ-2"}},
+			}
+		case "/rest/api/2/issue/EPIC-1":
+			epic := createMinimalIssue("EPIC-1", "Epic")
+			epic["fields"].(map[string]interface{})["issuetype"] = map[string]interface{}{"name": "Epic"}
+			response = epic
+		case "/rest/api/2/issue/PROJ-2":
+			response = createMinimalIssue("PROJ-2", "Child")
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
 			return
 		}
 
@@ -566,18 +666,59 @@ func TestFetchRecursiveSkipsEpicParents(t *testing.T) {
 
 	client := NewClient(server.URL, "user@example.com", "token123")
 
-	export, err := client.FetchIssueWithDependencies("PROJ-123")
+	export, err := client.FetchIssueWithDependencies("EPIC-1")
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
 
-	// Should only have PROJ-123, not EPIC-100
-	if len(export.Issues) != 1 {
-		t.Errorf("Expected 1 issue (epic parent excluded), got %d", len(export.Issues))
+	if len(export.Issues) != 2 || export.Issues[1].Key != "PROJ-2" {
+		t.Errorf("Expected epic and its child, got %v", export.Issues)
 	}
+}
+
+func TestFetchFollowsEpicLinkField(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
 
-	if fetchedIssues["EPIC-100"] {
-		t.Error("Epic parent should not have been fetched")
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			response = map[string]interface{}{
+				"total":  1,
+				"issues": []map[string]interface{}{{"key": "PROJ-1"}},
+			}
+		case "/rest/api/2/issue/PROJ-1":
+			issue := createMinimalIssue("PROJ-1", "Story")
+			issue["fields"].(map[string]interface{})["epic"] = map[string]interface{}{
+				"key":  "EPIC-1",
+				"name": "Legacy epic",
+			}
+			response = issue
+		case "/rest/api/2/issue/EPIC-1":
+			epic := createMinimalIssue("EPIC-1", "Legacy epic")
+			epic["fields"].(map[string]interface{})["issuetype"] = map[string]interface{}{"name": "Epic"}
+			response = epic
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Errorf("Failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	export, err := client.FetchIssueWithDependencies("PROJ-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(export.Issues) != 2 || export.Issues[1].Key != "EPIC-1" {
+		t.Errorf("Expected story and its epic, got %v", export.Issues)
 	}
 }
 
@@ -852,14 +993,55 @@ func TestSearchIssues(t *testing.T) {
 }
 
 func TestSearchIssuesWithPagination(t *testing.T) {
+	// 250 issues, served at most 100 at a time whatever maxResults asks for
+	const total = 250
+	var requests []string
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Simulate pagination warning
+		requests = append(requests, r.URL.Query().Get("startAt"))
+		startAt, err := strconv.Atoi(r.URL.Query().Get("startAt"))
+		if err != nil {
+			t.Errorf("Expected a numeric startAt, got %q", r.URL.Query().Get("startAt"))
+		}
+
+		issues := []map[string]interface{}{}
+		for i := startAt; i < total && i < startAt+100; i++ {
+			issues = append(issues, map[string]interface{}{"key": fmt.Sprintf("PROJ-%d", i+1)})
+		}
 		response := map[string]interface{}{
-			"issues": []map[string]interface{}{
-				{"key": "PROJ-1"},
-				{"key": "PROJ-2"},
-			},
-			"total": 1500, // More than maxResults
+			"startAt":    startAt,
+			"maxResults": 100,
+			"total":      total,
+			"issues":     issues,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Errorf("Failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	issueKeys, err := client.SearchIssues("project = PROJ")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(issueKeys) != total || issueKeys[total-1] != "PROJ-250" {
+		t.Errorf("Expected all %d issue keys, got %d", total, len(issueKeys))
+	}
+	if want := []string{"0", "100", "200"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("Expected pages starting at %v, got %v", want, requests)
+	}
+}
+
+func TestSearchIssuesEndsEarly(t *testing.T) {
+	// Jira claims more results than it returns
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := map[string]interface{}{"issues": []map[string]interface{}{}, "total": 1500}
+		if r.URL.Query().Get("startAt") == "0" {
+			response["issues"] = []map[string]interface{}{{"key": "PROJ-1"}, {"key": "PROJ-2"}}
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -1281,6 +1463,9 @@ func TestGetCurrentUserUnauthorized(t *testing.T) {
 	if err.Error() != expectedError {
 		t.Errorf("Expected error '%s', got '%s'", expectedError, err.Error())
 	}
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
 }
 
 func TestGetCurrentUserInvalidJSON(t *testing.T) {
@@ -1317,3 +1502,203 @@ func TestGetCurrentUserServerError(t *testing.T) {
 		t.Error("Expected error for server error, got nil")
 	}
 }
+
+func TestFetchIssuePaginatesWorklogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response map[string]interface{}
+
+		switch r.URL.Path {
+		case "/rest/api/2/issue/PROJ-1":
+			response = map[string]interface{}{
+				"key": "PROJ-1",
+				"id":  "1",
+				"fields": map[string]interface{}{
+					"summary":   "Busy issue",
+					"issuetype": map[string]interface{}{"name": "Story"},
+					"worklog": map[string]interface{}{
+						"startAt":    0,
+						"maxResults": 1,
+						"total":      3,
+						"worklogs": []map[string]interface{}{
+							{"id": "1", "timeSpentSeconds": 60},
+						},
+					},
+				},
+			}
+		case "/rest/api/2/issue/PROJ-1/worklog":
+			startAt := r.URL.Query().Get("startAt")
+			switch startAt {
+			case "0":
+				response = map[string]interface{}{
+					"startAt": 0, "maxResults": 2, "total": 3,
+					"worklogs": []map[string]interface{}{
+						{"id": "1", "timeSpentSeconds": 60},
+						{"id": "2", "timeSpentSeconds": 120},
+					},
+				}
+			case "2":
+				response = map[string]interface{}{
+					"startAt": 2, "maxResults": 2, "total": 3,
+					"worklogs": []map[string]interface{}{
+						{"id": "3", "timeSpentSeconds": 180},
+					},
+				}
+			default:
+				t.Errorf("Unexpected startAt %s", startAt)
+			}
+		default:
+			t.Errorf("Unexpected request path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Errorf("Failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	issue, err := client.FetchIssue("PROJ-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(issue.Fields.Worklogs) != 3 {
+		t.Fatalf("Expected 3 worklogs, got %d", len(issue.Fields.Worklogs))
+	}
+	if issue.Fields.Worklogs[2].Id != "3" {
+		t.Errorf("Expected last worklog ID 3, got %s", issue.Fields.Worklogs[2].Id)
+	}
+}
+
+func TestAddWorklog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Expected POST method, got '%s'", r.Method)
+		}
+		if r.URL.Path != "/rest/api/2/issue/PROJ-1/worklog" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if _, _, ok := r.BasicAuth(); !ok {
+			t.Error("Expected Basic Auth to be present")
+		}
+
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if payload["timeSpentSeconds"] != float64(5400) {
+			t.Errorf("Expected timeSpentSeconds 5400, got %v", payload["timeSpentSeconds"])
+		}
+		if payload["started"] != "2024-03-01T09:30:00.000+0000" {
+			t.Errorf("Unexpected started value %v", payload["started"])
+		}
+		if payload["comment"] != "Code review" {
+			t.Errorf("Unexpected comment %v", payload["comment"])
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		if _, err := w.Write([]byte(`{"id":"10500"}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	started := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	id, err := client.AddWorklog("PROJ-1", 5400, started, "Code review")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if id != "10500" {
+		t.Errorf("Expected worklog ID 10500, got %s", id)
+	}
+
+	if _, err := client.AddWorklog("PROJ-1", 0, started, ""); err == nil {
+		t.Error("Expected error for zero time spent")
+	}
+}
+
+func TestAddWorklogServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		if _, err := w.Write([]byte(`{"errorMessages":["Time tracking is disabled"]}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	if _, err := client.AddWorklog("PROJ-1", 60, time.Now(), ""); err == nil {
+		t.Error("Expected error for rejected worklog, got nil")
+	}
+}
+
+func TestFetchIssuesByJQL(t *testing.T) {
+	const query = `project = PROJ AND fixVersion = "2.0"`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response map[string]interface{}
+
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			if jql := r.URL.Query().Get("jql"); jql != query {
+				t.Errorf("Expected JQL %q, got %q", query, jql)
+			}
+			response = map[string]interface{}{
+				"issues": []map[string]interface{}{{"key": "PROJ-1"}},
+				"total":  1,
+			}
+		case "/rest/api/2/issue/PROJ-1":
+			response = createMinimalIssue("PROJ-1", "Release blocker")
+			response["fields"].(map[string]interface{})["subtasks"] = []map[string]interface{}{
+				{"key": "PROJ-2"},
+			}
+		case "/rest/api/2/issue/PROJ-2":
+			response = createMinimalIssue("PROJ-2", "Subtask")
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Errorf("Failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	export, err := client.FetchIssuesByJQL(query)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// The match and its subtask, crawled like FetchIssuesByLabel
+	if len(export.Issues) != 2 {
+		t.Errorf("Expected 2 issues, got %d", len(export.Issues))
+	}
+}
+
+func TestFetchIssuesByJQLNoResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"issues":[],"total":0}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	if _, err := client.FetchIssuesByJQL("assignee = nobody"); err == nil {
+		t.Error("Expected error when no issues match")
+	}
+}
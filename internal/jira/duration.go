@@ -0,0 +1,88 @@
+package jira
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Jira's default time tracking units: a working day is 8 hours and a
+// working week is 5 days
+const (
+	secondsPerMinute = 60
+	secondsPerHour   = 60 * secondsPerMinute
+	secondsPerDay    = 8 * secondsPerHour
+	secondsPerWeek   = 5 * secondsPerDay
+)
+
+// ParseDuration parses a Jira-style duration such as "1w 2d 3h 30m" into
+// seconds. Units are w, d, h and m, using Jira's default working-time
+// conversions.
+func ParseDuration(s string) (int64, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	var total int64
+	for _, field := range fields {
+		if len(field) < 2 {
+			return 0, fmt.Errorf("invalid duration component %q", field)
+		}
+
+		value, err := strconv.ParseInt(field[:len(field)-1], 10, 64)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("invalid duration component %q", field)
+		}
+
+		switch field[len(field)-1] {
+		case 'w':
+			total += value * secondsPerWeek
+		case 'd':
+			total += value * secondsPerDay
+		case 'h':
+			total += value * secondsPerHour
+		case 'm':
+			total += value * secondsPerMinute
+		default:
+			return 0, fmt.Errorf("invalid duration unit in %q (use w, d, h or m)", field)
+		}
+	}
+
+	if total == 0 {
+		return 0, fmt.Errorf("duration must be greater than zero")
+	}
+
+	return total, nil
+}
+
+// FormatDuration formats seconds as a Jira-style duration such as "1d 2h"
+func FormatDuration(seconds int64) string {
+	if seconds <= 0 {
+		return "0m"
+	}
+
+	units := []struct {
+		suffix  string
+		seconds int64
+	}{
+		{"w", secondsPerWeek},
+		{"d", secondsPerDay},
+		{"h", secondsPerHour},
+		{"m", secondsPerMinute},
+	}
+
+	var parts []string
+	for _, unit := range units {
+		if seconds >= unit.seconds {
+			parts = append(parts, fmt.Sprintf("%d%s", seconds/unit.seconds, unit.suffix))
+			seconds %= unit.seconds
+		}
+	}
+
+	if len(parts) == 0 {
+		return "0m"
+	}
+
+	return strings.Join(parts, " ")
+}
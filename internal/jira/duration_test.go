@@ -0,0 +1,78 @@
+package jira
+
+import "testing"
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      int64
+		expectErr bool
+	}{
+		{name: "minutes", input: "30m", want: 1800},
+		{name: "hours", input: "2h", want: 7200},
+		{name: "working day", input: "1d", want: 8 * 3600},
+		{name: "working week", input: "1w", want: 5 * 8 * 3600},
+		{name: "combined", input: "1d 2h 30m", want: 8*3600 + 2*3600 + 1800},
+		{name: "uppercase units", input: "1H 15M", want: 4500},
+		{name: "extra whitespace", input: "  1h   5m ", want: 3900},
+		{name: "empty", input: "", expectErr: true},
+		{name: "missing unit", input: "90", expectErr: true},
+		{name: "unknown unit", input: "3s", expectErr: true},
+		{name: "not a number", input: "xh", expectErr: true},
+		{name: "negative", input: "-1h", expectErr: true},
+		{name: "zero", input: "0m", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDuration(tt.input)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("ParseDuration(%q) expected error, got %d", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDuration(%q) unexpected error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDuration(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		seconds int64
+		want    string
+	}{
+		{0, "0m"},
+		{-5, "0m"},
+		{59, "0m"},
+		{1800, "30m"},
+		{3600, "1h"},
+		{8 * 3600, "1d"},
+		{5 * 8 * 3600, "1w"},
+		{8*3600 + 2*3600 + 1800, "1d 2h 30m"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatDuration(tt.seconds); got != tt.want {
+			t.Errorf("FormatDuration(%d) = %q, want %q", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestDurationRoundTrip(t *testing.T) {
+	for _, input := range []string{"1w 2d 3h 4m", "45m", "6h"} {
+		seconds, err := ParseDuration(input)
+		if err != nil {
+			t.Fatalf("ParseDuration(%q) unexpected error: %v", input, err)
+		}
+		if got := FormatDuration(seconds); got != input {
+			t.Errorf("round trip of %q produced %q", input, got)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package jira
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Sentinel errors for failed API requests, matched with errors.Is
+var (
+	ErrUnauthorized = errors.New("jira rejected the credentials")
+	ErrForbidden    = errors.New("no permission in jira")
+	ErrNotFound     = errors.New("not found in jira")
+	ErrConflict     = errors.New("conflicting change in jira")
+)
+
+// APIError is an unsuccessful response from the Jira REST API
+type APIError struct {
+	StatusCode int
+	Body       string
+
+	// Message replaces the default description of the error, if set
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("jira API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// Is matches the sentinel error for the status code
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	}
+	return false
+}
+
+// newAPIError describes an unsuccessful response
+func newAPIError(statusCode int, body []byte) error {
+	return &APIError{StatusCode: statusCode, Body: string(body)}
+}
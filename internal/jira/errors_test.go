@@ -0,0 +1,37 @@
+package jira
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrForbidden},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusConflict, ErrConflict},
+	}
+
+	sentinels := []error{ErrUnauthorized, ErrForbidden, ErrNotFound, ErrConflict}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			err := fmt.Errorf("failed to fetch PROJ-1: %w", newAPIError(tt.status, []byte("body")))
+			for _, sentinel := range sentinels {
+				if got := errors.Is(err, sentinel); got != (sentinel == tt.want) {
+					t.Errorf("errors.Is(%v) = %t", sentinel, got)
+				}
+			}
+		})
+	}
+
+	if errors.Is(newAPIError(http.StatusInternalServerError, nil), ErrNotFound) {
+		t.Error("Expected a server error to match no sentinel")
+	}
+}
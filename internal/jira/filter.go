@@ -0,0 +1,62 @@
+package jira
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	pb "github.com/conallob/jira-beads-sync/gen/jira"
+)
+
+// Filter represents a saved Jira filter
+type Filter struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	JQL   string `json:"jql"`
+	Owner *User  `json:"owner,omitempty"`
+}
+
+// ValidateFilterID checks that a saved filter ID is numeric, as Jira's are
+func ValidateFilterID(filterID string) error {
+	if _, err := strconv.ParseUint(filterID, 10, 64); err != nil {
+		return fmt.Errorf("invalid filter ID %q: saved filter IDs are numeric", filterID)
+	}
+	return nil
+}
+
+// GetFilter fetches a saved filter, including its JQL
+func (c *Client) GetFilter(filterID string) (*Filter, error) {
+	if err := ValidateFilterID(filterID); err != nil {
+		return nil, err
+	}
+
+	apiURL := fmt.Sprintf("%s/rest/api/2/filter/%s", c.baseURL, filterID)
+
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var filter Filter
+	if err := c.doJSON(req, &filter); err != nil {
+		return nil, fmt.Errorf("failed to fetch filter %s: %w", filterID, err)
+	}
+
+	if filter.JQL == "" {
+		return nil, fmt.Errorf("filter %s has no JQL", filterID)
+	}
+
+	return &filter, nil
+}
+
+// FetchIssuesByFilter fetches all issues matched by a saved filter and their dependencies
+func (c *Client) FetchIssuesByFilter(filterID string) (*pb.Export, error) {
+	filter, err := c.GetFilter(filterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.reporter.Info("Using filter", "name", filter.Name)
+
+	return c.FetchIssuesByJQL(filter.JQL)
+}
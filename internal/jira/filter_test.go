@@ -0,0 +1,103 @@
+package jira
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/filter/12345" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"id":"12345","name":"Roadmap","jql":"project = PROJ ORDER BY Rank"}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	filter, err := client.GetFilter("12345")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if filter.Name != "Roadmap" || filter.JQL != "project = PROJ ORDER BY Rank" {
+		t.Errorf("Unexpected filter: %+v", filter)
+	}
+}
+
+func TestGetFilterNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		if _, err := w.Write([]byte(`{"errorMessages":["The selected filter is not available to you"]}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	if _, err := client.GetFilter("999"); err == nil {
+		t.Error("Expected error for unavailable filter")
+	}
+}
+
+func TestGetFilterInvalidID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Expected no request, got %s", r.URL)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	for _, id := range []string{"", "abc", "123/../../myself", "-1"} {
+		if _, err := client.GetFilter(id); err == nil {
+			t.Errorf("Expected error for filter ID %q", id)
+		}
+	}
+}
+
+func TestFetchIssuesByFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response map[string]interface{}
+
+		switch r.URL.Path {
+		case "/rest/api/2/filter/12345":
+			response = map[string]interface{}{"id": "12345", "name": "Roadmap", "jql": "labels = roadmap"}
+		case "/rest/api/2/search":
+			if jql := r.URL.Query().Get("jql"); jql != "labels = roadmap" {
+				t.Errorf("Expected filter JQL, got %q", jql)
+			}
+			response = map[string]interface{}{
+				"issues": []map[string]interface{}{{"key": "PROJ-7"}},
+				"total":  1,
+			}
+		case "/rest/api/2/issue/PROJ-7":
+			response = createMinimalIssue("PROJ-7", "Roadmap item")
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Errorf("Failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	export, err := client.FetchIssuesByFilter("12345")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(export.Issues) != 1 || export.Issues[0].Key != "PROJ-7" {
+		t.Errorf("Expected PROJ-7, got %v", export.Issues)
+	}
+}
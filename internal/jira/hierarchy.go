@@ -0,0 +1,64 @@
+package jira
+
+import (
+	"fmt"
+	"strings"
+
+	pb "github.com/conallob/jira-beads-sync/gen/jira"
+)
+
+// Hierarchy describes which issue types sit at the epic level or above
+// (e.g., Initiative → Epic → Story), and so become beads epics
+type Hierarchy struct {
+	// EpicTypes are issue type names treated as epics, compared
+	// case-insensitively
+	EpicTypes []string
+	// EpicLevel is the lowest Jira hierarchy level treated as an epic;
+	// 0 disables matching by level. Only Jira Cloud reports levels.
+	EpicLevel int32
+	// ParentLinkField is the custom field ID (e.g., "customfield_10500") of
+	// the Advanced Roadmaps Parent Link on Jira Data Center
+	ParentLinkField string
+}
+
+// DefaultHierarchy treats Epic issues, and anything Jira Cloud places at the
+// epic level or above, as epics
+func DefaultHierarchy() Hierarchy {
+	return Hierarchy{
+		EpicTypes: []string{"Epic"},
+		EpicLevel: 1,
+	}
+}
+
+// IsEpic reports whether an issue type sits at the epic level or above
+func (h *Hierarchy) IsEpic(issueType *pb.IssueType) bool {
+	if issueType == nil {
+		return false
+	}
+	if h.EpicLevel > 0 && issueType.HierarchyLevel >= h.EpicLevel {
+		return true
+	}
+	for _, name := range h.EpicTypes {
+		if strings.EqualFold(name, issueType.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// childrenJQL returns queries finding the children of an epic-level issue,
+// with and without the Epic Link field, which newer Jira Cloud sites and
+// team-managed projects don't have
+func (h *Hierarchy) childrenJQL(epicKey string) (string, string) {
+	parent := fmt.Sprintf("parent = %s", epicKey)
+	clauses := []string{parent, fmt.Sprintf(`"Epic Link" = %s`, epicKey)}
+	fallback := []string{parent}
+
+	if id := strings.TrimPrefix(h.ParentLinkField, "customfield_"); id != "" {
+		parentLink := fmt.Sprintf("cf[%s] = %s", id, epicKey)
+		clauses = append(clauses, parentLink)
+		fallback = append(fallback, parentLink)
+	}
+
+	return strings.Join(clauses, " OR "), strings.Join(fallback, " OR ")
+}
@@ -0,0 +1,53 @@
+package jira
+
+import (
+	"testing"
+
+	pb "github.com/conallob/jira-beads-sync/gen/jira"
+)
+
+func TestHierarchyIsEpic(t *testing.T) {
+	tests := []struct {
+		name      string
+		hierarchy Hierarchy
+		issueType *pb.IssueType
+		want      bool
+	}{
+		{"default epic", DefaultHierarchy(), &pb.IssueType{Name: "Epic"}, true},
+		{"default story", DefaultHierarchy(), &pb.IssueType{Name: "Story"}, false},
+		{"default by level", DefaultHierarchy(), &pb.IssueType{Name: "Initiative", HierarchyLevel: 2}, true},
+		{"default subtask level", DefaultHierarchy(), &pb.IssueType{Name: "Sub-task", HierarchyLevel: -1}, false},
+		{"configured type", Hierarchy{EpicTypes: []string{"Epic", "theme"}}, &pb.IssueType{Name: "Theme"}, true},
+		{"level disabled", Hierarchy{EpicTypes: []string{"Epic"}}, &pb.IssueType{Name: "Initiative", HierarchyLevel: 2}, false},
+		{"raised level", Hierarchy{EpicLevel: 2}, &pb.IssueType{Name: "Epic", HierarchyLevel: 1}, false},
+		{"nil type", DefaultHierarchy(), nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hierarchy.IsEpic(tt.issueType); got != tt.want {
+				t.Errorf("IsEpic(%v) = %v, want %v", tt.issueType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHierarchyChildrenJQL(t *testing.T) {
+	hierarchy := DefaultHierarchy()
+	jql, fallback := hierarchy.childrenJQL("EPIC-1")
+	if jql != `parent = EPIC-1 OR "Epic Link" = EPIC-1` {
+		t.Errorf("Unexpected query %q", jql)
+	}
+	if fallback != "parent = EPIC-1" {
+		t.Errorf("Unexpected fallback %q", fallback)
+	}
+
+	hierarchy.ParentLinkField = "customfield_10500"
+	jql, fallback = hierarchy.childrenJQL("INIT-1")
+	if jql != `parent = INIT-1 OR "Epic Link" = INIT-1 OR cf[10500] = INIT-1` {
+		t.Errorf("Unexpected query %q", jql)
+	}
+	if fallback != "parent = INIT-1 OR cf[10500] = INIT-1" {
+		t.Errorf("Unexpected fallback %q", fallback)
+	}
+}
@@ -0,0 +1,247 @@
+package jira
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "github.com/conallob/jira-beads-sync/gen/jira"
+)
+
+// LinkDirection selects which side of an issue link is followed
+type LinkDirection int
+
+const (
+	LinkDirectionBoth    LinkDirection = iota // Follow inward and outward links
+	LinkDirectionInward                       // Follow only inward links (e.g., "is blocked by")
+	LinkDirectionOutward                      // Follow only outward links (e.g., "blocks")
+)
+
+// ParseLinkDirection parses "both", "inward" or "outward"; empty means both
+func ParseLinkDirection(s string) (LinkDirection, error) {
+	switch strings.ToLower(s) {
+	case "", "both":
+		return LinkDirectionBoth, nil
+	case "inward":
+		return LinkDirectionInward, nil
+	case "outward":
+		return LinkDirectionOutward, nil
+	default:
+		return LinkDirectionBoth, fmt.Errorf("invalid link direction %q (use inward, outward or both)", s)
+	}
+}
+
+// TraversalOptions limits how far dependency crawling reaches from the
+// requested issues. The zero value follows everything without limit.
+type TraversalOptions struct {
+	// MaxDepth is the number of hops to follow from a requested issue;
+	// 0 means unlimited
+	MaxDepth int
+	// LinkTypes restricts which issue link types (e.g., "Blocks") are
+	// followed, compared case-insensitively; empty follows all types
+	LinkTypes []string
+	// Direction restricts which side of issue links is followed
+	Direction LinkDirection
+	// Projects restricts crawling to issues in these project keys; requested
+	// issues are always fetched. Empty allows all projects.
+	Projects []string
+}
+
+// SetTraversalOptions sets the limits applied when fetching dependencies
+func (c *Client) SetTraversalOptions(opts TraversalOptions) {
+	c.traversal = opts
+}
+
+// followsLinkType reports whether links of the given type are followed
+func (o *TraversalOptions) followsLinkType(linkType *pb.IssueLinkType) bool {
+	if len(o.LinkTypes) == 0 {
+		return true
+	}
+	if linkType == nil {
+		return false
+	}
+	for _, name := range o.LinkTypes {
+		if strings.EqualFold(name, linkType.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsProject reports whether an issue key is within the project allow-list
+func (o *TraversalOptions) allowsProject(issueKey string) bool {
+	if len(o.Projects) == 0 {
+		return true
+	}
+	project := issueKey
+	if idx := strings.LastIndex(issueKey, "-"); idx > 0 {
+		project = issueKey[:idx]
+	}
+	for _, allowed := range o.Projects {
+		if strings.EqualFold(allowed, project) {
+			return true
+		}
+	}
+	return false
+}
+
+// crawlItem is an issue waiting to be fetched
+type crawlItem struct {
+	key          string
+	depth        int
+	referencedBy string // Empty for requested issues
+	// expand is set for requested issues and the children found under
+	// them, whose own children are fetched if they're epics. Epics reached
+	// any other way, such as a story's parent, aren't expanded, so fetching
+	// a story doesn't pull in every other story in its epic.
+	expand bool
+}
+
+// fetchAll fetches the given issues and their dependencies breadth-first,
+// so depth limits measure the shortest path from a requested issue.
+// References that fall outside the traversal limits, or that can't be
+// fetched because they don't exist or aren't visible, are reported in the
+// export's Skipped list. Failing to fetch a requested issue is an error.
+func (c *Client) fetchAll(issueKeys []string) (*pb.Export, error) {
+	opts := c.traversal
+	visited := make(map[string]bool)
+	skipped := make(map[string]*pb.SkippedIssue)
+	issues := make([]*pb.Issue, 0)
+
+	queue := make([]crawlItem, 0, len(issueKeys))
+	for _, key := range issueKeys {
+		queue = append(queue, crawlItem{key: key, expand: true})
+	}
+
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+
+		if visited[item.key] {
+			continue
+		}
+		if err := c.context().Err(); err != nil {
+			return nil, err
+		}
+
+		// The total grows as references are discovered
+		c.reporter.Progress("Fetching issues", len(issues), len(issues)+len(queue)+1)
+		c.reporter.Debug("Fetching issue", "key", item.key)
+		visited[item.key] = true
+
+		issue, err := c.FetchIssue(item.key)
+		if err != nil {
+			if reason := unfetchableReason(err); reason != "" && item.referencedBy != "" {
+				c.reporter.Warn("Skipping unfetchable issue", "key", item.key, "reason", reason)
+				skipped[item.key] = &pb.SkippedIssue{Key: item.key, ReferencedBy: item.referencedBy, Reason: reason, Failed: true}
+				continue
+			}
+			return nil, fmt.Errorf("failed to fetch %s: %w", item.key, err)
+		}
+
+		issues = append(issues, issue)
+
+		// follow queues a related issue unless it is outside the limits;
+		// expand marks an epic's children
+		follow := func(key string, expand bool) {
+			if visited[key] {
+				return
+			}
+			var reason string
+			switch {
+			case opts.MaxDepth > 0 && item.depth+1 > opts.MaxDepth:
+				reason = fmt.Sprintf("beyond max depth %d", opts.MaxDepth)
+			case !opts.allowsProject(key):
+				reason = "outside allowed projects"
+			}
+			if reason != "" {
+				if _, exists := skipped[key]; !exists {
+					skipped[key] = &pb.SkippedIssue{Key: key, ReferencedBy: issue.Key, Reason: reason}
+				}
+				return
+			}
+			queue = append(queue, crawlItem{key: key, depth: item.depth + 1, referencedBy: issue.Key, expand: expand})
+		}
+
+		// Fetch subtasks
+		for _, subtask := range issue.Fields.Subtasks {
+			follow(subtask.Key, false)
+		}
+
+		// Fetch linked issues (dependencies)
+		for _, link := range issue.Fields.IssueLinks {
+			if !opts.followsLinkType(link.Type) {
+				continue
+			}
+			if link.InwardIssue != nil && opts.Direction != LinkDirectionOutward {
+				follow(link.InwardIssue.Key, false)
+			}
+			if link.OutwardIssue != nil && opts.Direction != LinkDirectionInward {
+				follow(link.OutwardIssue.Key, false)
+			}
+		}
+
+		// Fetch parent, including epics, so epics.jsonl is complete
+		if issue.Fields.Parent != nil {
+			follow(issue.Fields.Parent.Key, false)
+		}
+
+		// Older Jira versions reference epics via the Epic Link field instead
+		if issue.Fields.Epic != nil && issue.Fields.Epic.Key != "" {
+			follow(issue.Fields.Epic.Key, false)
+		}
+
+		// Epic children reference their epic but aren't listed on it, so
+		// they have to be searched for
+		if item.expand && c.hierarchy.IsEpic(issue.Fields.IssueType) {
+			children, err := c.searchEpicChildren(issue.Key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find children of epic %s: %w", issue.Key, err)
+			}
+			for _, child := range children {
+				follow(child, true)
+			}
+		}
+	}
+
+	c.reporter.Progress("Fetching issues", len(issues), len(issues))
+
+	// A reference skipped on one path may have been reached by a shorter one
+	export := &pb.Export{Issues: issues}
+	for key, skip := range skipped {
+		if !visited[key] || skip.Failed {
+			export.Skipped = append(export.Skipped, skip)
+		}
+	}
+	sort.Slice(export.Skipped, func(i, j int) bool {
+		return export.Skipped[i].Key < export.Skipped[j].Key
+	})
+
+	return export, nil
+}
+
+// searchEpicChildren returns the keys of issues in an epic or higher-level
+// issue. Team-managed projects and newer Jira Cloud sites don't have the
+// Epic Link field, so searching without it is the fallback.
+func (c *Client) searchEpicChildren(epicKey string) ([]string, error) {
+	jql, fallback := c.hierarchy.childrenJQL(epicKey)
+	keys, err := c.SearchIssues(jql)
+	if err == nil {
+		return keys, nil
+	}
+	return c.SearchIssues(fallback)
+}
+
+// unfetchableReason describes why a referenced issue can be skipped rather
+// than failing the whole fetch, or returns "" if it can't
+func unfetchableReason(err error) string {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return "not found"
+	case errors.Is(err, ErrForbidden):
+		return "no permission to view"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,277 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// newLinkedIssueServer serves issues whose "Blocks" and "Relates" links are
+// described by the given maps of key to outward-linked keys
+func newLinkedIssueServer(t *testing.T, blocks, relates map[string][]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/")
+		issue := createMinimalIssue(key, "Issue "+key)
+
+		linkTypes := []struct {
+			name    string
+			targets map[string][]string
+		}{{"Blocks", blocks}, {"Relates", relates}}
+
+		links := []map[string]interface{}{}
+		for _, linkType := range linkTypes {
+			for _, target := range linkType.targets[key] {
+				links = append(links, map[string]interface{}{
+					"type":         map[string]interface{}{"name": linkType.name},
+					"outwardIssue": map[string]interface{}{"key": target},
+				})
+			}
+		}
+		// Each issue also sees the reverse side of links pointing at it
+		for _, linkType := range linkTypes {
+			sources := make([]string, 0, len(linkType.targets))
+			for source := range linkType.targets {
+				sources = append(sources, source)
+			}
+			sort.Strings(sources)
+			for _, source := range sources {
+				for _, target := range linkType.targets[source] {
+					if target == key {
+						links = append(links, map[string]interface{}{
+							"type":        map[string]interface{}{"name": linkType.name},
+							"inwardIssue": map[string]interface{}{"key": source},
+						})
+					}
+				}
+			}
+		}
+		issue["fields"].(map[string]interface{})["issuelinks"] = links
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(issue); err != nil {
+			t.Errorf("Failed to encode response: %v", err)
+		}
+	}))
+}
+
+func TestFetchTraversalLimits(t *testing.T) {
+	// PROJ-1 blocks PROJ-2 blocks PROJ-3; PROJ-1 relates to PROJ-4;
+	// PROJ-5 blocks PROJ-1; PROJ-2 blocks OTHER-1
+	blocks := map[string][]string{
+		"PROJ-1": {"PROJ-2"},
+		"PROJ-2": {"PROJ-3", "OTHER-1"},
+		"PROJ-5": {"PROJ-1"},
+	}
+	relates := map[string][]string{
+		"PROJ-1": {"PROJ-4"},
+	}
+
+	tests := []struct {
+		name        string
+		opts        TraversalOptions
+		wantIssues  []string
+		wantSkipped []string
+	}{
+		{
+			name:       "unlimited",
+			opts:       TraversalOptions{},
+			wantIssues: []string{"PROJ-1", "PROJ-2", "PROJ-4", "PROJ-5", "PROJ-3", "OTHER-1"},
+		},
+		{
+			name:        "max depth",
+			opts:        TraversalOptions{MaxDepth: 1},
+			wantIssues:  []string{"PROJ-1", "PROJ-2", "PROJ-4", "PROJ-5"},
+			wantSkipped: []string{"OTHER-1", "PROJ-3"},
+		},
+		{
+			name:       "link types",
+			opts:       TraversalOptions{LinkTypes: []string{"blocks"}},
+			wantIssues: []string{"PROJ-1", "PROJ-2", "PROJ-5", "PROJ-3", "OTHER-1"},
+		},
+		{
+			name:       "outward only",
+			opts:       TraversalOptions{Direction: LinkDirectionOutward},
+			wantIssues: []string{"PROJ-1", "PROJ-2", "PROJ-4", "PROJ-3", "OTHER-1"},
+		},
+		{
+			name:       "inward only",
+			opts:       TraversalOptions{Direction: LinkDirectionInward},
+			wantIssues: []string{"PROJ-1", "PROJ-5"},
+		},
+		{
+			name:        "project allow-list",
+			opts:        TraversalOptions{Projects: []string{"proj"}},
+			wantIssues:  []string{"PROJ-1", "PROJ-2", "PROJ-4", "PROJ-5", "PROJ-3"},
+			wantSkipped: []string{"OTHER-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newLinkedIssueServer(t, blocks, relates)
+			defer server.Close()
+
+			client := NewClient(server.URL, "user@example.com", "token123")
+			client.SetTraversalOptions(tt.opts)
+
+			export, err := client.FetchIssueWithDependencies("PROJ-1")
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			var got []string
+			for _, issue := range export.Issues {
+				got = append(got, issue.Key)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantIssues, ",") {
+				t.Errorf("Expected issues %v, got %v", tt.wantIssues, got)
+			}
+
+			var skipped []string
+			for _, skip := range export.Skipped {
+				skipped = append(skipped, skip.Key)
+				if skip.ReferencedBy == "" || skip.Reason == "" {
+					t.Errorf("Expected skipped %s to record its referrer and reason", skip.Key)
+				}
+			}
+			if strings.Join(skipped, ",") != strings.Join(tt.wantSkipped, ",") {
+				t.Errorf("Expected skipped %v, got %v", tt.wantSkipped, skipped)
+			}
+		})
+	}
+}
+
+func TestFetchTraversalSkippedThenReached(t *testing.T) {
+	// PROJ-3 is beyond max depth via PROJ-2, but also linked directly from
+	// the root, so it must not be reported as skipped
+	blocks := map[string][]string{
+		"PROJ-1": {"PROJ-2", "PROJ-3"},
+		"PROJ-2": {"PROJ-3"},
+	}
+
+	server := newLinkedIssueServer(t, blocks, nil)
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+	client.SetTraversalOptions(TraversalOptions{MaxDepth: 1})
+
+	export, err := client.FetchIssueWithDependencies("PROJ-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(export.Issues) != 3 {
+		t.Errorf("Expected 3 issues, got %d", len(export.Issues))
+	}
+	if len(export.Skipped) != 0 {
+		t.Errorf("Expected no skipped issues, got %v", export.Skipped)
+	}
+}
+
+func TestFetchTraversalUnfetchable(t *testing.T) {
+	// PROJ-1 blocks an issue that was deleted and one the user can't see
+	blocks := map[string][]string{
+		"PROJ-1": {"GONE-1", "SECRET-1", "PROJ-2"},
+	}
+
+	linked := newLinkedIssueServer(t, blocks, nil)
+	handler := linked.Config.Handler
+	linked.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/") {
+		case "GONE-1", "MISSING-1":
+			http.Error(w, `{"errorMessages":["Issue does not exist"]}`, http.StatusNotFound)
+		case "SECRET-1":
+			http.Error(w, `{"errorMessages":["No permission"]}`, http.StatusForbidden)
+		default:
+			handler.ServeHTTP(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+
+	export, err := client.FetchIssueWithDependencies("PROJ-1")
+	if err != nil {
+		t.Fatalf("Expected unfetchable references to be skipped, got: %v", err)
+	}
+	if len(export.Issues) != 2 {
+		t.Errorf("Expected PROJ-1 and PROJ-2, got %d issues", len(export.Issues))
+	}
+
+	reasons := make(map[string]string)
+	for _, skip := range export.Skipped {
+		if !skip.Failed || skip.ReferencedBy != "PROJ-1" {
+			t.Errorf("Expected %s to be a failed reference from PROJ-1, got %+v", skip.Key, skip)
+		}
+		reasons[skip.Key] = skip.Reason
+	}
+	if reasons["GONE-1"] != "not found" || reasons["SECRET-1"] != "no permission to view" {
+		t.Errorf("Unexpected skip reasons %v", reasons)
+	}
+
+	// A requested issue that can't be fetched is an error
+	_, err = client.FetchIssueWithDependencies("MISSING-1")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing requested issue, got %v", err)
+	}
+}
+
+func TestFetchTraversalCancelled(t *testing.T) {
+	blocks := map[string][]string{
+		"PROJ-1": {"PROJ-2"},
+		"PROJ-2": {"PROJ-3"},
+	}
+	server := newLinkedIssueServer(t, blocks, nil)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client := NewClient(server.URL, "user@example.com", "token123")
+	_, err := client.WithContext(ctx).FetchIssueWithDependencies("PROJ-1")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	// The original client is unaffected
+	export, err := client.FetchIssueWithDependencies("PROJ-1")
+	if err != nil {
+		t.Fatalf("FetchIssueWithDependencies failed: %v", err)
+	}
+	if len(export.Issues) != 3 {
+		t.Errorf("Expected 3 issues, got %d", len(export.Issues))
+	}
+}
+
+func TestParseLinkDirection(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    LinkDirection
+		wantErr bool
+	}{
+		{"", LinkDirectionBoth, false},
+		{"both", LinkDirectionBoth, false},
+		{"Inward", LinkDirectionInward, false},
+		{"outward", LinkDirectionOutward, false},
+		{"sideways", LinkDirectionBoth, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLinkDirection(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLinkDirection(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLinkDirection(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package jira
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// URLKind identifies what a Jira web URL points at
+type URLKind int
+
+const (
+	URLKindIssue   URLKind = iota // A single issue, e.g. /browse/PROJ-123
+	URLKindFilter                 // A saved filter, e.g. /issues/?filter=12345
+	URLKindJQL                    // An issue search, e.g. /issues/?jql=...
+	URLKindBoard                  // A board, whose active sprints are imported
+	URLKindSprint                 // A specific sprint on a board
+	URLKindBacklog                // A board's backlog
+)
+
+// URLTarget is the result of parsing a Jira web URL. Only the fields
+// relevant to Kind are set.
+type URLTarget struct {
+	Kind     URLKind
+	BaseURL  string
+	IssueKey string
+	FilterID string
+	JQL      string
+	BoardID  int
+	SprintID int64
+}
+
+// String describes the target for display
+func (t *URLTarget) String() string {
+	switch t.Kind {
+	case URLKindIssue:
+		return fmt.Sprintf("Issue key: %s", t.IssueKey)
+	case URLKindFilter:
+		return fmt.Sprintf("Filter: %s", t.FilterID)
+	case URLKindJQL:
+		return fmt.Sprintf("JQL: %s", t.JQL)
+	case URLKindBoard:
+		return fmt.Sprintf("Board: %d (active sprints)", t.BoardID)
+	case URLKindSprint:
+		return fmt.Sprintf("Sprint: %d (board %d)", t.SprintID, t.BoardID)
+	case URLKindBacklog:
+		return fmt.Sprintf("Backlog of board %d", t.BoardID)
+	default:
+		return "unknown"
+	}
+}
+
+// ParseURL works out what a Jira web URL refers to. It accepts:
+// - issue URLs: /browse/PROJ-123, /projects/PROJ/issues/PROJ-123
+// - saved filters: /issues/?filter=12345, /secure/IssueNavigator.jspa?requestId=12345
+// - searches: /issues/?jql=project%20%3D%20PROJ
+// - boards: /jira/software/projects/PROJ/boards/42, /secure/RapidBoard.jspa?rapidView=42
+// - sprints: a board URL with ?sprint=17
+// - backlogs: /jira/software/projects/PROJ/boards/42/backlog, RapidBoard.jspa?rapidView=42&view=planning
+func ParseURL(jiraURL string) (*URLTarget, error) {
+	u, err := url.Parse(jiraURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	target := &URLTarget{BaseURL: fmt.Sprintf("%s://%s", u.Scheme, u.Host)}
+	query := u.Query()
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	// Issue URLs take precedence over any query parameters they carry
+	if len(parts) >= 2 && parts[0] == "browse" && parts[1] != "" {
+		target.Kind = URLKindIssue
+		target.IssueKey = parts[1]
+		return target, nil
+	}
+	if len(parts) >= 4 && parts[0] == "projects" && parts[2] == "issues" && parts[3] != "" {
+		target.Kind = URLKindIssue
+		target.IssueKey = parts[3]
+		return target, nil
+	}
+
+	// An explicit JQL query wins over the filter it was derived from
+	if jql := query.Get("jql"); jql != "" {
+		target.Kind = URLKindJQL
+		target.JQL = jql
+		return target, nil
+	}
+	for _, param := range []string{"filter", "requestId"} {
+		if id := query.Get(param); id != "" {
+			if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+				return nil, fmt.Errorf("unsupported filter %q: only saved filters with a numeric ID can be imported", id)
+			}
+			target.Kind = URLKindFilter
+			target.FilterID = id
+			return target, nil
+		}
+	}
+
+	if boardID, backlog, ok := parseBoard(parts, query); ok {
+		target.BoardID = boardID
+		switch {
+		case query.Get("sprint") != "":
+			sprintID, err := strconv.ParseInt(query.Get("sprint"), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sprint ID in URL: %s", jiraURL)
+			}
+			target.Kind = URLKindSprint
+			target.SprintID = sprintID
+		case backlog:
+			target.Kind = URLKindBacklog
+		default:
+			target.Kind = URLKindBoard
+		}
+		return target, nil
+	}
+
+	issueKey, err := ParseIssueKeyFromURL(jiraURL)
+	if err != nil {
+		return nil, fmt.Errorf("unrecognised Jira URL: %s", jiraURL)
+	}
+	target.Kind = URLKindIssue
+	target.IssueKey = issueKey
+	return target, nil
+}
+
+// parseBoard extracts a board ID from Cloud (/boards/42) or Data Center
+// (RapidBoard.jspa?rapidView=42) board URLs, and whether it shows the backlog
+func parseBoard(parts []string, query url.Values) (int, bool, bool) {
+	for i, part := range parts {
+		if part == "boards" && i+1 < len(parts) {
+			boardID, err := strconv.Atoi(parts[i+1])
+			if err != nil {
+				return 0, false, false
+			}
+			backlog := i+2 < len(parts) && parts[i+2] == "backlog"
+			return boardID, backlog, true
+		}
+	}
+
+	if len(parts) > 0 && parts[len(parts)-1] == "RapidBoard.jspa" {
+		boardID, err := strconv.Atoi(query.Get("rapidView"))
+		if err != nil {
+			return 0, false, false
+		}
+		backlog := strings.HasPrefix(query.Get("view"), "planning")
+		return boardID, backlog, true
+	}
+
+	return 0, false, false
+}
@@ -0,0 +1,141 @@
+package jira
+
+import "testing"
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		want        URLTarget
+		expectError bool
+	}{
+		{
+			name: "browse URL",
+			url:  "https://jira.example.com/browse/PROJ-123",
+			want: URLTarget{Kind: URLKindIssue, BaseURL: "https://jira.example.com", IssueKey: "PROJ-123"},
+		},
+		{
+			name: "browse URL carrying a filter",
+			url:  "https://jira.example.com/browse/PROJ-123?filter=12345",
+			want: URLTarget{Kind: URLKindIssue, BaseURL: "https://jira.example.com", IssueKey: "PROJ-123"},
+		},
+		{
+			name: "projects URL",
+			url:  "https://jira.example.com/projects/PROJ/issues/PROJ-123",
+			want: URLTarget{Kind: URLKindIssue, BaseURL: "https://jira.example.com", IssueKey: "PROJ-123"},
+		},
+		{
+			name: "saved filter",
+			url:  "https://jira.example.com/issues/?filter=12345",
+			want: URLTarget{Kind: URLKindFilter, BaseURL: "https://jira.example.com", FilterID: "12345"},
+		},
+		{
+			name: "project issue navigator with filter",
+			url:  "https://acme.atlassian.net/projects/PROJ/issues/?filter=777",
+			want: URLTarget{Kind: URLKindFilter, BaseURL: "https://acme.atlassian.net", FilterID: "777"},
+		},
+		{
+			name: "legacy issue navigator filter",
+			url:  "https://jira.example.com/secure/IssueNavigator.jspa?mode=hide&requestId=10400",
+			want: URLTarget{Kind: URLKindFilter, BaseURL: "https://jira.example.com", FilterID: "10400"},
+		},
+		{
+			name:        "system filter",
+			url:         "https://jira.example.com/issues/?filter=-1",
+			expectError: true,
+		},
+		{
+			name: "JQL search",
+			url:  "https://jira.example.com/issues/?jql=project%20%3D%20PROJ%20AND%20labels%20%3D%20urgent",
+			want: URLTarget{Kind: URLKindJQL, BaseURL: "https://jira.example.com", JQL: "project = PROJ AND labels = urgent"},
+		},
+		{
+			name: "modified filter prefers JQL",
+			url:  "https://jira.example.com/issues/?filter=12345&jql=project%20%3D%20PROJ",
+			want: URLTarget{Kind: URLKindJQL, BaseURL: "https://jira.example.com", JQL: "project = PROJ"},
+		},
+		{
+			name: "cloud board",
+			url:  "https://acme.atlassian.net/jira/software/projects/PROJ/boards/42",
+			want: URLTarget{Kind: URLKindBoard, BaseURL: "https://acme.atlassian.net", BoardID: 42},
+		},
+		{
+			name: "cloud company-managed backlog",
+			url:  "https://acme.atlassian.net/jira/software/c/projects/PROJ/boards/42/backlog",
+			want: URLTarget{Kind: URLKindBacklog, BaseURL: "https://acme.atlassian.net", BoardID: 42},
+		},
+		{
+			name: "cloud board with sprint",
+			url:  "https://acme.atlassian.net/jira/software/projects/PROJ/boards/42?sprint=17",
+			want: URLTarget{Kind: URLKindSprint, BaseURL: "https://acme.atlassian.net", BoardID: 42, SprintID: 17},
+		},
+		{
+			name: "data center rapid board",
+			url:  "https://jira.example.com/secure/RapidBoard.jspa?rapidView=42",
+			want: URLTarget{Kind: URLKindBoard, BaseURL: "https://jira.example.com", BoardID: 42},
+		},
+		{
+			name: "data center backlog",
+			url:  "https://jira.example.com/secure/RapidBoard.jspa?rapidView=42&view=planning.nodetail",
+			want: URLTarget{Kind: URLKindBacklog, BaseURL: "https://jira.example.com", BoardID: 42},
+		},
+		{
+			name: "data center sprint",
+			url:  "https://jira.example.com/secure/RapidBoard.jspa?rapidView=42&sprint=99",
+			want: URLTarget{Kind: URLKindSprint, BaseURL: "https://jira.example.com", BoardID: 42, SprintID: 99},
+		},
+		{
+			name:        "invalid sprint",
+			url:         "https://jira.example.com/secure/RapidBoard.jspa?rapidView=42&sprint=abc",
+			expectError: true,
+		},
+		{
+			name: "issue key fallback",
+			url:  "https://jira.example.com/some/path/PROJ-456",
+			want: URLTarget{Kind: URLKindIssue, BaseURL: "https://jira.example.com", IssueKey: "PROJ-456"},
+		},
+		{
+			name:        "unrecognised URL",
+			url:         "https://jira.example.com/dashboard",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseURL(tt.url)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %s, got %+v", tt.url, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("ParseURL(%s) = %+v, want %+v", tt.url, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLTargetString(t *testing.T) {
+	tests := []struct {
+		target URLTarget
+		want   string
+	}{
+		{URLTarget{Kind: URLKindIssue, IssueKey: "PROJ-1"}, "Issue key: PROJ-1"},
+		{URLTarget{Kind: URLKindFilter, FilterID: "12"}, "Filter: 12"},
+		{URLTarget{Kind: URLKindJQL, JQL: "project = X"}, "JQL: project = X"},
+		{URLTarget{Kind: URLKindBoard, BoardID: 4}, "Board: 4 (active sprints)"},
+		{URLTarget{Kind: URLKindSprint, BoardID: 4, SprintID: 9}, "Sprint: 9 (board 4)"},
+		{URLTarget{Kind: URLKindBacklog, BoardID: 4}, "Backlog of board 4"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.target.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Package keyring stores secrets in the operating system's keyring: the
+// Secret Service on Linux (via secret-tool) and the login keychain on macOS
+// (via security)
+package keyring
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Service identifies jira-beads-sync secrets in the keyring
+const Service = "jira-beads-sync"
+
+// ErrNotFound is returned when the keyring holds no matching secret
+var ErrNotFound = errors.New("secret not found in keyring")
+
+// ErrUnsupported is returned on platforms without a supported keyring tool
+var ErrUnsupported = fmt.Errorf("no supported keyring on %s (requires secret-tool or security)", runtime.GOOS)
+
+// goos and runCommand are variables so tests can replace them
+var (
+	goos       = runtime.GOOS
+	runCommand = run
+)
+
+// Get returns the secret stored for an account on a Jira host
+func Get(host, account string) (string, error) {
+	var out string
+	var err error
+
+	switch goos {
+	case "linux", "freebsd", "openbsd":
+		out, err = runCommand("", "secret-tool", "lookup", "service", Service, "host", host, "account", account)
+	case "darwin":
+		out, err = runCommand("", "security", "find-generic-password", "-s", darwinService(host), "-a", account, "-w")
+	default:
+		return "", ErrUnsupported
+	}
+
+	secret := strings.TrimRight(out, "\r\n")
+	var cmdErr *commandError
+	if (err == nil && secret == "") || (errors.As(err, &cmdErr) && cmdErr.notFound()) {
+		return "", fmt.Errorf("%w for %s on %s", ErrNotFound, account, host)
+	}
+	if err != nil {
+		// e.g. a locked keychain, or the tool isn't installed
+		return "", fmt.Errorf("failed to read secret from keyring: %w", err)
+	}
+
+	return secret, nil
+}
+
+// Set stores the secret for an account on a Jira host, replacing any
+// existing one
+func Set(host, account, secret string) error {
+	var err error
+
+	switch goos {
+	case "linux", "freebsd", "openbsd":
+		label := fmt.Sprintf("%s (%s on %s)", Service, account, host)
+		_, err = runCommand(secret, "secret-tool", "store", "--label", label, "service", Service, "host", host, "account", account)
+	case "darwin":
+		// Interactive mode reads the command from stdin, keeping the secret
+		// out of the arguments any local user can list
+		command := fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+			quote(darwinService(host)), quote(account), hex.EncodeToString([]byte(secret)))
+		_, err = runCommand(command, "security", "-i")
+	default:
+		return ErrUnsupported
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to store secret in keyring: %w", err)
+	}
+
+	return nil
+}
+
+// Available reports whether a keyring tool is installed
+func Available() bool {
+	switch goos {
+	case "linux", "freebsd", "openbsd":
+		_, err := exec.LookPath("secret-tool")
+		return err == nil
+	case "darwin":
+		_, err := exec.LookPath("security")
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// darwinService names keychain items, which have no separate host attribute
+func darwinService(host string) string {
+	return fmt.Sprintf("%s:%s", Service, host)
+}
+
+// quote single-quotes an argument for security's interactive mode
+func quote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'"'"'`) + "'"
+}
+
+// commandError is a keyring tool that failed to run or exited non-zero
+type commandError struct {
+	Name   string
+	Code   int // Exit code, or -1 if the tool didn't run
+	Stderr string
+	Err    error
+}
+
+func (e *commandError) Error() string {
+	if e.Stderr != "" {
+		return fmt.Sprintf("%s: %v: %s", e.Name, e.Err, e.Stderr)
+	}
+	return fmt.Sprintf("%s: %v", e.Name, e.Err)
+}
+
+func (e *commandError) Unwrap() error {
+	return e.Err
+}
+
+// notFound reports whether the tool exited because nothing matched:
+// secret-tool exits 1 without a message, and security exits 44
+// (errSecItemNotFound)
+func (e *commandError) notFound() bool {
+	switch e.Name {
+	case "secret-tool":
+		return e.Code == 1 && e.Stderr == ""
+	case "security":
+		return e.Code == 44
+	default:
+		return false
+	}
+}
+
+// run executes a program with the given stdin and returns its stdout
+func run(stdin, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		cmdErr := &commandError{Name: name, Code: -1, Stderr: strings.TrimSpace(stderr.String()), Err: err}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			cmdErr.Code = exitErr.ExitCode()
+		}
+		return "", cmdErr
+	}
+
+	return stdout.String(), nil
+}
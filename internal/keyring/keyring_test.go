@@ -0,0 +1,119 @@
+package keyring
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeKeyring records commands and serves secrets from memory
+type fakeKeyring struct {
+	secrets  map[string]string
+	commands []string
+}
+
+func (f *fakeKeyring) run(stdin, name string, args ...string) (string, error) {
+	f.commands = append(f.commands, name+" "+strings.Join(args, " "))
+
+	switch {
+	case name == "secret-tool" && args[0] == "store":
+		f.secrets[args[len(args)-3]+"/"+args[len(args)-1]] = stdin
+		return "", nil
+	case name == "secret-tool" && args[0] == "lookup":
+		if secret, ok := f.secrets[args[len(args)-3]+"/"+args[len(args)-1]]; ok {
+			return secret, nil
+		}
+		return "", &commandError{Name: name, Code: 1, Err: fmt.Errorf("exit status 1")}
+	case name == "security" && args[0] == "-i":
+		// add-generic-password -U -s 'service' -a 'account' -X hex
+		fields := strings.Fields(stdin)
+		secret, err := hex.DecodeString(fields[7])
+		if err != nil {
+			return "", err
+		}
+		f.secrets[strings.Trim(fields[3], "'")+"/"+strings.Trim(fields[5], "'")] = string(secret)
+		return "", nil
+	case name == "security" && args[0] == "find-generic-password":
+		if secret, ok := f.secrets[args[2]+"/"+args[4]]; ok {
+			return secret + "\n", nil
+		}
+		return "", &commandError{Name: name, Code: 44, Err: fmt.Errorf("exit status 44")}
+	}
+	return "", fmt.Errorf("unexpected command %s", name)
+}
+
+func useFakeKeyring(t *testing.T, platform string) *fakeKeyring {
+	fake := &fakeKeyring{secrets: make(map[string]string)}
+
+	originalGOOS, originalRun := goos, runCommand
+	t.Cleanup(func() { goos, runCommand = originalGOOS, originalRun })
+	goos, runCommand = platform, fake.run
+
+	return fake
+}
+
+func TestKeyringRoundTrip(t *testing.T) {
+	for _, platform := range []string{"linux", "darwin"} {
+		t.Run(platform, func(t *testing.T) {
+			fake := useFakeKeyring(t, platform)
+
+			if err := Set("acme.atlassian.net", "user@example.com", "token123"); err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+
+			secret, err := Get("acme.atlassian.net", "user@example.com")
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			if secret != "token123" {
+				t.Errorf("Expected token123, got %q", secret)
+			}
+
+			// The secret must never be passed on the command line, where
+			// any local user can see it
+			if strings.Contains(fake.commands[0], "token123") || strings.Contains(fake.commands[0], hex.EncodeToString([]byte("token123"))) {
+				t.Errorf("Secret leaked into arguments: %s", fake.commands[0])
+			}
+
+			if _, err := Get("other.atlassian.net", "user@example.com"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound for another host, got %v", err)
+			}
+		})
+	}
+}
+
+func TestKeyringGetErrors(t *testing.T) {
+	tests := []struct {
+		platform string
+		err      *commandError
+	}{
+		{"linux", &commandError{Name: "secret-tool", Code: -1, Err: fmt.Errorf("executable file not found")}},
+		{"linux", &commandError{Name: "secret-tool", Code: 1, Stderr: "Cannot unlock the collection", Err: fmt.Errorf("exit status 1")}},
+		{"darwin", &commandError{Name: "security", Code: 36, Stderr: "User interaction is not allowed.", Err: fmt.Errorf("exit status 36")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			useFakeKeyring(t, tt.platform)
+			runCommand = func(string, string, ...string) (string, error) { return "", tt.err }
+
+			_, err := Get("acme.atlassian.net", "user")
+			if err == nil || errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected a failure other than ErrNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestKeyringUnsupported(t *testing.T) {
+	useFakeKeyring(t, "plan9")
+
+	if _, err := Get("acme.atlassian.net", "user"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+	if err := Set("acme.atlassian.net", "user", "secret"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+}
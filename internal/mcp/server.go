@@ -0,0 +1,277 @@
+// Package mcp implements a Model Context Protocol server over stdio,
+// exposing tools to agents as newline-delimited JSON-RPC 2.0
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/conallob/jira-beads-sync/internal/progress"
+)
+
+// ProtocolVersion is the latest MCP revision the server implements
+const ProtocolVersion = "2025-06-18"
+
+// supportedVersions are the revisions a client may ask for; others are
+// answered with ProtocolVersion
+var supportedVersions = map[string]bool{
+	"2024-11-05":    true,
+	"2025-03-26":    true,
+	ProtocolVersion: true,
+}
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Tool is an operation exposed to clients
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+
+	// Handler runs the tool with the client's arguments. Its result, which
+	// must encode as a JSON object, is returned as structured content;
+	// errors are returned as tool errors the agent can act on.
+	Handler func(ctx context.Context, args json.RawMessage) (interface{}, error) `json:"-"`
+}
+
+// Server answers MCP requests with a fixed set of tools
+type Server struct {
+	name     string
+	version  string
+	tools    []*Tool
+	byName   map[string]*Tool
+	reporter progress.Reporter
+}
+
+// NewServer creates a server identifying itself with name and version
+func NewServer(name, version string, tools []*Tool) *Server {
+	byName := make(map[string]*Tool, len(tools))
+	for _, tool := range tools {
+		byName[tool.Name] = tool
+	}
+	return &Server{
+		name:     name,
+		version:  version,
+		tools:    tools,
+		byName:   byName,
+		reporter: progress.Nop{},
+	}
+}
+
+// SetReporter sets where the server logs the requests it handles
+func (s *Server) SetReporter(reporter progress.Reporter) {
+	s.reporter = progress.OrNop(reporter)
+}
+
+// request is a JSON-RPC request, or a notification when ID is absent
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is a JSON-RPC response carrying either a result or an error
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC error
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// Serve reads requests from r and writes responses to w, one per line,
+// until r is exhausted or ctx is done. Requests are handled in order.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		resp := s.handle(ctx, line)
+		if resp == nil {
+			continue
+		}
+		if err := s.write(w, resp); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// write encodes a response as a single line
+func (s *Server) write(w io.Writer, resp *response) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+	return nil
+}
+
+// handle answers one message, returning nil for notifications
+func (s *Server) handle(ctx context.Context, line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"),
+			Error: &rpcError{Code: codeParseError, Message: "invalid JSON: " + err.Error()}}
+	}
+
+	notification := len(req.ID) == 0
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if notification {
+			return nil
+		}
+		return &response{JSONRPC: "2.0", ID: req.ID,
+			Error: &rpcError{Code: codeInvalidRequest, Message: "not a JSON-RPC 2.0 request"}}
+	}
+
+	s.reporter.Debug("MCP request", "method", req.Method)
+	result, err := s.dispatch(ctx, &req)
+	if notification {
+		return nil
+	}
+
+	resp := &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		resp.Result = nil
+		resp.Error = rpcErr
+	}
+	return resp
+}
+
+// dispatch runs a method
+func (s *Server) dispatch(ctx context.Context, req *request) (interface{}, error) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": s.tools}, nil
+	case "tools/call":
+		return s.callTool(ctx, req.Params)
+	default:
+		// Notifications such as notifications/initialized need no action
+		return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+	}
+}
+
+// initialize agrees a protocol version and advertises the tools capability
+func (s *Server) initialize(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid initialize params: %w", err)
+		}
+	}
+
+	version := ProtocolVersion
+	if supportedVersions[p.ProtocolVersion] {
+		version = p.ProtocolVersion
+	}
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+		"serverInfo":      map[string]string{"name": s.name, "version": s.version},
+	}, nil
+}
+
+// toolResult is the result of tools/call
+type toolResult struct {
+	Content           []textContent `json:"content"`
+	StructuredContent interface{}   `json:"structuredContent,omitempty"`
+	IsError           bool          `json:"isError,omitempty"`
+}
+
+// textContent is a text content block
+type textContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// callTool runs a tool. Tool failures are results with IsError set, so the
+// agent sees them; only unknown tools and malformed calls are errors.
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid tools/call params: %w", err)
+	}
+	tool, ok := s.byName[p.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tool %q", p.Name)
+	}
+	if len(p.Arguments) == 0 || string(p.Arguments) == "null" {
+		p.Arguments = json.RawMessage("{}")
+	}
+
+	s.reporter.Info("Running tool", "tool", p.Name)
+	value, err := tool.Handler(ctx, p.Arguments)
+	if err != nil {
+		s.reporter.Warn("Tool failed", "tool", p.Name, "error", err)
+		return &toolResult{Content: []textContent{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+
+	// Clients without structured content support read the text block
+	text, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s result: %w", p.Name, err)
+	}
+	return &toolResult{
+		Content:           []textContent{{Type: "text", Text: string(text)}},
+		StructuredContent: value,
+	}, nil
+}
+
+// DecodeArgs decodes a tool's arguments, rejecting unknown fields so typos
+// are reported rather than ignored
+func DecodeArgs(args json.RawMessage, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(args))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newTestServer has an echo tool, returning its message, and a failing tool
+func newTestServer() *Server {
+	return NewServer("test", "v1.0.0", []*Tool{
+		{
+			Name:        "echo",
+			Description: "Echo a message",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"message":{"type":"string"}}}`),
+			Handler: func(ctx context.Context, args json.RawMessage) (interface{}, error) {
+				var params struct {
+					Message string `json:"message"`
+				}
+				if err := DecodeArgs(args, &params); err != nil {
+					return nil, err
+				}
+				return map[string]string{"message": params.Message}, nil
+			},
+		},
+		{
+			Name:        "fail",
+			Description: "Always fail",
+			InputSchema: json.RawMessage(`{"type":"object"}`),
+			Handler: func(ctx context.Context, args json.RawMessage) (interface{}, error) {
+				return nil, errors.New("something broke")
+			},
+		},
+	})
+}
+
+// serve sends requests, one per line, and decodes the responses
+func serve(t *testing.T, requests ...string) []map[string]interface{} {
+	t.Helper()
+	var out bytes.Buffer
+	in := strings.NewReader(strings.Join(requests, "\n") + "\n")
+	if err := newTestServer().Serve(context.Background(), in, &out); err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+
+	var responses []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var resp map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("Invalid response %q: %v", line, err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestServeInitialize(t *testing.T) {
+	responses := serve(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`,
+		`{"jsonrpc":"2.0","id":"p","method":"ping"}`,
+	)
+	if len(responses) != 3 {
+		t.Fatalf("Expected no response to the notification, got %d responses", len(responses))
+	}
+
+	result := responses[0]["result"].(map[string]interface{})
+	if result["protocolVersion"] != "2024-11-05" {
+		t.Errorf("Expected the requested version, got %v", result["protocolVersion"])
+	}
+	if info := result["serverInfo"].(map[string]interface{}); info["name"] != "test" || info["version"] != "v1.0.0" {
+		t.Errorf("Unexpected server info: %v", info)
+	}
+
+	result = responses[1]["result"].(map[string]interface{})
+	if result["protocolVersion"] != ProtocolVersion {
+		t.Errorf("Expected %s for an unsupported version, got %v", ProtocolVersion, result["protocolVersion"])
+	}
+
+	if responses[2]["id"] != "p" || responses[2]["result"] == nil {
+		t.Errorf("Expected an empty ping result with the string ID, got %v", responses[2])
+	}
+}
+
+func TestServeTools(t *testing.T) {
+	responses := serve(t,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"message":"hi"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"fail","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"echo","arguments":{"mesage":"typo"}}}`,
+	)
+	if len(responses) != 4 {
+		t.Fatalf("Expected 4 responses, got %d", len(responses))
+	}
+
+	tools := responses[0]["result"].(map[string]interface{})["tools"].([]interface{})
+	if len(tools) != 2 || tools[0].(map[string]interface{})["inputSchema"] == nil {
+		t.Errorf("Expected both tools with schemas, got %v", tools)
+	}
+
+	result := responses[1]["result"].(map[string]interface{})
+	if structured := result["structuredContent"].(map[string]interface{}); structured["message"] != "hi" {
+		t.Errorf("Expected structured content echoing the message, got %v", result)
+	}
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != `{"message":"hi"}` {
+		t.Errorf("Expected the result as text too, got %v", text)
+	}
+
+	for _, resp := range responses[2:] {
+		result := resp["result"].(map[string]interface{})
+		if result["isError"] != true {
+			t.Errorf("Expected a tool error, got %v", resp)
+		}
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		request string
+		code    float64
+	}{
+		{"invalid JSON", `{"jsonrpc":`, codeParseError},
+		{"not JSON-RPC 2.0", `{"jsonrpc":"1.0","id":1,"method":"ping"}`, codeInvalidRequest},
+		{"unknown method", `{"jsonrpc":"2.0","id":1,"method":"resources/list"}`, codeMethodNotFound},
+		{"unknown tool", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"nope"}}`, codeInvalidParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses := serve(t, tt.request)
+			if len(responses) != 1 {
+				t.Fatalf("Expected 1 response, got %d", len(responses))
+			}
+			rpcErr, ok := responses[0]["error"].(map[string]interface{})
+			if !ok || rpcErr["code"] != tt.code {
+				t.Errorf("Expected error code %v, got %v", tt.code, responses[0])
+			}
+		})
+	}
+}
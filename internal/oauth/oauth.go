@@ -0,0 +1,398 @@
+// Package oauth implements the Atlassian OAuth 2.0 (3LO) authorization code
+// flow, with refresh tokens kept in a store and refreshed on demand
+package oauth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Atlassian OAuth endpoints
+const (
+	DefaultAuthURL      = "https://auth.atlassian.com/authorize"
+	DefaultTokenURL     = "https://auth.atlassian.com/oauth/token"
+	DefaultResourcesURL = "https://api.atlassian.com/oauth/token/accessible-resources"
+	DefaultCallbackURL  = "http://localhost:8765/callback"
+
+	// APIBaseURL is the gateway OAuth requests go through, followed by the
+	// site's cloud ID
+	APIBaseURL = "https://api.atlassian.com/ex/jira"
+)
+
+// DefaultScopes cover reading and updating issues, plus offline_access for
+// refresh tokens
+var DefaultScopes = []string{"read:jira-work", "write:jira-work", "read:jira-user", "offline_access"}
+
+// loginTimeout bounds how long Login waits for the browser callback
+var loginTimeout = 5 * time.Minute
+
+// Config identifies an OAuth app registered in the Atlassian developer console
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	CallbackURL  string   // Must match the app's registered callback URL
+	Scopes       []string // Defaults to DefaultScopes
+
+	// Endpoints, overridable for testing
+	AuthURL      string
+	TokenURL     string
+	ResourcesURL string
+
+	HTTPClient *http.Client
+}
+
+// Token is an access token with the refresh token used to renew it and the
+// Jira site it grants access to
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry"`
+	CloudID      string    `json:"cloud_id"`
+	SiteURL      string    `json:"site_url"`
+}
+
+// Valid reports whether the access token can be used without refreshing
+func (t *Token) Valid() bool {
+	return t.AccessToken != "" && time.Now().Add(time.Minute).Before(t.Expiry)
+}
+
+// APIBaseURL returns the base URL for Jira REST requests made with the token
+func (t *Token) APIBaseURL() string {
+	return fmt.Sprintf("%s/%s", APIBaseURL, t.CloudID)
+}
+
+// NewConfig creates a config with the Atlassian endpoints and defaults
+func NewConfig(clientID, clientSecret, callbackURL string, scopes []string) *Config {
+	if callbackURL == "" {
+		callbackURL = DefaultCallbackURL
+	}
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+	return &Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		CallbackURL:  callbackURL,
+		Scopes:       scopes,
+		AuthURL:      DefaultAuthURL,
+		TokenURL:     DefaultTokenURL,
+		ResourcesURL: DefaultResourcesURL,
+		HTTPClient:   &http.Client{},
+	}
+}
+
+// AuthCodeURL returns the URL the user visits to grant access
+func (c *Config) AuthCodeURL(state string) string {
+	query := url.Values{}
+	query.Set("audience", "api.atlassian.com")
+	query.Set("client_id", c.ClientID)
+	query.Set("scope", strings.Join(c.Scopes, " "))
+	query.Set("redirect_uri", c.CallbackURL)
+	query.Set("state", state)
+	query.Set("response_type", "code")
+	query.Set("prompt", "consent")
+	return c.AuthURL + "?" + query.Encode()
+}
+
+// Login runs the authorization code flow: it listens on the loopback
+// callback URL, asks openBrowser to show the consent page, exchanges the
+// returned code for tokens and selects the site matching siteURL
+func (c *Config) Login(siteURL string, openBrowser func(string) error) (*Token, error) {
+	callback, err := url.Parse(c.CallbackURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid callback URL: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", callback.Host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", callback.Host, err)
+	}
+
+	state, err := randomState()
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(callback.Path, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		// Ignore requests that didn't come from this login
+		if query.Get("state") != state {
+			http.Error(w, "Invalid state", http.StatusBadRequest)
+			return
+		}
+
+		res := result{code: query.Get("code")}
+		if query.Get("error") != "" {
+			http.Error(w, "Authorization denied", http.StatusForbidden)
+			res = result{err: fmt.Errorf("authorization denied: %s", query.Get("error_description"))}
+		} else {
+			_, _ = fmt.Fprintln(w, "jira-beads-sync is authorized. You can close this window.")
+		}
+
+		select {
+		case results <- res:
+		default: // A result was already delivered
+		}
+	})
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = server.Serve(listener) }()
+	defer func() { _ = server.Close() }()
+
+	if err := openBrowser(c.AuthCodeURL(state)); err != nil {
+		return nil, fmt.Errorf("failed to open browser: %w", err)
+	}
+
+	var res result
+	select {
+	case res = <-results:
+	case <-time.After(loginTimeout):
+		return nil, fmt.Errorf("timed out waiting for authorization")
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	token, err := c.Exchange(res.code)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.selectSite(token, siteURL); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// Exchange trades an authorization code for tokens
+func (c *Config) Exchange(code string) (*Token, error) {
+	return c.requestToken(map[string]string{
+		"grant_type":    "authorization_code",
+		"client_id":     c.ClientID,
+		"client_secret": c.ClientSecret,
+		"code":          code,
+		"redirect_uri":  c.CallbackURL,
+	})
+}
+
+// Refresh obtains a new access token. Atlassian rotates refresh tokens, so
+// the returned token's refresh token replaces the old one.
+func (c *Config) Refresh(refreshToken string) (*Token, error) {
+	return c.requestToken(map[string]string{
+		"grant_type":    "refresh_token",
+		"client_id":     c.ClientID,
+		"client_secret": c.ClientSecret,
+		"refresh_token": refreshToken,
+	})
+}
+
+// requestToken posts a grant to the token endpoint
+func (c *Config) requestToken(grant map[string]string) (token *Token, err error) {
+	payload, err := json.Marshal(grant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", c.TokenURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	var response struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	if err := c.doJSON(req, &response); err != nil {
+		return nil, fmt.Errorf("failed to obtain %s token: %w", grant["grant_type"], err)
+	}
+
+	return &Token{
+		AccessToken:  response.AccessToken,
+		RefreshToken: response.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(response.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// selectSite records the cloud ID of the Jira site the token grants access
+// to, picking the one matching siteURL when several were granted
+func (c *Config) selectSite(token *Token, siteURL string) error {
+	req, err := http.NewRequest("GET", c.ResourcesURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Accept", "application/json")
+
+	var resources []struct {
+		ID   string `json:"id"`
+		URL  string `json:"url"`
+		Name string `json:"name"`
+	}
+	if err := c.doJSON(req, &resources); err != nil {
+		return fmt.Errorf("failed to list accessible sites: %w", err)
+	}
+
+	for _, resource := range resources {
+		if (siteURL == "" && len(resources) == 1) || SameHost(resource.URL, siteURL) {
+			token.CloudID = resource.ID
+			token.SiteURL = resource.URL
+			return nil
+		}
+	}
+
+	return fmt.Errorf("authorization does not include the Jira site %s", siteURL)
+}
+
+// doJSON sends a request and decodes a successful JSON response into out
+func (c *Config) doJSON(req *http.Request, out interface{}) (err error) {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+// Source supplies access tokens for API requests, refreshing and saving
+// them when they expire
+type Source struct {
+	config *Config
+	store  Store
+
+	mu    sync.Mutex
+	token *Token
+	stale bool // Set when Jira rejected the access token before it expired
+}
+
+// NewSource creates a token source backed by a store
+func NewSource(config *Config, store Store) *Source {
+	return &Source{config: config, store: store}
+}
+
+// Token returns the current token, loading it from the store if needed
+func (s *Source) Token() (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s.token, nil
+}
+
+// AccessToken returns a valid access token, refreshing it if it has expired
+func (s *Source) AccessToken() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return "", err
+	}
+
+	if s.stale || !s.token.Valid() {
+		if s.token.RefreshToken == "" {
+			return "", fmt.Errorf("access token expired and no refresh token is available")
+		}
+
+		refreshed, err := s.config.Refresh(s.token.RefreshToken)
+		if err != nil {
+			return "", err
+		}
+		refreshed.CloudID = s.token.CloudID
+		refreshed.SiteURL = s.token.SiteURL
+		if refreshed.RefreshToken == "" {
+			refreshed.RefreshToken = s.token.RefreshToken
+		}
+
+		if err := s.store.Save(refreshed); err != nil {
+			return "", fmt.Errorf("failed to save refreshed token: %w", err)
+		}
+		s.token = refreshed
+		s.stale = false
+	}
+
+	return s.token.AccessToken, nil
+}
+
+// Invalidate marks the access token as rejected, such as when it was
+// revoked before it expired, so the next AccessToken refreshes it
+func (s *Source) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stale = true
+}
+
+// load reads the token from the store on first use
+func (s *Source) load() error {
+	if s.token != nil {
+		return nil
+	}
+	token, err := s.store.Load()
+	if err != nil {
+		return err
+	}
+	s.token = token
+	return nil
+}
+
+// SameHost reports whether two URLs point at the same, non-empty host
+func SameHost(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return ua.Host != "" && strings.EqualFold(ua.Host, ub.Host)
+}
+
+// randomState returns an unguessable value tying the callback to this login
+func randomState() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,252 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newAtlassianServer fakes the token and accessible-resources endpoints
+func newAtlassianServer(t *testing.T, grants *[]map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/oauth/token":
+			var grant map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&grant); err != nil {
+				t.Errorf("Failed to decode grant: %v", err)
+			}
+			*grants = append(*grants, grant)
+
+			if grant["client_secret"] != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			response := map[string]interface{}{
+				"access_token":  fmt.Sprintf("access-%d", len(*grants)),
+				"refresh_token": fmt.Sprintf("refresh-%d", len(*grants)),
+				"expires_in":    3600,
+			}
+			if err := json.NewEncoder(w).Encode(response); err != nil {
+				t.Errorf("Failed to encode response: %v", err)
+			}
+		case "/oauth/token/accessible-resources":
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if _, err := w.Write([]byte(`[
+				{"id":"cloud-1","url":"https://other.atlassian.net","name":"other"},
+				{"id":"cloud-2","url":"https://acme.atlassian.net","name":"acme"}
+			]`)); err != nil {
+				t.Errorf("Failed to write response: %v", err)
+			}
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+// newTestConfig points a config at the fake server with a free callback port
+func newTestConfig(t *testing.T, serverURL string) *Config {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	callback := fmt.Sprintf("http://%s/callback", listener.Addr())
+	if err := listener.Close(); err != nil {
+		t.Fatalf("Failed to release port: %v", err)
+	}
+
+	config := NewConfig("client", "secret", callback, nil)
+	config.AuthURL = serverURL + "/authorize"
+	config.TokenURL = serverURL + "/oauth/token"
+	config.ResourcesURL = serverURL + "/oauth/token/accessible-resources"
+	return config
+}
+
+func TestLogin(t *testing.T) {
+	var grants []map[string]string
+	server := newAtlassianServer(t, &grants)
+	defer server.Close()
+
+	config := newTestConfig(t, server.URL)
+
+	// Stand in for the browser: check the consent URL, then follow the
+	// redirect back to the loopback callback
+	browser := func(authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		query := u.Query()
+		if query.Get("client_id") != "client" || query.Get("redirect_uri") != config.CallbackURL {
+			t.Errorf("Unexpected consent URL %s", authURL)
+		}
+		if query.Get("scope") != "read:jira-work write:jira-work read:jira-user offline_access" {
+			t.Errorf("Unexpected scopes %q", query.Get("scope"))
+		}
+
+		go func() {
+			// A forged callback is rejected without ending the login
+			if resp, err := http.Get(config.CallbackURL + "?state=forged&code=evil"); err == nil {
+				_ = resp.Body.Close()
+			}
+			resp, err := http.Get(fmt.Sprintf("%s?state=%s&code=abc", config.CallbackURL, query.Get("state")))
+			if err != nil {
+				t.Errorf("Callback failed: %v", err)
+				return
+			}
+			_ = resp.Body.Close()
+		}()
+		return nil
+	}
+
+	token, err := config.Login("https://acme.atlassian.net", browser)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(grants) != 1 || grants[0]["grant_type"] != "authorization_code" || grants[0]["code"] != "abc" {
+		t.Errorf("Expected one authorization code grant for code abc, got %v", grants)
+	}
+	if token.AccessToken != "access-1" || token.RefreshToken != "refresh-1" {
+		t.Errorf("Unexpected token: %+v", token)
+	}
+	if token.CloudID != "cloud-2" || token.SiteURL != "https://acme.atlassian.net" {
+		t.Errorf("Expected the acme site to be selected, got %s (%s)", token.CloudID, token.SiteURL)
+	}
+	if token.APIBaseURL() != "https://api.atlassian.com/ex/jira/cloud-2" {
+		t.Errorf("Unexpected API base URL %s", token.APIBaseURL())
+	}
+}
+
+func TestLoginDenied(t *testing.T) {
+	var grants []map[string]string
+	server := newAtlassianServer(t, &grants)
+	defer server.Close()
+
+	config := newTestConfig(t, server.URL)
+
+	browser := func(authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		go func() {
+			resp, err := http.Get(fmt.Sprintf("%s?state=%s&error=access_denied", config.CallbackURL, u.Query().Get("state")))
+			if err == nil {
+				_ = resp.Body.Close()
+			}
+		}()
+		return nil
+	}
+
+	if _, err := config.Login("https://acme.atlassian.net", browser); err == nil {
+		t.Error("Expected error when authorization is denied")
+	}
+	if len(grants) != 0 {
+		t.Errorf("Expected no token requests, got %v", grants)
+	}
+}
+
+func TestSourceRefreshesExpiredToken(t *testing.T) {
+	var grants []map[string]string
+	server := newAtlassianServer(t, &grants)
+	defer server.Close()
+
+	config := newTestConfig(t, server.URL)
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "token.json")}
+	if err := store.Save(&Token{
+		AccessToken:  "stale",
+		RefreshToken: "refresh-0",
+		Expiry:       time.Now().Add(-time.Hour),
+		CloudID:      "cloud-2",
+		SiteURL:      "https://acme.atlassian.net",
+	}); err != nil {
+		t.Fatalf("Failed to save token: %v", err)
+	}
+
+	source := NewSource(config, store)
+
+	for i := 0; i < 2; i++ {
+		accessToken, err := source.AccessToken()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if accessToken != "access-1" {
+			t.Errorf("Expected refreshed access token, got %q", accessToken)
+		}
+	}
+
+	if len(grants) != 1 || grants[0]["grant_type"] != "refresh_token" || grants[0]["refresh_token"] != "refresh-0" {
+		t.Errorf("Expected a single refresh with the stored refresh token, got %v", grants)
+	}
+
+	// The rotated refresh token is saved, keeping the site
+	saved, err := store.Load()
+	if err != nil {
+		t.Fatalf("Failed to load token: %v", err)
+	}
+	if saved.RefreshToken != "refresh-1" || saved.CloudID != "cloud-2" {
+		t.Errorf("Expected rotated token for the same site to be saved, got %+v", saved)
+	}
+}
+
+func TestSourceInvalidateRefreshes(t *testing.T) {
+	var grants []map[string]string
+	server := newAtlassianServer(t, &grants)
+	defer server.Close()
+
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "token.json")}
+	if err := store.Save(&Token{AccessToken: "revoked", RefreshToken: "refresh-0", Expiry: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Failed to save token: %v", err)
+	}
+	source := NewSource(newTestConfig(t, server.URL), store)
+
+	// The token hasn't expired, but Jira rejected it
+	source.Invalidate()
+	accessToken, err := source.AccessToken()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if accessToken != "access-1" || len(grants) != 1 {
+		t.Errorf("Expected one refresh after invalidating, got %q after %d grants", accessToken, len(grants))
+	}
+}
+
+func TestSourceValidTokenNotRefreshed(t *testing.T) {
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "token.json")}
+	if err := store.Save(&Token{AccessToken: "fresh", Expiry: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Failed to save token: %v", err)
+	}
+
+	// No token endpoint is reachable, so a refresh would fail
+	source := NewSource(NewConfig("client", "secret", "", nil), store)
+	source.config.TokenURL = "http://127.0.0.1:0/oauth/token"
+
+	accessToken, err := source.AccessToken()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if accessToken != "fresh" {
+		t.Errorf("Expected stored access token, got %q", accessToken)
+	}
+}
+
+func TestFileStoreNotLoggedIn(t *testing.T) {
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "missing.json")}
+
+	if _, err := store.Load(); !errors.Is(err, ErrNotLoggedIn) {
+		t.Errorf("Expected ErrNotLoggedIn, got %v", err)
+	}
+}
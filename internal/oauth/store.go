@@ -0,0 +1,96 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/conallob/jira-beads-sync/internal/keyring"
+)
+
+// ErrNotLoggedIn is returned by stores holding no token
+var ErrNotLoggedIn = errors.New("not logged in to Jira: run 'jira-beads-sync login'")
+
+// Store persists tokens between runs
+type Store interface {
+	Load() (*Token, error)
+	Save(token *Token) error
+}
+
+// FileStore keeps the token in a JSON file readable only by the user
+type FileStore struct {
+	Path string
+}
+
+// Load reads the token, returning ErrNotLoggedIn if there is none
+func (s *FileStore) Load() (*Token, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotLoggedIn
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token: %w", err)
+	}
+
+	var token Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("failed to parse token %s: %w", s.Path, err)
+	}
+
+	return &token, nil
+}
+
+// Save writes the token with owner-only permissions
+func (s *FileStore) Save(token *Token) error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return fmt.Errorf("failed to create token directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode token: %w", err)
+	}
+
+	if err := os.WriteFile(s.Path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write token: %w", err)
+	}
+
+	return nil
+}
+
+// KeyringStore keeps the token in the OS keyring, keyed by Jira host
+type KeyringStore struct {
+	Host string
+}
+
+// keyringAccount names the OAuth token entry in the keyring
+const keyringAccount = "oauth-token"
+
+// Load reads the token, returning ErrNotLoggedIn if there is none
+func (s *KeyringStore) Load() (*Token, error) {
+	data, err := keyring.Get(s.Host, keyringAccount)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, ErrNotLoggedIn
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var token Token
+	if err := json.Unmarshal([]byte(data), &token); err != nil {
+		return nil, fmt.Errorf("failed to parse token from keyring: %w", err)
+	}
+
+	return &token, nil
+}
+
+// Save stores the token in the keyring
+func (s *KeyringStore) Save(token *Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to encode token: %w", err)
+	}
+	return keyring.Set(s.Host, keyringAccount, string(data))
+}
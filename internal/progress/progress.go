@@ -0,0 +1,235 @@
+// Package progress reports what long-running operations are doing, so
+// library code can log and show progress without printing to stdout itself
+package progress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// LevelTrace is below slog.LevelDebug, for HTTP requests shown with -vv
+const LevelTrace = slog.LevelDebug - 4
+
+// Reporter receives log messages, with slog-style key-value arguments, and
+// progress updates
+type Reporter interface {
+	Trace(msg string, args ...any)
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+
+	// Progress reports that done of total items are complete. The total
+	// may grow as more work is discovered; done == total means finished.
+	Progress(label string, done, total int)
+}
+
+// Nop is a Reporter that discards everything, the default for library code
+type Nop struct{}
+
+func (Nop) Trace(string, ...any)      {}
+func (Nop) Debug(string, ...any)      {}
+func (Nop) Info(string, ...any)       {}
+func (Nop) Warn(string, ...any)       {}
+func (Nop) Progress(string, int, int) {}
+
+// OrNop returns r, or Nop if r is nil
+func OrNop(r Reporter) Reporter {
+	if r == nil {
+		return Nop{}
+	}
+	return r
+}
+
+// Logger is a Reporter backed by slog, optionally drawing a progress bar
+type Logger struct {
+	logger *slog.Logger
+	bar    *bar
+}
+
+// NewLogger creates a Reporter writing messages at or above level to w.
+// When showBar is set, progress is drawn as a bar on w, which should be a
+// terminal.
+func NewLogger(w io.Writer, level slog.Level, showBar bool) *Logger {
+	out := &lineWriter{w: w}
+	l := &Logger{logger: slog.New(&handler{out: out, level: level})}
+	if showBar {
+		l.bar = &bar{out: out}
+		out.bar = l.bar
+	}
+	return l
+}
+
+// Trace logs at LevelTrace
+func (l *Logger) Trace(msg string, args ...any) {
+	l.logger.Log(context.Background(), LevelTrace, msg, args...)
+}
+
+// Debug logs at slog.LevelDebug
+func (l *Logger) Debug(msg string, args ...any) {
+	l.logger.Debug(msg, args...)
+}
+
+// Info logs at slog.LevelInfo
+func (l *Logger) Info(msg string, args ...any) {
+	l.logger.Info(msg, args...)
+}
+
+// Warn logs at slog.LevelWarn
+func (l *Logger) Warn(msg string, args ...any) {
+	l.logger.Warn(msg, args...)
+}
+
+// Progress updates the progress bar, if there is one
+func (l *Logger) Progress(label string, done, total int) {
+	if l.bar != nil {
+		l.bar.update(label, done, total)
+	}
+}
+
+// Done clears the progress bar, if it is showing
+func (l *Logger) Done() {
+	if l.bar != nil {
+		l.bar.out.clear()
+	}
+}
+
+// IsTerminal reports whether f is a terminal, where a progress bar can be
+// redrawn in place
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// handler is a slog.Handler formatting records for people rather than log
+// processors: no timestamps, and the level only when it isn't info
+type handler struct {
+	out   *lineWriter
+	level slog.Level
+	attrs []slog.Attr
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *handler) Handle(_ context.Context, record slog.Record) error {
+	var b strings.Builder
+	switch {
+	case record.Level >= slog.LevelError:
+		b.WriteString("✗ ")
+	case record.Level >= slog.LevelWarn:
+		b.WriteString("⚠ ")
+	case record.Level < slog.LevelInfo:
+		b.WriteString("  ")
+	}
+	b.WriteString(record.Message)
+
+	writeAttr := func(attr slog.Attr) bool {
+		fmt.Fprintf(&b, " %s=%v", attr.Key, attr.Value)
+		return true
+	}
+	for _, attr := range h.attrs {
+		writeAttr(attr)
+	}
+	record.Attrs(writeAttr)
+	b.WriteString("\n")
+
+	return h.out.writeLine(b.String())
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &handler{out: h.out, level: h.level, attrs: append(append([]slog.Attr{}, h.attrs...), attrs...)}
+}
+
+func (h *handler) WithGroup(string) slog.Handler {
+	// Groups aren't used; attributes are written flat
+	return h
+}
+
+// lineWriter serializes output, clearing the progress bar before each log
+// line and redrawing it after
+type lineWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	bar *bar
+}
+
+func (lw *lineWriter) writeLine(line string) error {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	if lw.bar != nil && lw.bar.visible {
+		if _, err := io.WriteString(lw.w, "\r\033[K"); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(lw.w, line); err != nil {
+		return err
+	}
+	if lw.bar != nil && lw.bar.visible {
+		_, err := io.WriteString(lw.w, lw.bar.render())
+		return err
+	}
+	return nil
+}
+
+func (lw *lineWriter) clear() {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	if lw.bar != nil && lw.bar.visible {
+		_, _ = io.WriteString(lw.w, "\r\033[K")
+		lw.bar.visible = false
+	}
+}
+
+// barWidth is the number of cells in the progress bar
+const barWidth = 30
+
+// bar is a single-line progress bar redrawn in place
+type bar struct {
+	out     *lineWriter
+	label   string
+	done    int
+	total   int
+	visible bool
+}
+
+func (b *bar) update(label string, done, total int) {
+	b.out.mu.Lock()
+	defer b.out.mu.Unlock()
+
+	b.label, b.done, b.total = label, done, total
+	_, _ = io.WriteString(b.out.w, "\r\033[K"+b.render())
+
+	// A finished bar stays on screen, followed by later output
+	b.visible = done < total
+	if !b.visible {
+		_, _ = io.WriteString(b.out.w, "\n")
+	}
+}
+
+// render draws the bar, e.g. "Fetching issues [=======>      ] 12/40"
+func (b *bar) render() string {
+	filled := 0
+	if b.total > 0 {
+		filled = barWidth * b.done / b.total
+	}
+	if filled > barWidth {
+		filled = barWidth
+	}
+
+	cells := strings.Repeat("=", filled)
+	if filled < barWidth {
+		cells += ">" + strings.Repeat(" ", barWidth-filled-1)
+	}
+	return fmt.Sprintf("%s [%s] %d/%d", b.label, cells, b.done, b.total)
+}
@@ -0,0 +1,93 @@
+package progress
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLoggerLevels(t *testing.T) {
+	tests := []struct {
+		name  string
+		level slog.Level
+		want  string
+	}{
+		{
+			name:  "quiet",
+			level: slog.LevelWarn,
+			want:  "⚠ warn count=2\n",
+		},
+		{
+			name:  "default",
+			level: slog.LevelInfo,
+			want:  "info key=PROJ-1\n⚠ warn count=2\n",
+		},
+		{
+			name:  "verbose",
+			level: slog.LevelDebug,
+			want:  "  debug\ninfo key=PROJ-1\n⚠ warn count=2\n",
+		},
+		{
+			name:  "trace",
+			level: LevelTrace,
+			want:  "  trace method=GET\n  debug\ninfo key=PROJ-1\n⚠ warn count=2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := NewLogger(&buf, tt.level, false)
+
+			logger.Trace("trace", "method", "GET")
+			logger.Debug("debug")
+			logger.Info("info", "key", "PROJ-1")
+			logger.Warn("warn", "count", 2)
+			logger.Progress("Fetching issues", 1, 2)
+
+			if buf.String() != tt.want {
+				t.Errorf("Expected output %q, got %q", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestLoggerProgressBar(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, slog.LevelInfo, true)
+
+	logger.Progress("Fetching issues", 1, 4)
+	if !strings.HasSuffix(buf.String(), "Fetching issues [=======>                      ] 1/4") {
+		t.Errorf("Expected a quarter-filled bar, got %q", buf.String())
+	}
+
+	// Log lines clear the bar and redraw it afterwards
+	buf.Reset()
+	logger.Info("Fetched", "key", "PROJ-1")
+	want := "\r\033[KFetched key=PROJ-1\nFetching issues [=======>                      ] 1/4"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+
+	// A finished bar is left on its own line and not redrawn
+	buf.Reset()
+	logger.Progress("Fetching issues", 4, 4)
+	logger.Info("Done")
+	logger.Done()
+	want = "\r\033[KFetching issues [==============================] 4/4\nDone\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestOrNop(t *testing.T) {
+	if _, ok := OrNop(nil).(Nop); !ok {
+		t.Error("Expected OrNop(nil) to return Nop")
+	}
+
+	logger := NewLogger(&bytes.Buffer{}, slog.LevelInfo, false)
+	if OrNop(logger) != Reporter(logger) {
+		t.Error("Expected OrNop to return a non-nil reporter unchanged")
+	}
+}
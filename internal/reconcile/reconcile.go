@@ -0,0 +1,261 @@
+// Package reconcile handles beads issues whose Jira issue was deleted or
+// moved to another key, so re-syncs don't leave stale or duplicate entries
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	jirapb "github.com/conallob/jira-beads-sync/gen/jira"
+	"github.com/conallob/jira-beads-sync/internal/converter"
+	"github.com/conallob/jira-beads-sync/internal/jira"
+	"github.com/conallob/jira-beads-sync/internal/progress"
+)
+
+// Policy is what happens to a beads issue whose Jira issue is gone
+type Policy string
+
+// Policies for deleted and moved issues
+const (
+	// PolicyClose closes the issue locally, keeping it
+	PolicyClose Policy = "close"
+	// PolicyTombstone marks the issue as a tombstone, keeping it so
+	// references still resolve
+	PolicyTombstone Policy = "tombstone"
+	// PolicyDelete removes the issue and references to it
+	PolicyDelete Policy = "delete"
+)
+
+// RemovedKey is the metadata key recording why an issue kept by PolicyClose
+// or PolicyTombstone is no longer synced: "deleted", or "moved to <KEY>"
+const RemovedKey = "jiraRemoved"
+
+// ParsePolicy parses a policy name; empty means close
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(strings.ToLower(s)); p {
+	case "":
+		return PolicyClose, nil
+	case PolicyClose, PolicyTombstone, PolicyDelete:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid removed issue policy %q (use close, tombstone or delete)", s)
+	}
+}
+
+// Change is a tracked issue deleted in Jira, or moved to a new key
+type Change struct {
+	ID      string // Beads ID of the local issue or epic
+	JiraKey string // Jira key it was synced from
+	NewID   string // Beads ID for the new key; empty if deleted
+	NewKey  string // New Jira key; empty if deleted
+}
+
+// Deleted reports whether the issue was deleted rather than moved
+func (c Change) Deleted() bool {
+	return c.NewKey == ""
+}
+
+func (c Change) String() string {
+	if c.Deleted() {
+		return c.JiraKey + " deleted"
+	}
+	return c.JiraKey + " moved to " + c.NewKey
+}
+
+// Removed reports whether metadata marks an issue as no longer synced
+func Removed(metadata *beadspb.Metadata) bool {
+	_, ok := metadata.GetCustom()[RemovedKey]
+	return ok
+}
+
+// DetectMoves finds issues and epics in existing that reappear in converted
+// under another ID but the same Jira issue ID, having moved to a new key
+func DetectMoves(existing, converted *beadspb.Export) []Change {
+	newIDs := make(map[string]string) // Jira ID to beads ID
+	newKeys := make(map[string]string)
+	for _, item := range tracked(converted) {
+		newIDs[item.jiraID] = item.id
+		newKeys[item.jiraID] = item.jiraKey
+	}
+
+	var changes []Change
+	for _, item := range tracked(existing) {
+		newID, ok := newIDs[item.jiraID]
+		if ok && newID != item.id {
+			changes = append(changes, Change{ID: item.id, JiraKey: item.jiraKey, NewID: newID, NewKey: newKeys[item.jiraID]})
+		}
+	}
+	return changes
+}
+
+// Apply applies the policy to each changed issue or epic in export. For
+// moved issues, references to the old ID are rewritten to the new one;
+// deleting an issue also removes references to it.
+func Apply(export *beadspb.Export, changes []Change, policy Policy) {
+	for _, change := range changes {
+		if !change.Deleted() {
+			rewriteReferences(export, change.ID, change.NewID)
+		}
+
+		if policy == PolicyDelete {
+			removeItem(export, change.ID)
+			rewriteReferences(export, change.ID, "")
+			continue
+		}
+
+		status := beadspb.Status_STATUS_CLOSED
+		if policy == PolicyTombstone {
+			status = beadspb.Status_STATUS_TOMBSTONE
+		}
+		reason := "deleted"
+		if !change.Deleted() {
+			reason = "moved to " + change.NewKey
+		}
+		for _, issue := range export.Issues {
+			if issue.Id == change.ID {
+				issue.Status = status
+				issue.Metadata = markRemoved(issue.Metadata, reason)
+			}
+		}
+		for _, epic := range export.Epics {
+			if epic.Id == change.ID {
+				epic.Status = status
+				epic.Metadata = markRemoved(epic.Metadata, reason)
+			}
+		}
+	}
+}
+
+// Check looks up every tracked issue and epic in Jira by its stable issue
+// ID, returning those deleted or moved, and the moved issues converted under
+// their new keys with the hierarchy and mapping. Issues that can't be looked
+// up for other reasons, such as permissions, are left alone.
+func Check(ctx context.Context, client *jira.Client, hierarchy jira.Hierarchy, mapping converter.Mapping, existing *beadspb.Export, reporter progress.Reporter) ([]Change, *beadspb.Export, error) {
+	reporter = progress.OrNop(reporter)
+	client = client.WithContext(ctx)
+
+	items := tracked(existing)
+	var changes []Change
+	var moved []*jirapb.Issue
+	for i, item := range items {
+		reporter.Progress("Checking issues", i+1, len(items))
+		issue, err := client.FetchIssue(item.jiraID)
+		switch {
+		case errors.Is(err, jira.ErrNotFound):
+			reporter.Debug("Issue deleted in Jira", "key", item.jiraKey)
+			changes = append(changes, Change{ID: item.id, JiraKey: item.jiraKey})
+		case err != nil && ctx.Err() != nil:
+			return nil, nil, ctx.Err()
+		case err != nil:
+			reporter.Warn("Failed to look up issue", "key", item.jiraKey, "error", err)
+		case issue.Key != item.jiraKey:
+			reporter.Debug("Issue moved in Jira", "key", item.jiraKey, "new_key", issue.Key)
+			changes = append(changes, Change{
+				ID: item.id, JiraKey: item.jiraKey, NewID: strings.ToLower(issue.Key), NewKey: issue.Key,
+			})
+			moved = append(moved, issue)
+		}
+	}
+
+	conv := converter.NewProtoConverterWithHierarchy(hierarchy)
+	conv.SetMapping(mapping)
+	for _, epic := range existing.Epics {
+		if key := epic.GetMetadata().GetJiraKey(); key != "" && !Removed(epic.Metadata) {
+			conv.RegisterEpic(key)
+		}
+	}
+	converted, err := conv.Convert(&jirapb.Export{Issues: moved})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert moved issues: %w", err)
+	}
+	return changes, converted, nil
+}
+
+// trackedItem is an issue or epic synced from Jira
+type trackedItem struct {
+	id, jiraKey, jiraID string
+}
+
+// tracked returns the issues and epics in an export that are still synced
+// from Jira
+func tracked(export *beadspb.Export) []trackedItem {
+	var items []trackedItem
+	add := func(id string, metadata *beadspb.Metadata) {
+		if metadata.GetJiraId() != "" && !Removed(metadata) {
+			items = append(items, trackedItem{id: id, jiraKey: metadata.GetJiraKey(), jiraID: metadata.GetJiraId()})
+		}
+	}
+	for _, issue := range export.GetIssues() {
+		add(issue.Id, issue.Metadata)
+	}
+	for _, epic := range export.GetEpics() {
+		add(epic.Id, epic.Metadata)
+	}
+	return items
+}
+
+// markRemoved records why an issue is no longer synced
+func markRemoved(metadata *beadspb.Metadata, reason string) *beadspb.Metadata {
+	if metadata == nil {
+		metadata = &beadspb.Metadata{}
+	}
+	if metadata.Custom == nil {
+		metadata.Custom = make(map[string]string)
+	}
+	metadata.Custom[RemovedKey] = reason
+	return metadata
+}
+
+// removeItem deletes an issue or epic by ID
+func removeItem(export *beadspb.Export, id string) {
+	issues := export.Issues[:0]
+	for _, issue := range export.Issues {
+		if issue.Id != id {
+			issues = append(issues, issue)
+		}
+	}
+	export.Issues = issues
+
+	epics := export.Epics[:0]
+	for _, epic := range export.Epics {
+		if epic.Id != id {
+			epics = append(epics, epic)
+		}
+	}
+	export.Epics = epics
+}
+
+// rewriteReferences points dependencies, epic links and epic parents at
+// newID instead of oldID, or removes them if newID is empty
+func rewriteReferences(export *beadspb.Export, oldID, newID string) {
+	for _, issue := range export.Issues {
+		if issue.Epic == oldID {
+			issue.Epic = newID
+		}
+
+		deps := issue.DependsOn[:0]
+		seen := make(map[string]bool)
+		for _, dep := range issue.DependsOn {
+			if dep == oldID {
+				dep = newID
+				if dep == "" || dep == issue.Id {
+					continue
+				}
+			}
+			if seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			deps = append(deps, dep)
+		}
+		issue.DependsOn = deps
+	}
+	for _, epic := range export.Epics {
+		if epic.Parent == oldID {
+			epic.Parent = newID
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	"github.com/conallob/jira-beads-sync/internal/converter"
+	"github.com/conallob/jira-beads-sync/internal/jira"
+)
+
+// newExport has proj-1 in epic proj-10, depending on proj-2
+func newExport() *beadspb.Export {
+	return &beadspb.Export{
+		Issues: []*beadspb.Issue{
+			{Id: "proj-1", Epic: "proj-10", DependsOn: []string{"proj-2"}, Metadata: &beadspb.Metadata{JiraKey: "PROJ-1", JiraId: "1"}},
+			{Id: "proj-2", Metadata: &beadspb.Metadata{JiraKey: "PROJ-2", JiraId: "2"}},
+		},
+		Epics: []*beadspb.Epic{
+			{Id: "proj-10", Metadata: &beadspb.Metadata{JiraKey: "PROJ-10", JiraId: "10"}},
+		},
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Run("close deleted", func(t *testing.T) {
+		export := newExport()
+		Apply(export, []Change{{ID: "proj-2", JiraKey: "PROJ-2"}}, PolicyClose)
+		if got := export.Issues[1]; got.Status != beadspb.Status_STATUS_CLOSED || got.Metadata.Custom[RemovedKey] != "deleted" {
+			t.Errorf("Expected proj-2 closed as deleted, got %v", got)
+		}
+		if deps := export.Issues[0].DependsOn; len(deps) != 1 {
+			t.Errorf("Expected the dependency on the closed issue to be kept, got %v", deps)
+		}
+	})
+
+	t.Run("delete deleted", func(t *testing.T) {
+		export := newExport()
+		Apply(export, []Change{{ID: "proj-2", JiraKey: "PROJ-2"}, {ID: "proj-10", JiraKey: "PROJ-10"}}, PolicyDelete)
+		if len(export.Issues) != 1 || len(export.Epics) != 0 {
+			t.Fatalf("Expected proj-2 and proj-10 deleted, got %v", export)
+		}
+		if got := export.Issues[0]; len(got.DependsOn) != 0 || got.Epic != "" {
+			t.Errorf("Expected references to be removed, got %v", got)
+		}
+	})
+
+	t.Run("tombstone moved", func(t *testing.T) {
+		export := newExport()
+		export.Issues = append(export.Issues, &beadspb.Issue{Id: "ops-2", DependsOn: []string{"proj-2"}})
+		export.Epics = append(export.Epics, &beadspb.Epic{Id: "ops-10"})
+		Apply(export, []Change{
+			{ID: "proj-2", JiraKey: "PROJ-2", NewID: "ops-2", NewKey: "OPS-2"},
+			{ID: "proj-10", JiraKey: "PROJ-10", NewID: "ops-10", NewKey: "OPS-10"},
+		}, PolicyTombstone)
+
+		if got := export.Issues[0]; len(got.DependsOn) != 1 || got.DependsOn[0] != "ops-2" || got.Epic != "ops-10" {
+			t.Errorf("Expected proj-1 to follow the moves, got %v", got)
+		}
+		if got := export.Issues[2]; len(got.DependsOn) != 0 {
+			t.Errorf("Expected no dependency of ops-2 on itself, got %v", got.DependsOn)
+		}
+		if got := export.Epics[0]; got.Status != beadspb.Status_STATUS_TOMBSTONE || got.Metadata.Custom[RemovedKey] != "moved to OPS-10" {
+			t.Errorf("Expected proj-10 to be a tombstone, got %v", got)
+		}
+	})
+}
+
+func TestDetectMoves(t *testing.T) {
+	existing := newExport()
+	converted := &beadspb.Export{Issues: []*beadspb.Issue{
+		{Id: "proj-1", Metadata: &beadspb.Metadata{JiraKey: "PROJ-1", JiraId: "1"}},
+		{Id: "ops-2", Metadata: &beadspb.Metadata{JiraKey: "OPS-2", JiraId: "2"}},
+	}}
+
+	changes := DetectMoves(existing, converted)
+	if len(changes) != 1 || changes[0] != (Change{ID: "proj-2", JiraKey: "PROJ-2", NewID: "ops-2", NewKey: "OPS-2"}) {
+		t.Fatalf("Expected proj-2 moved to ops-2, got %v", changes)
+	}
+
+	// Once applied, the old issue isn't detected again
+	Apply(existing, changes, PolicyClose)
+	if changes := DetectMoves(existing, converted); len(changes) != 0 {
+		t.Errorf("Expected no more moves, got %v", changes)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	// Issue 1 is unchanged, 2 moved to OPS-2, 10 was deleted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys := map[string]string{"1": "PROJ-1", "2": "OPS-2"}
+		id := strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/")
+		key, ok := keys[id]
+		if !ok {
+			http.Error(w, `{"errorMessages":["Issue does not exist"]}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"key": key,
+			"id":  id,
+			"fields": map[string]interface{}{
+				"summary":   "Issue " + key,
+				"issuetype": map[string]interface{}{"name": "Task"},
+				"status":    map[string]interface{}{"name": "Open", "statusCategory": map[string]interface{}{"key": "new"}},
+				"created":   "2024-01-01T10:00:00.000+0000",
+				"updated":   "2024-01-01T10:00:00.000+0000",
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := jira.NewClient(server.URL, "user@example.com", "token")
+	changes, moved, err := Check(context.Background(), client, jira.DefaultHierarchy(), converter.Mapping{}, newExport(), nil)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+
+	want := []Change{
+		{ID: "proj-2", JiraKey: "PROJ-2", NewID: "ops-2", NewKey: "OPS-2"},
+		{ID: "proj-10", JiraKey: "PROJ-10"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("Change %d: expected %v, got %v", i, want[i], changes[i])
+		}
+	}
+	if len(moved.Issues) != 1 || moved.Issues[0].Id != "ops-2" {
+		t.Errorf("Expected ops-2 converted, got %v", moved.Issues)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Policy
+		wantErr bool
+	}{
+		{"", PolicyClose, false},
+		{"close", PolicyClose, false},
+		{"Tombstone", PolicyTombstone, false},
+		{"delete", PolicyDelete, false},
+		{"archive", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePolicy(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParsePolicy(%q) = %q, %v; want %q, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
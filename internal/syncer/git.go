@@ -0,0 +1,56 @@
+package syncer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// syncedFiles are the files committed after a sync, relative to the
+// directory; the state file is machine-specific and left out
+var syncedFiles = []string{
+	filepath.Join(".beads", "issues.jsonl"),
+	filepath.Join(".beads", "epics.jsonl"),
+}
+
+// commit commits the synced files in dir, returning false if git saw no
+// changes to them
+func commit(dir, message string) (bool, error) {
+	var paths []string
+	for _, file := range syncedFiles {
+		if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+			paths = append(paths, file)
+		}
+	}
+
+	if _, err := git(dir, append([]string{"add", "--"}, paths...)...); err != nil {
+		return false, err
+	}
+	status, err := git(dir, append([]string{"status", "--porcelain", "--"}, paths...)...)
+	if err != nil {
+		return false, err
+	}
+	if strings.TrimSpace(status) == "" {
+		return false, nil
+	}
+	if _, err := git(dir, append([]string{"commit", "--quiet", "-m", message, "--"}, paths...)...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// git runs a git command in dir and returns its output
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
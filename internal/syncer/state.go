@@ -0,0 +1,160 @@
+package syncer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+)
+
+// StateFileName is the file in .beads recording what was last synced
+const StateFileName = "sync-state.json"
+
+// state records when each query last synced, a hash of each issue and epic
+// as last written, to detect local edits, a hash of each as last converted
+// from Jira, to detect changes there, and the conflicts the last cycle left
+// unresolved
+type state struct {
+	Queries   map[string]time.Time `json:"queries"`
+	Hashes    map[string]string    `json:"hashes"`
+	Jira      map[string]string    `json:"jira,omitempty"`
+	Conflicts []string             `json:"conflicts,omitempty"`
+}
+
+// statePath returns the state file for a directory
+func statePath(dir string) string {
+	return filepath.Join(dir, ".beads", StateFileName)
+}
+
+// loadState reads the state file, or returns empty state if there is none
+func loadState(dir string) (*state, error) {
+	st := &state{
+		Queries: make(map[string]time.Time),
+		Hashes:  make(map[string]string),
+		Jira:    make(map[string]string),
+	}
+
+	data, err := os.ReadFile(statePath(dir))
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state: %w", err)
+	}
+	if st.Queries == nil {
+		st.Queries = make(map[string]time.Time)
+	}
+	if st.Hashes == nil {
+		st.Hashes = make(map[string]string)
+	}
+	if st.Jira == nil {
+		st.Jira = make(map[string]string)
+	}
+	return st, nil
+}
+
+// save writes the state file
+func (st *state) save(dir string) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(statePath(dir)), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(statePath(dir), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
+
+// RecordSynced records issues and epics just written from Jira by a command
+// other than watch, so the next watch cycle doesn't take them for local
+// edits, and clears their conflicts. Directories watch hasn't synced are
+// left alone.
+func RecordSynced(dir string, synced *beadspb.Export) error {
+	if _, err := os.Stat(statePath(dir)); os.IsNotExist(err) {
+		return nil
+	}
+	st, err := loadState(dir)
+	if err != nil {
+		return err
+	}
+	st.record(synced)
+	return st.save(dir)
+}
+
+// RecordFetch records a fetch of scope, a JQL query or other description of
+// what was fetched, that started at start and wrote fetched. Unlike
+// RecordSynced it creates the state file, so status can report when the
+// directory was last synced and what has been edited since.
+func RecordFetch(dir, scope string, start time.Time, fetched *beadspb.Export) error {
+	st, err := loadState(dir)
+	if err != nil {
+		return err
+	}
+	st.record(fetched)
+	st.Queries[scope] = start
+	return st.save(dir)
+}
+
+// record stores the hashes of issues and epics written from Jira and clears
+// their conflicts
+func (st *state) record(synced *beadspb.Export) {
+	for id, hash := range mergedHashes(synced) {
+		st.Hashes[id] = hash
+	}
+	fetched := jiraHashes(synced)
+	for id, hash := range fetched {
+		st.Jira[id] = hash
+	}
+	conflicts := st.Conflicts[:0]
+	for _, id := range st.Conflicts {
+		if _, ok := fetched[id]; !ok {
+			conflicts = append(conflicts, id)
+		}
+	}
+	st.Conflicts = conflicts
+}
+
+// Status describes what watch last synced in a directory
+type Status struct {
+	// LastSynced is when each query last synced without conflicts
+	LastSynced map[string]time.Time `json:"lastSynced"`
+	// EditedLocally lists the issues and epics changed since last synced
+	EditedLocally []string `json:"editedLocally"`
+	// Conflicts lists the issues and epics the last cycle found changed both
+	// locally and in Jira, and left as edited locally
+	Conflicts []string `json:"conflicts"`
+}
+
+// ReadStatus reports a directory's sync state, comparing the beads files
+// against the hashes recorded when they were last synced
+func ReadStatus(dir string) (*Status, error) {
+	st, err := loadState(dir)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := beads.NewJSONLRenderer(dir).ReadExport()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read existing beads: %w", err)
+	}
+
+	status := &Status{LastSynced: st.Queries, EditedLocally: []string{}, Conflicts: []string{}}
+	status.Conflicts = append(status.Conflicts, st.Conflicts...)
+	for id, hash := range mergedHashes(existing) {
+		if synced, ok := st.Hashes[id]; ok && synced != hash {
+			status.EditedLocally = append(status.EditedLocally, id)
+		}
+	}
+	sort.Strings(status.EditedLocally)
+	return status, nil
+}
@@ -0,0 +1,373 @@
+// Package syncer keeps a .beads directory in sync with Jira queries,
+// merging in the issues updated since the last cycle
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	jirapb "github.com/conallob/jira-beads-sync/gen/jira"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+	"github.com/conallob/jira-beads-sync/internal/converter"
+	"github.com/conallob/jira-beads-sync/internal/jira"
+	"github.com/conallob/jira-beads-sync/internal/progress"
+	"github.com/conallob/jira-beads-sync/internal/reconcile"
+	"github.com/conallob/jira-beads-sync/internal/validate"
+)
+
+// ErrConflict is returned, wrapped, when issues changed both locally and in
+// Jira since the last sync
+var ErrConflict = errors.New("changed both locally and in jira")
+
+// Options configures a Syncer
+type Options struct {
+	// Queries are the JQL queries kept in sync
+	Queries []string
+	// Commit commits the updated JSONL files to git after each change
+	Commit bool
+	// Overwrite replaces issues edited locally with the Jira version
+	// instead of reporting a conflict
+	Overwrite bool
+	// Removed is what happens to issues found under a new key, having
+	// moved in Jira; empty means close
+	Removed reconcile.Policy
+	// Validation is what happens to dangling references, cycles and other
+	// problems in the merged issues; empty means keep
+	Validation validate.Mode
+	// Mapping overrides how Jira statuses and priorities are converted
+	Mapping converter.Mapping
+}
+
+// Summary describes one sync cycle
+type Summary struct {
+	Fetched   int                // Jira issues fetched, including dependencies
+	Added     int                // Issues and epics new to .beads
+	Updated   int                // Issues and epics that changed
+	Moved     int                // Issues and epics fetched under a new key
+	Conflicts []string           // IDs edited both locally and in Jira, left as edited locally
+	Problems  []validate.Problem // Problems found in the merged issues
+	Committed bool               // Whether the changes were committed to git
+}
+
+// Changed reports whether the cycle wrote any changes
+func (s *Summary) Changed() bool {
+	return s.Added+s.Updated > 0
+}
+
+// Syncer merges the results of Jira queries into a .beads directory
+type Syncer struct {
+	client    *jira.Client
+	hierarchy jira.Hierarchy
+	dir       string
+	opts      Options
+	reporter  progress.Reporter
+	now       func() time.Time
+}
+
+// New creates a syncer writing to dir/.beads
+func New(client *jira.Client, hierarchy jira.Hierarchy, dir string, opts Options) *Syncer {
+	return &Syncer{
+		client:    client,
+		hierarchy: hierarchy,
+		dir:       dir,
+		opts:      opts,
+		reporter:  progress.Nop{},
+		now:       time.Now,
+	}
+}
+
+// SetReporter sets where the syncer logs each cycle
+func (s *Syncer) SetReporter(reporter progress.Reporter) {
+	s.reporter = progress.OrNop(reporter)
+}
+
+// Cycle runs each query once, fetching only the issues updated since its
+// last successful cycle, and merges them into .beads. Issues edited locally
+// since they were last synced are left alone, unless Overwrite is set.
+// Those that changed in Jira too are reported as conflicts, and the queries
+// are then searched again from the same point next cycle until the
+// conflicts are resolved.
+func (s *Syncer) Cycle(ctx context.Context) (*Summary, error) {
+	start := s.now()
+	renderer := beads.NewJSONLRenderer(s.dir)
+	renderer.SetReporter(s.reporter)
+
+	st, err := loadState(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := renderer.ReadExport()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read existing beads: %w", err)
+	}
+
+	fetched, err := s.fetch(ctx, st, start)
+	if err != nil {
+		return nil, err
+	}
+	summary := &Summary{Fetched: len(fetched.Issues)}
+
+	conv := converter.NewProtoConverterWithHierarchy(s.hierarchy)
+	conv.SetMapping(s.opts.Mapping)
+	converted, err := conv.Convert(fetched)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert: %w", err)
+	}
+
+	// Compare against what was last written to find local edits
+	existingHashes := make(map[string]string)
+	for _, issue := range existing.Issues {
+		existingHashes[issue.Id] = beads.IssueHash(issue)
+	}
+	for _, epic := range existing.Epics {
+		existingHashes[epic.Id] = beads.EpicHash(epic)
+	}
+	editedLocally := func(id string) bool {
+		synced, ok := st.Hashes[id]
+		current, exists := existingHashes[id]
+		return ok && exists && synced != current
+	}
+	// Compare against what Jira last sent to find changes there. Without a
+	// record the issue is assumed to have changed.
+	fetchedHashes := jiraHashes(converted)
+	changedInJira := func(id string) bool {
+		previous, ok := st.Jira[id]
+		return !ok || previous != fetchedHashes[id]
+	}
+
+	// Issues moved to another key arrive under a new ID; the policy decides
+	// what happens to the old one, and references follow the move
+	moves := reconcile.DetectMoves(existing, converted)
+	reconcile.Apply(existing, moves, s.opts.Removed)
+	summary.Moved = len(moves)
+	for _, move := range moves {
+		s.reporter.Info("Issue moved in Jira", "id", move.ID, "new_id", move.NewID)
+	}
+
+	// Issues edited locally keep the local version; it's only a conflict if
+	// Jira's version changed too
+	keptLocal := make(map[string]bool)
+	if !s.opts.Overwrite {
+		issues := converted.Issues[:0]
+		for _, issue := range converted.Issues {
+			if editedLocally(issue.Id) {
+				keptLocal[issue.Id] = true
+				continue
+			}
+			issues = append(issues, issue)
+		}
+		converted.Issues = issues
+
+		epics := converted.Epics[:0]
+		for _, epic := range converted.Epics {
+			if editedLocally(epic.Id) {
+				keptLocal[epic.Id] = true
+				continue
+			}
+			epics = append(epics, epic)
+		}
+		converted.Epics = epics
+
+		for id := range keptLocal {
+			if changedInJira(id) {
+				summary.Conflicts = append(summary.Conflicts, id)
+			}
+		}
+		sort.Strings(summary.Conflicts)
+	}
+
+	merged := beads.Merge(existing, converted)
+	if summary.Problems, err = validate.Validate(merged, s.opts.Validation); err != nil {
+		return nil, err
+	}
+	hashes := mergedHashes(merged)
+	for id, hash := range hashes {
+		previous, exists := existingHashes[id]
+		switch {
+		case !exists:
+			summary.Added++
+		case previous != hash:
+			summary.Updated++
+		}
+	}
+
+	if summary.Changed() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := renderer.RenderExport(merged); err != nil {
+			return nil, fmt.Errorf("failed to render: %w", err)
+		}
+		if s.opts.Commit {
+			committed, err := commit(s.dir, commitMessage(summary))
+			if err != nil {
+				return nil, err
+			}
+			summary.Committed = committed
+		}
+	}
+
+	// Issues kept as edited locally keep the hashes they were synced with,
+	// so they're still seen as edited locally next cycle
+	for id := range keptLocal {
+		hashes[id] = st.Hashes[id]
+	}
+	for id, hash := range fetchedHashes {
+		if !keptLocal[id] {
+			st.Jira[id] = hash
+		}
+	}
+	for id := range st.Jira {
+		if _, ok := hashes[id]; !ok {
+			delete(st.Jira, id)
+		}
+	}
+	st.Hashes = hashes
+	st.Conflicts = summary.Conflicts
+	if len(summary.Conflicts) == 0 {
+		for _, jql := range s.opts.Queries {
+			st.Queries[jql] = start
+		}
+	}
+	if err := st.save(s.dir); err != nil {
+		return nil, err
+	}
+
+	s.reporter.Info("Sync cycle complete", "fetched", summary.Fetched, "added", summary.Added,
+		"updated", summary.Updated, "moved", summary.Moved, "conflicts", len(summary.Conflicts), "committed", summary.Committed)
+	if len(summary.Conflicts) > 0 {
+		s.reporter.Warn("Left issues edited both locally and in Jira as edited locally",
+			"ids", strings.Join(summary.Conflicts, ","))
+	}
+	for _, problem := range summary.Problems {
+		s.reporter.Warn(problem.String())
+	}
+	return summary, nil
+}
+
+// Run runs a cycle every interval until ctx is done, which is not an
+// error. Failed cycles are logged and retried, doubling the wait after each
+// consecutive failure up to maxBackoff.
+func (s *Syncer) Run(ctx context.Context, interval, maxBackoff time.Duration) error {
+	failures := 0
+	for {
+		_, err := s.Cycle(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil:
+			failures++
+			wait := backoff(interval, maxBackoff, failures)
+			s.reporter.Warn("Sync cycle failed", "error", err, "retry_in", wait)
+		default:
+			failures = 0
+		}
+
+		timer := time.NewTimer(backoff(interval, maxBackoff, failures))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the wait before the next cycle: the interval, doubled for
+// each consecutive failure up to maxBackoff
+func backoff(interval, maxBackoff time.Duration, failures int) time.Duration {
+	wait := interval
+	for i := 0; i < failures && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff && maxBackoff > interval {
+		wait = maxBackoff
+	}
+	return wait
+}
+
+// fetch runs each query, limited to issues updated since its last sync
+func (s *Syncer) fetch(ctx context.Context, st *state, now time.Time) (*jirapb.Export, error) {
+	client := s.client.WithContext(ctx)
+	combined := &jirapb.Export{}
+	seen := make(map[string]bool)
+
+	for _, jql := range s.opts.Queries {
+		query := jql
+		if since, ok := st.Queries[jql]; ok {
+			query = updatedSince(jql, now.Sub(since))
+		}
+		s.reporter.Debug("Searching for updated issues", "jql", query)
+
+		keys, err := client.SearchIssues(query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search issues: %w", err)
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		export, err := client.FetchIssues(keys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch issues: %w", err)
+		}
+		for _, issue := range export.Issues {
+			if !seen[issue.Key] {
+				seen[issue.Key] = true
+				combined.Issues = append(combined.Issues, issue)
+			}
+		}
+		combined.Skipped = append(combined.Skipped, export.Skipped...)
+	}
+
+	return combined, nil
+}
+
+// orderBy matches a trailing ORDER BY clause, which can't be followed by
+// more conditions
+var orderBy = regexp.MustCompile(`(?is)\s+order\s+by\s+.*$`)
+
+// updatedSince restricts a query to issues updated in the last elapsed
+// time, plus a minute's margin. Relative times avoid depending on the Jira
+// user's time zone.
+func updatedSince(jql string, elapsed time.Duration) string {
+	minutes := int(math.Ceil(elapsed.Minutes())) + 1
+	return fmt.Sprintf("(%s) AND updated >= -%dm", orderBy.ReplaceAllString(jql, ""), minutes)
+}
+
+// mergedHashes hashes every issue and epic in an export by ID
+func mergedHashes(export *beadspb.Export) map[string]string {
+	hashes := make(map[string]string)
+	for _, issue := range export.Issues {
+		hashes[issue.Id] = beads.IssueHash(issue)
+	}
+	for _, epic := range export.Epics {
+		hashes[epic.Id] = beads.EpicHash(epic)
+	}
+	return hashes
+}
+
+// jiraHashes hashes every issue and epic in an export by ID, leaving out
+// metadata added locally
+func jiraHashes(export *beadspb.Export) map[string]string {
+	hashes := make(map[string]string)
+	for _, issue := range export.Issues {
+		hashes[issue.Id] = beads.IssueJiraHash(issue)
+	}
+	for _, epic := range export.Epics {
+		hashes[epic.Id] = beads.EpicJiraHash(epic)
+	}
+	return hashes
+}
+
+// commitMessage describes a cycle's changes for git
+func commitMessage(summary *Summary) string {
+	return fmt.Sprintf("Sync beads from Jira: %d added, %d updated", summary.Added, summary.Updated)
+}
@@ -0,0 +1,563 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+	"github.com/conallob/jira-beads-sync/internal/jira"
+	"github.com/conallob/jira-beads-sync/internal/reconcile"
+)
+
+// fakeJira serves issues by key. Searches restricted to recent updates
+// return only the keys marked as updated.
+type fakeJira struct {
+	mu       sync.Mutex
+	summary  map[string]string
+	ids      map[string]string // Jira IDs that aren't derived from the key
+	updated  []string
+	searches []string
+	pageSize int // Search results per page; 0 means all at once
+}
+
+func newFakeJira(t *testing.T, summary map[string]string) (*fakeJira, *httptest.Server) {
+	fake := &fakeJira{summary: summary}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		var body interface{}
+		if r.URL.Path == "/rest/api/2/search" {
+			jql := r.URL.Query().Get("jql")
+			fake.searches = append(fake.searches, jql)
+
+			keys := fake.updated
+			if !strings.Contains(jql, "updated >=") {
+				keys = nil
+				for key := range fake.summary {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+			}
+			total := len(keys)
+			startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+			keys = keys[min(startAt, total):]
+			if fake.pageSize > 0 && len(keys) > fake.pageSize {
+				keys = keys[:fake.pageSize]
+			}
+			issues := make([]map[string]string, 0, len(keys))
+			for _, key := range keys {
+				issues = append(issues, map[string]string{"key": key})
+			}
+			body = map[string]interface{}{"issues": issues, "startAt": startAt, "total": total}
+		} else {
+			key := strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/")
+			summary, ok := fake.summary[key]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			id, ok := fake.ids[key]
+			if !ok {
+				id = key + "-id"
+			}
+			body = map[string]interface{}{
+				"key": key,
+				"id":  id,
+				"fields": map[string]interface{}{
+					"summary":   summary,
+					"issuetype": map[string]interface{}{"name": "Task"},
+					"status":    map[string]interface{}{"name": "Open", "statusCategory": map[string]interface{}{"key": "new"}},
+					"created":   "2024-01-01T10:00:00.000+0000",
+					"updated":   "2024-01-01T10:00:00.000+0000",
+				},
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			t.Errorf("Failed to encode response: %v", err)
+		}
+	}))
+	return fake, server
+}
+
+func (f *fakeJira) update(key, summary string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.summary[key] = summary
+	f.updated = []string{key}
+}
+
+// move changes an issue's key, keeping its ID
+func (f *fakeJira) move(oldKey, newKey string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.summary[newKey] = f.summary[oldKey]
+	delete(f.summary, oldKey)
+	f.ids = map[string]string{newKey: oldKey + "-id"}
+	f.updated = []string{newKey}
+}
+
+func (f *fakeJira) lastSearch() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.searches[len(f.searches)-1]
+}
+
+// newTestSyncer creates a syncer with a controllable clock
+func newTestSyncer(serverURL, dir string, opts Options) (*Syncer, *time.Time) {
+	client := jira.NewClient(serverURL, "user@example.com", "token")
+	s := New(client, jira.DefaultHierarchy(), dir, opts)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestCycle(t *testing.T) {
+	fake, server := newFakeJira(t, map[string]string{"PROJ-1": "First", "PROJ-2": "Second"})
+	defer server.Close()
+
+	dir := t.TempDir()
+	s, now := newTestSyncer(server.URL, dir, Options{Queries: []string{"project = PROJ ORDER BY rank"}})
+	ctx := context.Background()
+
+	// The first cycle fetches everything
+	summary, err := s.Cycle(ctx)
+	if err != nil {
+		t.Fatalf("Cycle failed: %v", err)
+	}
+	if summary.Added != 2 || summary.Updated != 0 {
+		t.Errorf("Expected 2 added, got %+v", summary)
+	}
+
+	// Later cycles only search for recent updates
+	*now = now.Add(5 * time.Minute)
+	fake.update("PROJ-1", "First, renamed")
+	summary, err = s.Cycle(ctx)
+	if err != nil {
+		t.Fatalf("Cycle failed: %v", err)
+	}
+	if want := "(project = PROJ) AND updated >= -6m"; fake.lastSearch() != want {
+		t.Errorf("Expected search %q, got %q", want, fake.lastSearch())
+	}
+	if summary.Added != 0 || summary.Updated != 1 {
+		t.Errorf("Expected 1 updated, got %+v", summary)
+	}
+
+	renderer := beads.NewJSONLRenderer(dir)
+	export, err := renderer.ReadExport()
+	if err != nil {
+		t.Fatalf("ReadExport failed: %v", err)
+	}
+	titles := make(map[string]string)
+	for _, issue := range export.Issues {
+		titles[issue.Id] = issue.Title
+	}
+	if titles["proj-1"] != "First, renamed" || titles["proj-2"] != "Second" {
+		t.Errorf("Unexpected issues after merge: %v", titles)
+	}
+}
+
+func TestCycleFetchesEveryPage(t *testing.T) {
+	fake, server := newFakeJira(t, map[string]string{"PROJ-1": "First", "PROJ-2": "Second", "PROJ-3": "Third"})
+	defer server.Close()
+	fake.pageSize = 2
+
+	// The first cycle imports every match, not just the first page, as
+	// later cycles only search for recent updates
+	dir := t.TempDir()
+	s, _ := newTestSyncer(server.URL, dir, Options{Queries: []string{"project = PROJ"}})
+	summary, err := s.Cycle(context.Background())
+	if err != nil {
+		t.Fatalf("Cycle failed: %v", err)
+	}
+	if summary.Added != 3 {
+		t.Errorf("Expected 3 added, got %+v", summary)
+	}
+}
+
+func TestCycleConflict(t *testing.T) {
+	fake, server := newFakeJira(t, map[string]string{"PROJ-1": "First"})
+	defer server.Close()
+
+	dir := t.TempDir()
+	s, now := newTestSyncer(server.URL, dir, Options{Queries: []string{"project = PROJ"}})
+	ctx := context.Background()
+	if _, err := s.Cycle(ctx); err != nil {
+		t.Fatalf("Cycle failed: %v", err)
+	}
+
+	// Edit the issue locally, then change it in Jira too
+	renderer := beads.NewJSONLRenderer(dir)
+	export, err := renderer.ReadExport()
+	if err != nil {
+		t.Fatalf("ReadExport failed: %v", err)
+	}
+	export.Issues[0].Title = "Edited locally"
+	if err := renderer.RenderExport(export); err != nil {
+		t.Fatalf("RenderExport failed: %v", err)
+	}
+	status, err := ReadStatus(dir)
+	if err != nil {
+		t.Fatalf("ReadStatus failed: %v", err)
+	}
+	if len(status.EditedLocally) != 1 || status.EditedLocally[0] != "proj-1" || status.LastSynced["project = PROJ"].IsZero() {
+		t.Errorf("Expected proj-1 edited locally since the last sync, got %+v", status)
+	}
+
+	*now = now.Add(5 * time.Minute)
+	fake.update("PROJ-1", "Edited in Jira")
+	summary, err := s.Cycle(ctx)
+	if err != nil {
+		t.Fatalf("Cycle failed: %v", err)
+	}
+	if len(summary.Conflicts) != 1 || summary.Conflicts[0] != "proj-1" || summary.Changed() {
+		t.Errorf("Expected a conflict on proj-1 and no changes, got %+v", summary)
+	}
+
+	// The conflict is seen again next cycle, searching from the same point
+	*now = now.Add(5 * time.Minute)
+	summary, err = s.Cycle(ctx)
+	if err != nil {
+		t.Fatalf("Cycle failed: %v", err)
+	}
+	if len(summary.Conflicts) != 1 {
+		t.Errorf("Expected the conflict to persist, got %+v", summary)
+	}
+	if status, err := ReadStatus(dir); err != nil || len(status.Conflicts) != 1 || status.Conflicts[0] != "proj-1" {
+		t.Errorf("Expected the conflict recorded in the sync state, got %+v, %v", status, err)
+	}
+	if want := "(project = PROJ) AND updated >= -11m"; fake.lastSearch() != want {
+		t.Errorf("Expected search %q, got %q", want, fake.lastSearch())
+	}
+
+	// Overwriting takes the Jira version
+	s.opts.Overwrite = true
+	summary, err = s.Cycle(ctx)
+	if err != nil {
+		t.Fatalf("Cycle failed: %v", err)
+	}
+	if len(summary.Conflicts) != 0 || summary.Updated != 1 {
+		t.Errorf("Expected the Jira version to overwrite, got %+v", summary)
+	}
+	export, err = renderer.ReadExport()
+	if err != nil {
+		t.Fatalf("ReadExport failed: %v", err)
+	}
+	if export.Issues[0].Title != "Edited in Jira" {
+		t.Errorf("Expected the Jira title, got %q", export.Issues[0].Title)
+	}
+	if status, err := ReadStatus(dir); err != nil || len(status.Conflicts) != 0 {
+		t.Errorf("Expected no conflicts left, got %+v, %v", status, err)
+	}
+}
+
+func TestCycleEditedLocallyOnly(t *testing.T) {
+	fake, server := newFakeJira(t, map[string]string{"PROJ-1": "First"})
+	defer server.Close()
+
+	dir := t.TempDir()
+	s, now := newTestSyncer(server.URL, dir, Options{Queries: []string{"project = PROJ"}})
+	ctx := context.Background()
+	if _, err := s.Cycle(ctx); err != nil {
+		t.Fatalf("Cycle failed: %v", err)
+	}
+
+	renderer := beads.NewJSONLRenderer(dir)
+	export, err := renderer.ReadExport()
+	if err != nil {
+		t.Fatalf("ReadExport failed: %v", err)
+	}
+	export.Issues[0].Title = "Edited locally"
+	if err := renderer.RenderExport(export); err != nil {
+		t.Fatalf("RenderExport failed: %v", err)
+	}
+
+	// Jira returns the issue again, unchanged
+	*now = now.Add(5 * time.Minute)
+	fake.update("PROJ-1", "First")
+	summary, err := s.Cycle(ctx)
+	if err != nil {
+		t.Fatalf("Cycle failed: %v", err)
+	}
+	if len(summary.Conflicts) != 0 || summary.Changed() {
+		t.Errorf("Expected no conflict and no changes, got %+v", summary)
+	}
+	export, err = renderer.ReadExport()
+	if err != nil {
+		t.Fatalf("ReadExport failed: %v", err)
+	}
+	if export.Issues[0].Title != "Edited locally" {
+		t.Errorf("Expected the local title kept, got %q", export.Issues[0].Title)
+	}
+	status, err := ReadStatus(dir)
+	if err != nil {
+		t.Fatalf("ReadStatus failed: %v", err)
+	}
+	if len(status.EditedLocally) != 1 || len(status.Conflicts) != 0 {
+		t.Errorf("Expected proj-1 still edited locally without a conflict, got %+v", status)
+	}
+
+	// The query moved on
+	*now = now.Add(5 * time.Minute)
+	if _, err := s.Cycle(ctx); err != nil {
+		t.Fatalf("Cycle failed: %v", err)
+	}
+	if want := "(project = PROJ) AND updated >= -6m"; fake.lastSearch() != want {
+		t.Errorf("Expected search %q, got %q", want, fake.lastSearch())
+	}
+}
+
+func TestRecordSynced(t *testing.T) {
+	_, server := newFakeJira(t, map[string]string{"PROJ-1": "First"})
+	defer server.Close()
+
+	// Without a watch cycle there's no state to update
+	dir := t.TempDir()
+	synced := &beadspb.Export{Issues: []*beadspb.Issue{{Id: "proj-1", Title: "Fetched"}}}
+	if err := RecordSynced(dir, synced); err != nil {
+		t.Fatalf("RecordSynced failed: %v", err)
+	}
+	if _, err := os.Stat(statePath(dir)); !os.IsNotExist(err) {
+		t.Errorf("Expected no sync state, got %v", err)
+	}
+
+	s, _ := newTestSyncer(server.URL, dir, Options{Queries: []string{"project = PROJ"}})
+	if _, err := s.Cycle(context.Background()); err != nil {
+		t.Fatalf("Cycle failed: %v", err)
+	}
+
+	// Another command writes the issue from Jira
+	renderer := beads.NewJSONLRenderer(dir)
+	export, err := renderer.ReadExport()
+	if err != nil {
+		t.Fatalf("ReadExport failed: %v", err)
+	}
+	export.Issues[0].Title = "Fetched"
+	if err := renderer.RenderExport(export); err != nil {
+		t.Fatalf("RenderExport failed: %v", err)
+	}
+	if err := RecordSynced(dir, export); err != nil {
+		t.Fatalf("RecordSynced failed: %v", err)
+	}
+
+	status, err := ReadStatus(dir)
+	if err != nil {
+		t.Fatalf("ReadStatus failed: %v", err)
+	}
+	if len(status.EditedLocally) != 0 {
+		t.Errorf("Expected nothing edited locally, got %v", status.EditedLocally)
+	}
+}
+
+func TestRecordFetch(t *testing.T) {
+	fake, server := newFakeJira(t, map[string]string{"PROJ-1": "First"})
+	defer server.Close()
+
+	// A fetch creates the state, recording what it wrote and when it started
+	dir := t.TempDir()
+	s, now := newTestSyncer(server.URL, dir, Options{Queries: []string{"project = PROJ"}})
+	fetched := &beadspb.Export{Issues: []*beadspb.Issue{{Id: "proj-1", Title: "First", Metadata: &beadspb.Metadata{JiraKey: "PROJ-1"}}}}
+	renderer := beads.NewJSONLRenderer(dir)
+	if err := renderer.RenderExport(fetched); err != nil {
+		t.Fatalf("RenderExport failed: %v", err)
+	}
+	if err := RecordFetch(dir, "project = PROJ", now.Add(-10*time.Minute), fetched); err != nil {
+		t.Fatalf("RecordFetch failed: %v", err)
+	}
+
+	status, err := ReadStatus(dir)
+	if err != nil {
+		t.Fatalf("ReadStatus failed: %v", err)
+	}
+	if !status.LastSynced["project = PROJ"].Equal(now.Add(-10*time.Minute)) || len(status.EditedLocally) != 0 {
+		t.Errorf("Expected the fetch recorded with nothing edited, got %+v", status)
+	}
+
+	// watch carries on from the fetch rather than starting over
+	if _, err := s.Cycle(context.Background()); err != nil {
+		t.Fatalf("Cycle failed: %v", err)
+	}
+	if want := "(project = PROJ) AND updated >= -11m"; fake.lastSearch() != want {
+		t.Errorf("Expected search %q, got %q", want, fake.lastSearch())
+	}
+}
+
+func TestCycleMoved(t *testing.T) {
+	tests := []struct {
+		policy     reconcile.Policy
+		wantOld    bool
+		wantStatus beadspb.Status
+	}{
+		{reconcile.PolicyClose, true, beadspb.Status_STATUS_CLOSED},
+		{reconcile.PolicyTombstone, true, beadspb.Status_STATUS_TOMBSTONE},
+		{reconcile.PolicyDelete, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			fake, server := newFakeJira(t, map[string]string{"PROJ-1": "First", "PROJ-2": "Second"})
+			defer server.Close()
+
+			dir := t.TempDir()
+			s, now := newTestSyncer(server.URL, dir, Options{Queries: []string{"project in (PROJ, NEW)"}, Removed: tt.policy})
+			ctx := context.Background()
+			if _, err := s.Cycle(ctx); err != nil {
+				t.Fatalf("Cycle failed: %v", err)
+			}
+
+			// proj-2 depends on proj-1, which then moves to NEW-1
+			renderer := beads.NewJSONLRenderer(dir)
+			export, err := renderer.ReadExport()
+			if err != nil {
+				t.Fatalf("ReadExport failed: %v", err)
+			}
+			for _, issue := range export.Issues {
+				if issue.Id == "proj-2" {
+					issue.DependsOn = []string{"proj-1"}
+				}
+			}
+			if err := renderer.RenderExport(export); err != nil {
+				t.Fatalf("RenderExport failed: %v", err)
+			}
+
+			*now = now.Add(5 * time.Minute)
+			fake.move("PROJ-1", "NEW-1")
+			summary, err := s.Cycle(ctx)
+			if err != nil {
+				t.Fatalf("Cycle failed: %v", err)
+			}
+			if summary.Moved != 1 || summary.Added != 1 {
+				t.Errorf("Expected 1 moved and added, got %+v", summary)
+			}
+
+			export, err = renderer.ReadExport()
+			if err != nil {
+				t.Fatalf("ReadExport failed: %v", err)
+			}
+			byID := make(map[string]*beadspb.Issue)
+			for _, issue := range export.Issues {
+				byID[issue.Id] = issue
+			}
+			if byID["new-1"] == nil {
+				t.Fatal("Expected new-1 to be added")
+			}
+			if deps := byID["proj-2"].DependsOn; len(deps) != 1 || deps[0] != "new-1" {
+				t.Errorf("Expected proj-2 to depend on new-1, got %v", deps)
+			}
+			old, ok := byID["proj-1"]
+			if ok != tt.wantOld {
+				t.Fatalf("Expected proj-1 kept: %v, got %v", tt.wantOld, ok)
+			}
+			if ok && (old.Status != tt.wantStatus || old.Metadata.Custom[reconcile.RemovedKey] != "moved to NEW-1") {
+				t.Errorf("Expected proj-1 %v and marked as moved, got %v %v", tt.wantStatus, old.Status, old.Metadata.Custom)
+			}
+		})
+	}
+}
+
+func TestCycleCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	_, server := newFakeJira(t, map[string]string{"PROJ-1": "First"})
+	defer server.Close()
+
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "user.email", "bot@example.com"},
+		{"config", "user.name", "Sync Bot"},
+	} {
+		if _, err := git(dir, args...); err != nil {
+			t.Fatalf("Failed to set up repository: %v", err)
+		}
+	}
+
+	s, _ := newTestSyncer(server.URL, dir, Options{Queries: []string{"project = PROJ"}, Commit: true})
+	summary, err := s.Cycle(context.Background())
+	if err != nil {
+		t.Fatalf("Cycle failed: %v", err)
+	}
+	if !summary.Committed {
+		t.Error("Expected the changes to be committed")
+	}
+
+	log, err := git(dir, "log", "--format=%s", "--name-only")
+	if err != nil {
+		t.Fatalf("git log failed: %v", err)
+	}
+	if !strings.Contains(log, "Sync beads from Jira: 1 added, 0 updated") || !strings.Contains(log, ".beads/issues.jsonl") {
+		t.Errorf("Unexpected commit:\n%s", log)
+	}
+	if strings.Contains(log, StateFileName) {
+		t.Errorf("Expected the state file not to be committed:\n%s", log)
+	}
+}
+
+func TestRunStopsWhenCancelled(t *testing.T) {
+	_, server := newFakeJira(t, map[string]string{"PROJ-1": "First"})
+	defer server.Close()
+
+	dir := t.TempDir()
+	s, _ := newTestSyncer(server.URL, dir, Options{Queries: []string{"project = PROJ"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Run(ctx, 10*time.Millisecond, time.Second); err != nil {
+		t.Errorf("Expected a clean stop, got %v", err)
+	}
+	if _, err := os.Stat(statePath(dir)); err != nil {
+		t.Errorf("Expected the state file to be written: %v", err)
+	}
+}
+
+func TestCycleError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	s, _ := newTestSyncer(server.URL, t.TempDir(), Options{Queries: []string{"project = PROJ"}})
+	if _, err := s.Cycle(context.Background()); !errors.Is(err, jira.ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 5 * time.Minute},
+		{1, 10 * time.Minute},
+		{2, 20 * time.Minute},
+		{3, 30 * time.Minute},
+		{10, 30 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(5*time.Minute, 30*time.Minute, tt.failures); got != tt.want {
+			t.Errorf("backoff(%d failures) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+
+	// A maximum below the interval never shortens it
+	if got := backoff(time.Hour, time.Minute, 3); got != time.Hour {
+		t.Errorf("Expected the interval, got %v", got)
+	}
+}
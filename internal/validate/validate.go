@@ -0,0 +1,230 @@
+// Package validate checks converted beads exports for references beads
+// can't resolve: dangling dependencies, dependency cycles, self-dependencies
+// and subtasks whose parent wasn't fetched
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+)
+
+// ErrInvalid is returned by Validate in ModeFail when problems are found
+var ErrInvalid = errors.New("invalid dependency graph")
+
+// Mode is what Validate does about the problems it finds
+type Mode string
+
+// Validation modes
+const (
+	// ModeKeep reports problems and writes the export unchanged
+	ModeKeep Mode = "keep"
+	// ModeDrop removes the offending references
+	ModeDrop Mode = "drop"
+	// ModeFail reports problems and fails, so nothing is written
+	ModeFail Mode = "fail"
+)
+
+// ParseMode parses a validation mode name; empty means keep
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(s)); m {
+	case "":
+		return ModeKeep, nil
+	case ModeKeep, ModeDrop, ModeFail:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid validation mode %q (use keep, drop or fail)", s)
+	}
+}
+
+// Kind identifies the type of a problem
+type Kind string
+
+// Problem kinds
+const (
+	KindDangling Kind = "dangling reference"
+	KindCycle    Kind = "dependency cycle"
+	KindSelf     Kind = "self-dependency"
+	KindOrphan   Kind = "orphaned subtask"
+)
+
+// Problem is a reference in an export that beads can't resolve
+type Problem struct {
+	Kind Kind
+	ID   string   // Issue or epic with the reference
+	Ref  string   // ID, or for orphaned subtasks the Jira key, referenced
+	Path []string // For cycles, the IDs around the cycle, ending where it started
+}
+
+func (p Problem) String() string {
+	switch p.Kind {
+	case KindCycle:
+		return fmt.Sprintf("%s: %s", p.Kind, strings.Join(p.Path, " → "))
+	case KindSelf:
+		return fmt.Sprintf("%s: %s depends on itself", p.Kind, p.ID)
+	case KindOrphan:
+		return fmt.Sprintf("%s: %s's parent %s is missing", p.Kind, p.ID, p.Ref)
+	default:
+		return fmt.Sprintf("%s: %s references missing %s", p.Kind, p.ID, p.Ref)
+	}
+}
+
+// Validate checks an export and applies the mode to the problems found,
+// returning them. In ModeDrop, dangling references and self-dependencies
+// are removed, and each cycle is broken by removing the dependency that
+// closes it. In ModeFail the error wraps ErrInvalid.
+func Validate(export *beadspb.Export, mode Mode) ([]Problem, error) {
+	problems := Check(export)
+	if len(problems) == 0 {
+		return nil, nil
+	}
+
+	switch mode {
+	case ModeFail:
+		return problems, fmt.Errorf("%w: %d problem(s), first %s", ErrInvalid, len(problems), problems[0])
+	case ModeDrop:
+		drop(export, problems)
+	}
+	return problems, nil
+}
+
+// Check returns the problems in an export without changing it
+func Check(export *beadspb.Export) []Problem {
+	ids := make(map[string]bool)
+	keys := make(map[string]bool)
+	for _, issue := range export.GetIssues() {
+		ids[issue.Id] = true
+		keys[issue.GetMetadata().GetJiraKey()] = true
+	}
+	for _, epic := range export.GetEpics() {
+		ids[epic.Id] = true
+		keys[epic.GetMetadata().GetJiraKey()] = true
+	}
+
+	var problems []Problem
+	for _, issue := range export.GetIssues() {
+		parentKey := issue.GetMetadata().GetCustom()[beads.ParentKey]
+		orphaned := parentKey != "" && !keys[parentKey]
+		if orphaned {
+			problems = append(problems, Problem{Kind: KindOrphan, ID: issue.Id, Ref: parentKey})
+		}
+
+		for _, dep := range issue.DependsOn {
+			switch {
+			case dep == issue.Id:
+				problems = append(problems, Problem{Kind: KindSelf, ID: issue.Id, Ref: dep})
+			case ids[dep]:
+			case orphaned && strings.EqualFold(dep, parentKey):
+				// Already reported as an orphaned subtask
+			default:
+				problems = append(problems, Problem{Kind: KindDangling, ID: issue.Id, Ref: dep})
+			}
+		}
+		if issue.Epic != "" && !ids[issue.Epic] {
+			problems = append(problems, Problem{Kind: KindDangling, ID: issue.Id, Ref: issue.Epic})
+		}
+	}
+	for _, epic := range export.GetEpics() {
+		if epic.Parent != "" && !ids[epic.Parent] {
+			problems = append(problems, Problem{Kind: KindDangling, ID: epic.Id, Ref: epic.Parent})
+		}
+	}
+
+	return append(problems, cycles(export)...)
+}
+
+// cycles finds dependency cycles between issues with a depth-first search.
+// Each cycle is reported once, at the dependency that closes it; removing
+// those dependencies leaves the graph acyclic.
+func cycles(export *beadspb.Export) []Problem {
+	deps := make(map[string][]string)
+	for _, issue := range export.GetIssues() {
+		deps[issue.Id] = issue.DependsOn
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var stack []string
+	var problems []Problem
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, dep := range deps[id] {
+			if dep == id {
+				continue // Reported as a self-dependency
+			}
+			switch state[dep] {
+			case unvisited:
+				if _, ok := deps[dep]; ok {
+					visit(dep)
+				}
+			case visiting:
+				start := len(stack) - 1
+				for stack[start] != dep {
+					start--
+				}
+				path := append(append([]string{}, stack[start:]...), dep)
+				problems = append(problems, Problem{Kind: KindCycle, ID: id, Ref: dep, Path: path})
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+
+	for _, issue := range export.GetIssues() {
+		if state[issue.Id] == unvisited {
+			visit(issue.Id)
+		}
+	}
+	return problems
+}
+
+// drop removes the references behind each problem. An orphaned subtask
+// loses its dependency on the missing parent and the record of it.
+func drop(export *beadspb.Export, problems []Problem) {
+	remove := make(map[string]map[string]bool) // ID to references to remove
+	for _, p := range problems {
+		ref := p.Ref
+		if p.Kind == KindOrphan {
+			ref = strings.ToLower(ref)
+		}
+		if remove[p.ID] == nil {
+			remove[p.ID] = make(map[string]bool)
+		}
+		remove[p.ID][ref] = true
+	}
+
+	for _, issue := range export.GetIssues() {
+		refs := remove[issue.Id]
+		if refs == nil {
+			continue
+		}
+		deps := issue.DependsOn[:0]
+		for _, dep := range issue.DependsOn {
+			if !refs[dep] && !refs[strings.ToLower(dep)] {
+				deps = append(deps, dep)
+			}
+		}
+		issue.DependsOn = deps
+		if parentKey := issue.GetMetadata().GetCustom()[beads.ParentKey]; refs[strings.ToLower(parentKey)] {
+			delete(issue.Metadata.Custom, beads.ParentKey)
+		}
+		if refs[issue.Epic] {
+			issue.Epic = ""
+		}
+	}
+	for _, epic := range export.GetEpics() {
+		if refs := remove[epic.Id]; refs != nil && refs[epic.Parent] {
+			epic.Parent = ""
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package validate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+)
+
+// newExport has one of each problem: proj-1 → proj-2 → proj-3 → proj-1 is
+// a cycle, proj-4 depends on itself and on missing proj-9, and subtask
+// proj-5's parent PROJ-8 wasn't fetched
+func newExport() *beadspb.Export {
+	return &beadspb.Export{
+		Issues: []*beadspb.Issue{
+			{Id: "proj-1", Epic: "proj-10", DependsOn: []string{"proj-2"}, Metadata: &beadspb.Metadata{JiraKey: "PROJ-1"}},
+			{Id: "proj-2", DependsOn: []string{"proj-3"}, Metadata: &beadspb.Metadata{JiraKey: "PROJ-2"}},
+			{Id: "proj-3", DependsOn: []string{"proj-1"}, Metadata: &beadspb.Metadata{JiraKey: "PROJ-3"}},
+			{Id: "proj-4", DependsOn: []string{"proj-4", "proj-9", "proj-1"}, Metadata: &beadspb.Metadata{JiraKey: "PROJ-4"}},
+			{Id: "proj-5", DependsOn: []string{"proj-8"}, Metadata: &beadspb.Metadata{
+				JiraKey: "PROJ-5",
+				Custom:  map[string]string{beads.ParentKey: "PROJ-8"},
+			}},
+		},
+		Epics: []*beadspb.Epic{
+			{Id: "proj-10", Parent: "proj-20", Metadata: &beadspb.Metadata{JiraKey: "PROJ-10"}},
+		},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	want := []string{
+		"self-dependency: proj-4 depends on itself",
+		"dangling reference: proj-4 references missing proj-9",
+		"orphaned subtask: proj-5's parent PROJ-8 is missing",
+		"dangling reference: proj-10 references missing proj-20",
+		"dependency cycle: proj-1 → proj-2 → proj-3 → proj-1",
+	}
+
+	problems := Check(newExport())
+	var got []string
+	for _, problem := range problems {
+		got = append(got, problem.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected problems:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestCheckValid(t *testing.T) {
+	export := &beadspb.Export{
+		Issues: []*beadspb.Issue{
+			{Id: "proj-1", Epic: "proj-10", DependsOn: []string{"proj-2", "proj-3"}},
+			{Id: "proj-2", DependsOn: []string{"proj-3"}},
+			{Id: "proj-3", DependsOn: []string{"proj-10"}, Metadata: &beadspb.Metadata{Custom: map[string]string{beads.ParentKey: "PROJ-2"}}},
+		},
+		Epics: []*beadspb.Epic{{Id: "proj-10"}},
+	}
+	export.Issues[1].Metadata = &beadspb.Metadata{JiraKey: "PROJ-2"}
+
+	if problems := Check(export); len(problems) != 0 {
+		t.Errorf("Expected no problems, got %v", problems)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("keep", func(t *testing.T) {
+		export := newExport()
+		problems, err := Validate(export, ModeKeep)
+		if err != nil || len(problems) != 5 {
+			t.Fatalf("Expected 5 problems and no error, got %v, %v", problems, err)
+		}
+		if deps := export.Issues[3].DependsOn; len(deps) != 3 {
+			t.Errorf("Expected dependencies kept, got %v", deps)
+		}
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		export := newExport()
+		if _, err := Validate(export, ModeDrop); err != nil {
+			t.Fatalf("Validate failed: %v", err)
+		}
+		if problems := Check(export); len(problems) != 0 {
+			t.Errorf("Expected no problems left, got %v", problems)
+		}
+		if deps := export.Issues[3].DependsOn; len(deps) != 1 || deps[0] != "proj-1" {
+			t.Errorf("Expected only the valid dependency of proj-4 kept, got %v", deps)
+		}
+		if deps := export.Issues[2].DependsOn; len(deps) != 0 {
+			t.Errorf("Expected the dependency closing the cycle dropped, got %v", deps)
+		}
+		if deps := export.Issues[4].DependsOn; len(deps) != 0 {
+			t.Errorf("Expected the orphaned subtask's parent dependency dropped, got %v", deps)
+		}
+		if export.Epics[0].Parent != "" {
+			t.Errorf("Expected the missing epic parent dropped, got %q", export.Epics[0].Parent)
+		}
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		export := newExport()
+		problems, err := Validate(export, ModeFail)
+		if !errors.Is(err, ErrInvalid) || len(problems) != 5 {
+			t.Fatalf("Expected ErrInvalid with 5 problems, got %v, %v", problems, err)
+		}
+	})
+}
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Mode
+		wantErr bool
+	}{
+		{"", ModeKeep, false},
+		{"keep", ModeKeep, false},
+		{"Drop", ModeDrop, false},
+		{"fail", ModeFail, false},
+		{"ignore", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMode(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
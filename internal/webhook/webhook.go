@@ -0,0 +1,436 @@
+// Package webhook applies Jira webhook events to a .beads directory, for
+// near-real-time updates without polling
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	jirapb "github.com/conallob/jira-beads-sync/gen/jira"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+	"github.com/conallob/jira-beads-sync/internal/converter"
+	"github.com/conallob/jira-beads-sync/internal/jira"
+	"github.com/conallob/jira-beads-sync/internal/progress"
+	"github.com/conallob/jira-beads-sync/internal/reconcile"
+	"github.com/conallob/jira-beads-sync/internal/syncer"
+	"github.com/conallob/jira-beads-sync/internal/validate"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the request body, keyed with
+// the webhook's secret, as "sha256=<hex>"
+const SignatureHeader = "X-Hub-Signature"
+
+// Webhook events that are applied; others are acknowledged and ignored
+const (
+	EventIssueCreated     = "jira:issue_created"
+	EventIssueUpdated     = "jira:issue_updated"
+	EventIssueDeleted     = "jira:issue_deleted"
+	EventIssueLinkCreated = "issuelink_created"
+)
+
+// maxPayloadSize limits the request bodies read
+const maxPayloadSize = 10 << 20
+
+// ErrBadSignature is returned when a payload isn't signed with the secret
+var ErrBadSignature = errors.New("invalid webhook signature")
+
+// payload is the part of a Jira webhook request body that is used
+type payload struct {
+	WebhookEvent string          `json:"webhookEvent"`
+	Issue        json.RawMessage `json:"issue"`
+	IssueLink    *issueLink      `json:"issueLink"`
+}
+
+// issueLink is a link in an issuelink_created event, which identifies
+// issues by ID rather than key
+type issueLink struct {
+	SourceIssueID      int64 `json:"sourceIssueId"`
+	DestinationIssueID int64 `json:"destinationIssueId"`
+	IssueLinkType      struct {
+		Name        string `json:"name"`
+		OutwardName string `json:"outwardName"`
+		InwardName  string `json:"inwardName"`
+	} `json:"issueLinkType"`
+}
+
+// Handler receives Jira webhooks and applies them to dir/.beads
+type Handler struct {
+	dir        string
+	secret     []byte
+	hierarchy  jira.Hierarchy
+	adapter    *jira.Adapter
+	mapping    converter.Mapping
+	policy     reconcile.Policy
+	validation validate.Mode
+	reporter   progress.Reporter
+
+	// mu serializes changes to the beads files
+	mu sync.Mutex
+}
+
+// NewHandler creates a handler for payloads signed with secret, reading
+// issues with adapter and converting them with the given hierarchy as fetch
+// does. The adapter's custom fields should match the client's.
+func NewHandler(dir, secret string, hierarchy jira.Hierarchy, adapter *jira.Adapter) *Handler {
+	return &Handler{
+		dir:        dir,
+		secret:     []byte(secret),
+		hierarchy:  hierarchy,
+		adapter:    adapter,
+		validation: validate.ModeKeep,
+		reporter:   progress.Nop{},
+	}
+}
+
+// SetMapping sets the status and priority overrides used as issues are
+// converted
+func (h *Handler) SetMapping(mapping converter.Mapping) {
+	h.mapping = mapping
+}
+
+// SetPolicy sets what happens to issues deleted in Jira or moved to a new
+// key; the default closes them
+func (h *Handler) SetPolicy(policy reconcile.Policy) {
+	h.policy = policy
+}
+
+// SetValidation sets what happens to dangling references, cycles and other
+// problems in the merged issues; the default keeps them and logs a warning
+func (h *Handler) SetValidation(mode validate.Mode) {
+	h.validation = mode
+}
+
+// SetReporter sets where the handler logs the events it applies
+func (h *Handler) SetReporter(reporter progress.Reporter) {
+	h.reporter = progress.OrNop(reporter)
+}
+
+// ServeHTTP verifies and applies a webhook request
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err := Verify(h.secret, body, r.Header.Get(SignatureHeader)); err != nil {
+		h.reporter.Warn("Rejected webhook", "remote", r.RemoteAddr, "error", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.Apply(body); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errBadPayload) {
+			status = http.StatusBadRequest
+		}
+		h.reporter.Warn("Failed to apply webhook", "error", err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Verify checks a payload's signature header against the secret
+func Verify(secret, body []byte, signature string) error {
+	hexSum, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return fmt.Errorf("%w: missing %s header", ErrBadSignature, SignatureHeader)
+	}
+	sum, err := hex.DecodeString(hexSum)
+	if err != nil {
+		return ErrBadSignature
+	}
+	if !hmac.Equal(sum, Sign(secret, body)) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Sign returns the HMAC-SHA256 of a payload, as sent in SignatureHeader
+func Sign(secret, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// errBadPayload is a payload missing what its event needs
+var errBadPayload = errors.New("invalid webhook payload")
+
+// Apply applies a verified webhook payload to the beads files
+func (h *Handler) Apply(body []byte) error {
+	var p payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return fmt.Errorf("%w: %w", errBadPayload, err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	renderer := beads.NewJSONLRenderer(h.dir)
+	existing, err := renderer.ReadExport()
+	if err != nil {
+		return fmt.Errorf("failed to read existing beads: %w", err)
+	}
+
+	var updated, synced *beadspb.Export
+	switch p.WebhookEvent {
+	case EventIssueCreated, EventIssueUpdated:
+		updated, synced, err = h.applyIssue(existing, p.Issue)
+	case EventIssueDeleted:
+		updated, synced, err = h.applyDelete(existing, p.Issue)
+	case EventIssueLinkCreated:
+		updated, synced, err = h.applyLink(existing, p.IssueLink)
+	default:
+		h.reporter.Debug("Ignoring webhook event", "event", p.WebhookEvent)
+		return nil
+	}
+	if err != nil || updated == nil {
+		return err
+	}
+
+	problems, err := validate.Validate(updated, h.validation)
+	for _, problem := range problems {
+		h.reporter.Warn(problem.String())
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := renderer.RenderExport(updated); err != nil {
+		return fmt.Errorf("failed to render: %w", err)
+	}
+	return syncer.RecordSynced(h.dir, synced)
+}
+
+// applyIssue converts a created or updated issue and merges it in,
+// returning the merged export and the issue as converted. Issues that are
+// neither tracked nor reference a tracked issue are ignored.
+func (h *Handler) applyIssue(existing *beadspb.Export, raw json.RawMessage) (*beadspb.Export, *beadspb.Export, error) {
+	if len(raw) == 0 {
+		return nil, nil, fmt.Errorf("%w: no issue", errBadPayload)
+	}
+	issue, err := h.adapter.ParseIssue(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", errBadPayload, err)
+	}
+
+	tracked := trackedKeys(existing)
+	if !tracked[issue.Key] && !tracked[issue.Id] && !referencesAny(issue, tracked) {
+		h.reporter.Debug("Ignoring untracked issue", "key", issue.Key)
+		return nil, nil, nil
+	}
+
+	conv := converter.NewProtoConverterWithHierarchy(h.hierarchy)
+	conv.SetMapping(h.mapping)
+	for _, epic := range existing.Epics {
+		if key := epic.GetMetadata().GetJiraKey(); key != "" && key != issue.Key && !reconcile.Removed(epic.Metadata) {
+			conv.RegisterEpic(key)
+		}
+	}
+	converted, err := conv.Convert(&jirapb.Export{Issues: []*jirapb.Issue{issue}})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert %s: %w", issue.Key, err)
+	}
+
+	// The issue may have moved between issues and epics; where it hasn't,
+	// Merge replaces it, keeping local annotations
+	id := strings.ToLower(issue.Key)
+	if len(converted.Epics) > 0 {
+		existing.Issues = withoutIssue(existing.Issues, id)
+	} else {
+		existing.Epics = withoutEpic(existing.Epics, id)
+	}
+
+	// An issue moved to another project keeps its Jira ID under a new key
+	moves := reconcile.DetectMoves(existing, converted)
+	reconcile.Apply(existing, moves, h.policy)
+	for _, move := range moves {
+		h.reporter.Info("Applied webhook", "key", move.JiraKey, "moved_to", move.NewKey)
+	}
+
+	h.reporter.Info("Applied webhook", "key", issue.Key, "tracked", tracked[issue.Key])
+	return beads.Merge(existing, converted), converted, nil
+}
+
+// applyDelete applies the policy to a deleted issue, returning the export
+// and the issue as closed or tombstoned
+func (h *Handler) applyDelete(existing *beadspb.Export, raw json.RawMessage) (*beadspb.Export, *beadspb.Export, error) {
+	var issue struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(raw, &issue); err != nil || issue.Key == "" {
+		return nil, nil, fmt.Errorf("%w: no issue key", errBadPayload)
+	}
+
+	id := strings.ToLower(issue.Key)
+	if !trackedKeys(existing)[issue.Key] {
+		h.reporter.Debug("Ignoring deletion of untracked issue", "key", issue.Key)
+		return nil, nil, nil
+	}
+	reconcile.Apply(existing, []reconcile.Change{{ID: id, JiraKey: issue.Key}}, h.policy)
+	h.reporter.Info("Applied webhook", "key", issue.Key, "deleted", true)
+	return existing, withID(existing, id), nil
+}
+
+// applyLink adds a dependency for a new "blocks" or "depends on" link
+// between tracked issues, returning the export and the dependent issue
+func (h *Handler) applyLink(existing *beadspb.Export, link *issueLink) (*beadspb.Export, *beadspb.Export, error) {
+	if link == nil {
+		return nil, nil, fmt.Errorf("%w: no issue link", errBadPayload)
+	}
+
+	// Mirror the converter: the inward side of "is blocked by" and the
+	// outward side of "depends on" is the dependent issue
+	source := strconv.FormatInt(link.SourceIssueID, 10)
+	destination := strconv.FormatInt(link.DestinationIssueID, 10)
+	var dependentID, blockerID string
+	switch {
+	case link.IssueLinkType.InwardName == "is blocked by":
+		dependentID, blockerID = destination, source
+	case link.IssueLinkType.OutwardName == "depends on":
+		dependentID, blockerID = source, destination
+	default:
+		h.reporter.Debug("Ignoring link that isn't a dependency", "type", link.IssueLinkType.Name)
+		return nil, nil, nil
+	}
+
+	var dependent *beadspb.Issue
+	var blocker string
+	for _, issue := range existing.Issues {
+		if reconcile.Removed(issue.Metadata) {
+			continue
+		}
+		switch issue.GetMetadata().GetJiraId() {
+		case dependentID:
+			dependent = issue
+		case blockerID:
+			blocker = issue.Id
+		}
+	}
+	if blocker == "" {
+		for _, epic := range existing.Epics {
+			if epic.GetMetadata().GetJiraId() == blockerID && !reconcile.Removed(epic.Metadata) {
+				blocker = epic.Id
+			}
+		}
+	}
+	if dependent == nil || blocker == "" {
+		h.reporter.Debug("Ignoring link between untracked issues", "source", source, "destination", destination)
+		return nil, nil, nil
+	}
+
+	for _, id := range dependent.DependsOn {
+		if id == blocker {
+			return nil, nil, nil
+		}
+	}
+	dependent.DependsOn = append(dependent.DependsOn, blocker)
+	h.reporter.Info("Applied webhook", "issue", dependent.Id, "depends_on", blocker)
+	return existing, &beadspb.Export{Issues: []*beadspb.Issue{dependent}}, nil
+}
+
+// withID returns the issues and epics in an export with the given ID
+func withID(export *beadspb.Export, id string) *beadspb.Export {
+	found := &beadspb.Export{}
+	for _, issue := range export.Issues {
+		if issue.Id == id {
+			found.Issues = append(found.Issues, issue)
+		}
+	}
+	for _, epic := range export.Epics {
+		if epic.Id == id {
+			found.Epics = append(found.Epics, epic)
+		}
+	}
+	return found
+}
+
+// trackedKeys returns the Jira keys and IDs of every issue and epic in an
+// export that is still synced
+func trackedKeys(export *beadspb.Export) map[string]bool {
+	keys := make(map[string]bool)
+	add := func(metadata *beadspb.Metadata) {
+		if reconcile.Removed(metadata) {
+			return
+		}
+		if key := metadata.GetJiraKey(); key != "" {
+			keys[key] = true
+		}
+		if id := metadata.GetJiraId(); id != "" {
+			keys[id] = true
+		}
+	}
+	for _, issue := range export.Issues {
+		add(issue.Metadata)
+	}
+	for _, epic := range export.Epics {
+		add(epic.Metadata)
+	}
+	return keys
+}
+
+// referencesAny reports whether an issue's parent, epic, subtasks or links
+// include any of the keys
+func referencesAny(issue *jirapb.Issue, keys map[string]bool) bool {
+	fields := issue.Fields
+	if fields.Parent != nil && keys[fields.Parent.Key] {
+		return true
+	}
+	if fields.Epic != nil && keys[fields.Epic.Key] {
+		return true
+	}
+	for _, subtask := range fields.Subtasks {
+		if keys[subtask.Key] {
+			return true
+		}
+	}
+	for _, link := range fields.IssueLinks {
+		if link.InwardIssue != nil && keys[link.InwardIssue.Key] {
+			return true
+		}
+		if link.OutwardIssue != nil && keys[link.OutwardIssue.Key] {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutIssue returns issues without the one with the given ID
+func withoutIssue(issues []*beadspb.Issue, id string) []*beadspb.Issue {
+	kept := make([]*beadspb.Issue, 0, len(issues))
+	for _, issue := range issues {
+		if issue.Id != id {
+			kept = append(kept, issue)
+		}
+	}
+	return kept
+}
+
+// withoutEpic returns epics without the one with the given ID
+func withoutEpic(epics []*beadspb.Epic, id string) []*beadspb.Epic {
+	kept := make([]*beadspb.Epic, 0, len(epics))
+	for _, epic := range epics {
+		if epic.Id != id {
+			kept = append(kept, epic)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,292 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+	"github.com/conallob/jira-beads-sync/internal/converter"
+	"github.com/conallob/jira-beads-sync/internal/jira"
+	"github.com/conallob/jira-beads-sync/internal/reconcile"
+	"github.com/conallob/jira-beads-sync/internal/validate"
+)
+
+const testSecret = "s3cret"
+
+// post sends a recorded payload, signed with secret
+func post(t *testing.T, server *httptest.Server, name, secret string) int {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read payload: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(Sign([]byte(secret), body)))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode
+}
+
+// readIssues returns the issues in dir/.beads by ID
+func readIssues(t *testing.T, dir string) map[string]*beadspb.Issue {
+	t.Helper()
+	export, err := beads.NewJSONLRenderer(dir).ReadExport()
+	if err != nil {
+		t.Fatalf("ReadExport failed: %v", err)
+	}
+	issues := make(map[string]*beadspb.Issue)
+	for _, issue := range export.Issues {
+		issues[issue.Id] = issue
+	}
+	return issues
+}
+
+func TestHandler(t *testing.T) {
+	dir := t.TempDir()
+	existing := &beadspb.Export{Issues: []*beadspb.Issue{
+		{Id: "proj-1", Title: "Design the schema", Metadata: &beadspb.Metadata{
+			JiraKey: "PROJ-1", JiraId: "10001", Custom: map[string]string{"repositories": "https://github.com/org/repo"},
+		}},
+		{Id: "proj-2", Title: "Provision the database", Metadata: &beadspb.Metadata{JiraKey: "PROJ-2", JiraId: "10002"}},
+	}}
+	if err := beads.NewJSONLRenderer(dir).RenderExport(existing); err != nil {
+		t.Fatalf("RenderExport failed: %v", err)
+	}
+
+	handler := NewHandler(dir, testSecret, jira.DefaultHierarchy(), jira.NewAdapter())
+	handler.SetPolicy(reconcile.PolicyDelete)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	// A new issue blocked by a tracked one is added
+	if status := post(t, server, "issue_created.json", testSecret); status != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", status)
+	}
+	issues := readIssues(t, dir)
+	if got := issues["proj-3"]; got == nil || len(got.DependsOn) != 1 || got.DependsOn[0] != "proj-1" {
+		t.Fatalf("Expected proj-3 depending on proj-1, got %v", got)
+	}
+
+	// Updates replace the tracked issue
+	if status := post(t, server, "issue_updated.json", testSecret); status != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", status)
+	}
+	issues = readIssues(t, dir)
+	if got := issues["proj-1"]; got.Title != "Design the schema, v2" || got.Status != beadspb.Status_STATUS_IN_PROGRESS {
+		t.Errorf("Expected proj-1 to be updated, got %q %v", got.Title, got.Status)
+	}
+	if got := issues["proj-1"].Metadata.Custom["repositories"]; got != "https://github.com/org/repo" {
+		t.Errorf("Expected the repository annotation to be kept, got %q", got)
+	}
+
+	// PROJ-2 blocks PROJ-1, by issue ID
+	if status := post(t, server, "issuelink_created.json", testSecret); status != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", status)
+	}
+	issues = readIssues(t, dir)
+	if deps := issues["proj-1"].DependsOn; len(deps) != 1 || deps[0] != "proj-2" {
+		t.Errorf("Expected proj-1 to depend on proj-2, got %v", deps)
+	}
+
+	// Deleting PROJ-2 removes it and the dependency on it
+	if status := post(t, server, "issue_deleted.json", testSecret); status != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", status)
+	}
+	issues = readIssues(t, dir)
+	if _, ok := issues["proj-2"]; ok || len(issues) != 2 {
+		t.Errorf("Expected proj-2 to be deleted, got %v", issues)
+	}
+	if deps := issues["proj-1"].DependsOn; len(deps) != 0 {
+		t.Errorf("Expected the dependency on proj-2 to be removed, got %v", deps)
+	}
+}
+
+func TestHandlerRemovedPolicy(t *testing.T) {
+	dir := t.TempDir()
+	existing := &beadspb.Export{Issues: []*beadspb.Issue{
+		{Id: "proj-1", Title: "Design the schema", DependsOn: []string{"proj-2"}, Metadata: &beadspb.Metadata{JiraKey: "PROJ-1", JiraId: "10001"}},
+		{Id: "proj-2", Title: "Provision the database", Metadata: &beadspb.Metadata{JiraKey: "PROJ-2", JiraId: "10002"}},
+	}}
+	if err := beads.NewJSONLRenderer(dir).RenderExport(existing); err != nil {
+		t.Fatalf("RenderExport failed: %v", err)
+	}
+
+	server := httptest.NewServer(NewHandler(dir, testSecret, jira.DefaultHierarchy(), jira.NewAdapter()))
+	defer server.Close()
+
+	// PROJ-2 moves to OPS-7: the old issue is closed, and proj-1 follows it
+	if status := post(t, server, "issue_moved.json", testSecret); status != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", status)
+	}
+	issues := readIssues(t, dir)
+	if got := issues["ops-7"]; got == nil || got.Title != "Provision the database" {
+		t.Fatalf("Expected ops-7 to be added, got %v", got)
+	}
+	if got := issues["proj-2"]; got.Status != beadspb.Status_STATUS_CLOSED || got.Metadata.Custom[reconcile.RemovedKey] != "moved to OPS-7" {
+		t.Errorf("Expected proj-2 to be closed as moved, got %v", got)
+	}
+	if deps := issues["proj-1"].DependsOn; len(deps) != 1 || deps[0] != "ops-7" {
+		t.Errorf("Expected proj-1 to depend on ops-7, got %v", deps)
+	}
+
+	// The old key is no longer synced, so its deletion changes nothing
+	if status := post(t, server, "issue_deleted.json", testSecret); status != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", status)
+	}
+	if got := readIssues(t, dir)["proj-2"].Metadata.Custom[reconcile.RemovedKey]; got != "moved to OPS-7" {
+		t.Errorf("Expected the moved issue to be left alone, got %q", got)
+	}
+}
+
+func TestHandlerCustomFields(t *testing.T) {
+	dir := t.TempDir()
+	existing := &beadspb.Export{
+		Issues: []*beadspb.Issue{
+			{Id: "proj-1", Title: "Design the schema", Metadata: &beadspb.Metadata{JiraKey: "PROJ-1", JiraId: "10001"}},
+		},
+		Epics: []*beadspb.Epic{
+			{Id: "proj-10", Name: "Launch the platform", Metadata: &beadspb.Metadata{JiraKey: "PROJ-10", JiraId: "10010"}},
+		},
+	}
+	if err := beads.NewJSONLRenderer(dir).RenderExport(existing); err != nil {
+		t.Fatalf("RenderExport failed: %v", err)
+	}
+
+	hierarchy := jira.DefaultHierarchy()
+	hierarchy.ParentLinkField = "customfield_10500"
+	adapter := jira.NewAdapter()
+	adapter.ParentLinkField = hierarchy.ParentLinkField
+	adapter.StoryPointsField = "customfield_10016"
+	handler := NewHandler(dir, testSecret, hierarchy, adapter)
+	handler.SetMapping(converter.Mapping{Statuses: map[string]beadspb.Status{"In Progress": beadspb.Status_STATUS_BLOCKED}})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	// The Data Center Parent Link puts PROJ-1 in the epic
+	if status := post(t, server, "issue_parent_link.json", testSecret); status != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", status)
+	}
+	got := readIssues(t, dir)["proj-1"]
+	if got.Epic != "proj-10" {
+		t.Errorf("Expected proj-1 in epic proj-10, got %q", got.Epic)
+	}
+	if points := got.Metadata.Custom[beads.PointsKey]; points != "5" {
+		t.Errorf("Expected 5 story points, got %q", points)
+	}
+	if got.Status != beadspb.Status_STATUS_BLOCKED {
+		t.Errorf("Expected the status mapping to apply, got %v", got.Status)
+	}
+}
+
+func TestHandlerValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       validate.Mode
+		wantStatus int
+		wantTitle  string
+		wantDeps   int
+	}{
+		{name: "fail", mode: validate.ModeFail, wantStatus: http.StatusInternalServerError, wantTitle: "Design the schema", wantDeps: 1},
+		{name: "drop", mode: validate.ModeDrop, wantStatus: http.StatusNoContent, wantTitle: "Design the schema, v2", wantDeps: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			existing := &beadspb.Export{Issues: []*beadspb.Issue{
+				{Id: "proj-1", Title: "Design the schema", Metadata: &beadspb.Metadata{JiraKey: "PROJ-1", JiraId: "10001"}},
+				{Id: "proj-2", Title: "Provision the database", DependsOn: []string{"gone-1"}, Metadata: &beadspb.Metadata{JiraKey: "PROJ-2", JiraId: "10002"}},
+			}}
+			if err := beads.NewJSONLRenderer(dir).RenderExport(existing); err != nil {
+				t.Fatalf("RenderExport failed: %v", err)
+			}
+
+			handler := NewHandler(dir, testSecret, jira.DefaultHierarchy(), jira.NewAdapter())
+			handler.SetValidation(tt.mode)
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			// proj-2's dependency on the missing gone-1 is in the merged export
+			if status := post(t, server, "issue_updated.json", testSecret); status != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d", tt.wantStatus, status)
+			}
+			issues := readIssues(t, dir)
+			if got := issues["proj-1"].Title; got != tt.wantTitle {
+				t.Errorf("Expected proj-1 titled %q, got %q", tt.wantTitle, got)
+			}
+			if deps := issues["proj-2"].DependsOn; len(deps) != tt.wantDeps {
+				t.Errorf("Expected %d dependencies on proj-2, got %v", tt.wantDeps, deps)
+			}
+		})
+	}
+}
+
+func TestHandlerIgnoresUntracked(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(NewHandler(dir, testSecret, jira.DefaultHierarchy(), jira.NewAdapter()))
+	defer server.Close()
+
+	// Nothing is tracked, so the new issue's link to PROJ-1 doesn't count
+	if status := post(t, server, "issue_created.json", testSecret); status != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", status)
+	}
+	if issues := readIssues(t, dir); len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+}
+
+func TestHandlerRejects(t *testing.T) {
+	server := httptest.NewServer(NewHandler(t.TempDir(), testSecret, jira.DefaultHierarchy(), jira.NewAdapter()))
+	defer server.Close()
+
+	if status := post(t, server, "issue_created.json", "wrong"); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a bad signature, got %d", status)
+	}
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET, got %d", resp.StatusCode)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"webhookEvent":"jira:issue_updated"}`)
+	valid := "sha256=" + hex.EncodeToString(Sign([]byte(testSecret), body))
+
+	tests := []struct {
+		name      string
+		signature string
+		wantErr   bool
+	}{
+		{"valid", valid, false},
+		{"missing", "", true},
+		{"wrong scheme", "sha1=" + valid[len("sha256="):], true},
+		{"not hex", "sha256=zz", true},
+		{"tampered", valid[:len(valid)-2] + "00", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify([]byte(testSecret), body, tt.signature)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+// Package jirabeads embeds the Jira to beads conversion in other programs,
+// such as bots, without shelling out to the jira-beads-sync binary.
+//
+// A sync is a pipeline of fetch, convert, merge and render:
+//
+//	client, err := jirabeads.NewClient(jirabeads.Options{
+//		BaseURL:  "https://example.atlassian.net",
+//		Username: "bot@example.com",
+//		Token:    token,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	fetched, err := client.FetchIssue(ctx, "PROJ-123")
+//	if err != nil {
+//		return err
+//	}
+//	converted, err := jirabeads.Convert(fetched, jirabeads.Mapping{})
+//	if err != nil {
+//		return err
+//	}
+//	existing, err := jirabeads.Read(dir)
+//	if err != nil {
+//		return err
+//	}
+//	return jirabeads.Render(dir, jirabeads.Merge(existing, converted))
+package jirabeads
+
+import (
+	"context"
+	"fmt"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+	jirapb "github.com/conallob/jira-beads-sync/gen/jira"
+	"github.com/conallob/jira-beads-sync/internal/beads"
+	"github.com/conallob/jira-beads-sync/internal/converter"
+	"github.com/conallob/jira-beads-sync/internal/graph"
+	"github.com/conallob/jira-beads-sync/internal/jira"
+)
+
+// Errors returned, possibly wrapped, when Jira rejects a request. Check
+// them with errors.Is.
+var (
+	ErrUnauthorized = jira.ErrUnauthorized
+	ErrForbidden    = jira.ErrForbidden
+	ErrNotFound     = jira.ErrNotFound
+	ErrConflict     = jira.ErrConflict
+)
+
+// Options configures a Client
+type Options struct {
+	// BaseURL is the Jira site, e.g. "https://example.atlassian.net"
+	BaseURL string
+	// AuthType is "basic" (the default, for Jira Cloud API tokens),
+	// "bearer" (Data Center personal access tokens) or "cookie"
+	AuthType string
+	// Username is the account email or username for basic and cookie auth
+	Username string
+	// Token is the API token, personal access token or password
+	Token string
+
+	// Traversal limits how far dependencies are followed
+	Traversal Traversal
+	// Mapping selects which issue types become epics when fetching
+	Mapping Mapping
+	// Reporter receives log messages and progress; nil discards them
+	Reporter Reporter
+}
+
+// Traversal limits dependency crawling. The zero value follows everything.
+type Traversal struct {
+	// MaxDepth is the number of hops followed from a requested issue;
+	// 0 means unlimited
+	MaxDepth int
+	// LinkTypes restricts which issue link types (e.g., "Blocks") are
+	// followed; empty follows all
+	LinkTypes []string
+	// Direction is "inward", "outward" or "both" (the default)
+	Direction string
+	// Projects restricts crawling to these project keys; empty allows all
+	Projects []string
+}
+
+// Mapping controls how Jira issues become beads issues and epics. The zero
+// value uses the same defaults as the CLI.
+type Mapping struct {
+	// EpicTypes are issue type names that become epics; empty means "Epic"
+	EpicTypes []string
+	// EpicLevel is the lowest Jira Cloud hierarchy level that becomes an
+	// epic; nil means 1, the epic level, and 0 only matches EpicTypes
+	EpicLevel *int32
+	// ParentLinkField is the Advanced Roadmaps Parent Link custom field ID
+	// on Jira Data Center, e.g. "customfield_10500"
+	ParentLinkField string
+	// StoryPointsField is the story points custom field ID, e.g.
+	// "customfield_10016"; story points aren't fetched without it
+	StoryPointsField string
+
+	// Statuses maps Jira status names to "open", "in_progress", "blocked"
+	// or "closed", overriding the mapping by status category
+	Statuses map[string]string
+	// Priorities maps Jira priority names to "p0" through "p4"
+	Priorities map[string]string
+}
+
+// Reporter receives log messages, with slog-style key-value arguments, and
+// progress as issues are fetched
+type Reporter interface {
+	Trace(msg string, args ...any)
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Progress(label string, done, total int)
+}
+
+// Client fetches issues and their dependencies from Jira. It is safe for
+// concurrent use.
+type Client struct {
+	jira *jira.Client
+}
+
+// NewClient creates a client for the Jira site in opts
+func NewClient(opts Options) (*Client, error) {
+	if opts.BaseURL == "" {
+		return nil, fmt.Errorf("jira base URL is required")
+	}
+	direction, err := jira.ParseLinkDirection(opts.Traversal.Direction)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := jira.NewAuthenticator(opts.AuthType, opts.BaseURL, opts.Username, opts.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	client := jira.NewClientWithAuth(opts.BaseURL, auth)
+	client.SetTraversalOptions(jira.TraversalOptions{
+		MaxDepth:  opts.Traversal.MaxDepth,
+		LinkTypes: opts.Traversal.LinkTypes,
+		Direction: direction,
+		Projects:  opts.Traversal.Projects,
+	})
+	client.SetHierarchy(opts.Mapping.hierarchy())
+	client.SetStoryPointsField(opts.Mapping.StoryPointsField)
+	if opts.Reporter != nil {
+		client.SetReporter(opts.Reporter)
+	}
+
+	return &Client{jira: client}, nil
+}
+
+// FetchIssue fetches an issue and its dependencies. References that can't be
+// fetched or fall outside the traversal limits are listed in the export's
+// Skipped issues.
+func (c *Client) FetchIssue(ctx context.Context, issueKey string) (*jirapb.Export, error) {
+	return c.jira.WithContext(ctx).FetchIssueWithDependencies(issueKey)
+}
+
+// FetchJQL fetches the issues matching a JQL query and their dependencies
+func (c *Client) FetchJQL(ctx context.Context, jql string) (*jirapb.Export, error) {
+	return c.jira.WithContext(ctx).FetchIssuesByJQL(jql)
+}
+
+// FetchLabel fetches the issues with a label and their dependencies
+func (c *Client) FetchLabel(ctx context.Context, label string) (*jirapb.Export, error) {
+	return c.jira.WithContext(ctx).FetchIssuesByLabel(label)
+}
+
+// FetchFilter fetches the issues matching a saved filter and their
+// dependencies
+func (c *Client) FetchFilter(ctx context.Context, filterID string) (*jirapb.Export, error) {
+	return c.jira.WithContext(ctx).FetchIssuesByFilter(filterID)
+}
+
+// Convert converts fetched Jira issues to beads issues and epics
+func Convert(export *jirapb.Export, mapping Mapping) (*beadspb.Export, error) {
+	converterMapping, err := converter.ParseMapping(mapping.Statuses, mapping.Priorities)
+	if err != nil {
+		return nil, err
+	}
+
+	conv := converter.NewProtoConverterWithHierarchy(mapping.hierarchy())
+	conv.SetMapping(converterMapping)
+	return conv.Convert(export)
+}
+
+// Render writes beads issues and epics to dir/.beads, replacing the JSONL
+// files there
+func Render(dir string, export *beadspb.Export) error {
+	return beads.NewJSONLRenderer(dir).RenderExport(export)
+}
+
+// Read reads the beads issues and epics in dir/.beads. A directory without
+// them yields an empty export.
+func Read(dir string) (*beadspb.Export, error) {
+	return beads.NewJSONLRenderer(dir).ReadExport()
+}
+
+// Merge combines freshly converted issues into existing ones: issues and
+// epics in update replace those with the same ID, keeping local
+// annotations such as repositories, and the rest of base is kept
+func Merge(base, update *beadspb.Export) *beadspb.Export {
+	return beads.Merge(base, update)
+}
+
+// Analysis lists the ready and blocked issues of an export, a work order
+// and its critical path
+type Analysis = graph.Analysis
+
+// Analyze finds which issues are ready, what the rest wait on, an order
+// that puts dependencies first and the critical path, weighed by story
+// points if byPoints is set and by issue count otherwise
+func Analyze(export *beadspb.Export, byPoints bool) *Analysis {
+	weight := graph.Count
+	if byPoints {
+		weight = graph.Points
+	}
+	return graph.Analyze(export, weight)
+}
+
+// hierarchy applies the mapping's epic settings over the defaults
+func (m Mapping) hierarchy() jira.Hierarchy {
+	hierarchy := jira.DefaultHierarchy()
+	if len(m.EpicTypes) > 0 {
+		hierarchy.EpicTypes = m.EpicTypes
+	}
+	if m.EpicLevel != nil {
+		hierarchy.EpicLevel = *m.EpicLevel
+	}
+	hierarchy.ParentLinkField = m.ParentLinkField
+	return hierarchy
+}
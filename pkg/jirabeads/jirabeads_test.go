@@ -0,0 +1,188 @@
+package jirabeads
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	beadspb "github.com/conallob/jira-beads-sync/gen/beads"
+)
+
+// newJiraServer serves PROJ-1, which is blocked by PROJ-2, estimated at
+// 3 story points
+func newJiraServer(t *testing.T) *httptest.Server {
+	issue := func(key, status string, links []interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"key": key,
+			"id":  key + "-id",
+			"fields": map[string]interface{}{
+				"summary":    "Issue " + key,
+				"issuetype":  map[string]interface{}{"name": "Task"},
+				"status":     map[string]interface{}{"name": status, "statusCategory": map[string]interface{}{"key": "indeterminate"}},
+				"priority":   map[string]interface{}{"name": "Blocker"},
+				"issuelinks": links,
+				"created":    "2024-01-01T10:00:00.000+0000",
+				"updated":    "2024-01-01T10:00:00.000+0000",
+			},
+		}
+	}
+	issues := map[string]interface{}{
+		"PROJ-1": issue("PROJ-1", "In Review", []interface{}{
+			map[string]interface{}{
+				"type":        map[string]interface{}{"name": "Blocks", "inward": "is blocked by", "outward": "blocks"},
+				"inwardIssue": map[string]interface{}{"key": "PROJ-2"},
+			},
+		}),
+		"PROJ-2": issue("PROJ-2", "In Progress", nil),
+	}
+	issues["PROJ-2"].(map[string]interface{})["fields"].(map[string]interface{})["customfield_10016"] = 3
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/")
+		issue, ok := issues[key]
+		if !ok {
+			http.Error(w, `{"errorMessages":["Issue does not exist"]}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(issue); err != nil {
+			t.Errorf("Failed to encode issue: %v", err)
+		}
+	}))
+}
+
+func TestSync(t *testing.T) {
+	server := newJiraServer(t)
+	defer server.Close()
+
+	client, err := NewClient(Options{
+		BaseURL:  server.URL,
+		Username: "bot@example.com",
+		Token:    "token",
+		Mapping:  Mapping{StoryPointsField: "customfield_10016"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	fetched, err := client.FetchIssue(context.Background(), "PROJ-1")
+	if err != nil {
+		t.Fatalf("FetchIssue failed: %v", err)
+	}
+	if len(fetched.Issues) != 2 {
+		t.Fatalf("Expected PROJ-1 and its blocker, got %d issues", len(fetched.Issues))
+	}
+
+	converted, err := Convert(fetched, Mapping{
+		Statuses:   map[string]string{"In Review": "blocked"},
+		Priorities: map[string]string{"Blocker": "p0"},
+	})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	existing := &beadspb.Export{Issues: []*beadspb.Issue{{Id: "other-1", Title: "Kept"}}}
+	if err := Render(dir, Merge(existing, converted)); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	read, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	byID := make(map[string]*beadspb.Issue)
+	for _, issue := range read.Issues {
+		byID[issue.Id] = issue
+	}
+	if len(byID) != 3 || byID["other-1"] == nil {
+		t.Fatalf("Expected the fetched issues merged with other-1, got %v", read.Issues)
+	}
+	if got := byID["proj-1"]; got.Status != beadspb.Status_STATUS_BLOCKED || got.Priority != beadspb.Priority_PRIORITY_P0 {
+		t.Errorf("Expected the mapping to apply to proj-1, got %v %v", got.Status, got.Priority)
+	}
+	if got := byID["proj-2"]; got.Status != beadspb.Status_STATUS_IN_PROGRESS {
+		t.Errorf("Expected proj-2 to use the built-in mapping, got %v", got.Status)
+	}
+	if deps := byID["proj-1"].DependsOn; len(deps) != 1 || deps[0] != "proj-2" {
+		t.Errorf("Expected proj-1 to depend on proj-2, got %v", deps)
+	}
+
+	analysis := Analyze(read, true)
+	if ready := strings.Join(analysis.Ready, ","); ready != "proj-2,other-1" && ready != "other-1,proj-2" {
+		t.Errorf("Expected proj-2 and other-1 ready, got %v", analysis.Ready)
+	}
+	if path := strings.Join(analysis.CriticalPath, ","); path != "proj-2,proj-1" || analysis.Length != 3 {
+		t.Errorf("Expected critical path proj-2,proj-1 of 3 points, got %v of %v", analysis.CriticalPath, analysis.Length)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	server := newJiraServer(t)
+	defer server.Close()
+
+	client, err := NewClient(Options{BaseURL: server.URL, AuthType: "bearer", Token: "pat"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	if _, err := client.FetchIssue(context.Background(), "PROJ-404"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.FetchIssue(ctx, "PROJ-1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestOptionsValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"missing base URL", Options{}, "base URL is required"},
+		{"unknown auth type", Options{BaseURL: "https://jira.example.com", AuthType: "kerberos"}, "unsupported auth type"},
+		{"invalid direction", Options{BaseURL: "https://jira.example.com", Traversal: Traversal{Direction: "up"}}, "invalid link direction"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, err := Convert(nil, Mapping{Statuses: map[string]string{"Done": "finished"}}); err == nil {
+		t.Error("Expected an error for an invalid status mapping")
+	}
+}
+
+func TestMappingHierarchy(t *testing.T) {
+	zero, two := int32(0), int32(2)
+	tests := []struct {
+		name      string
+		epicLevel *int32
+		want      int32
+	}{
+		{name: "default", want: 1},
+		{name: "disabled", epicLevel: &zero, want: 0},
+		{name: "raised", epicLevel: &two, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Mapping{EpicLevel: tt.epicLevel}).hierarchy().EpicLevel; got != tt.want {
+				t.Errorf("Expected epic level %d, got %d", tt.want, got)
+			}
+		})
+	}
+}